@@ -0,0 +1,83 @@
+// Package cli implements the 4hex command line: generating, rendering,
+// exporting, validating, comparing, inspecting and exploring hex maps, and
+// playing back recorded games.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+// env carries the streams commands write to.
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run parses args, runs the chosen subcommand and returns the process exit
+// code: ExitUsage for invalid arguments and ExitFailure when the command fails.
+func Run(args []string, stdout, stderr io.Writer) int {
+	e := &env{stdout: stdout, stderr: stderr}
+	parser := newParser(e)
+	_, err := parser.ParseArgs(args)
+	if err == nil {
+		return ExitOK
+	}
+	var flagsErr *flags.Error
+	if errors.As(err, &flagsErr) {
+		if flagsErr.Type == flags.ErrHelp {
+			fmt.Fprintln(stdout, flagsErr.Message)
+			return ExitOK
+		}
+		fmt.Fprintln(stderr, flagsErr.Message)
+		return ExitUsage
+	}
+	fmt.Fprintln(stderr, "error:", err)
+	return ExitFailure
+}
+
+// newParser builds the parser with every subcommand registered.
+func newParser(e *env) *flags.Parser {
+	parser := flags.NewNamedParser("4hex", flags.HelpFlag|flags.PassDoubleDash)
+	commands := []struct {
+		name, short, long string
+		data              any
+	}{
+		{"generate", "Generate a map", "Generate a map from a seed and write it to a file.", &generateCommand{env: e}},
+		{"render", "Render a map", "Draw a map as ASCII, colored or compact text, SVG or PNG.", &renderCommand{env: e}},
+		{"export", "Convert a map to another format", "Write a map as JSON, binary, compact text or a Tiled map.", &exportCommand{env: e}},
+		{"validate", "Check a map for playability", "Run playability rules over a map; exits non-zero on violations.", &validateCommand{env: e}},
+		{"diff", "Compare two maps", "Report added and removed layers and changed cells between two maps, or draw the changes.", &diffCommand{env: e}},
+		{"inspect", "Describe a map", "Print a map's size, topology, layers, values and units.", &inspectCommand{env: e}},
+		{"replay", "Step through a replay", "Play back a recorded game, drawing the map at the start of every turn.", &replayCommand{env: e}},
+		{"play", "Explore a map interactively", "Open a map, or a newly generated one, in the terminal explorer.", &playCommand{env: e}},
+	}
+	for _, c := range commands {
+		if _, err := parser.AddCommand(c.name, c.short, c.long, c.data); err != nil {
+			panic(err)
+		}
+	}
+	// No command takes more positional arguments than it declares, so
+	// leftovers are a usage error rather than silently ignored.
+	parser.CommandHandler = func(cmd flags.Commander, args []string) error {
+		if len(args) > 0 {
+			return &flags.Error{Type: flags.ErrUnknown, Message: fmt.Sprintf("unexpected argument(s): %s", strings.Join(args, " "))}
+		}
+		if cmd == nil {
+			return nil
+		}
+		return cmd.Execute(args)
+	}
+	return parser
+}
@@ -0,0 +1,376 @@
+package cli
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/klumhru/4hex/game"
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run runs the CLI and returns its exit code, stdout and stderr.
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// generateMap writes a small generated map into dir and returns its path.
+func generateMap(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, "map.json")
+	args = append([]string{"generate", "--seed", "7", "--width", "12", "--height", "8", "-o", path}, args...)
+	code, _, stderr := run(args...)
+	require.Equal(t, ExitOK, code, stderr)
+	return path
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	code, first, _ := run("generate", "--seed", "42", "--width", "10", "--height", "6")
+	require.Equal(t, ExitOK, code)
+	_, second, _ := run("generate", "--seed", "42", "--width", "10", "--height", "6")
+	assert.Equal(t, first, second)
+	_, other, _ := run("generate", "--seed", "43", "--width", "10", "--height", "6")
+	assert.NotEqual(t, first, other)
+
+	m, err := hex.UnmarshalMap([]byte(first))
+	require.NoError(t, err)
+	w, h := m.GetDimensions()
+	assert.Equal(t, []int{10, 6}, []int{w, h})
+	_, err = m.GetGridByName("terrain")
+	assert.NoError(t, err)
+}
+
+func TestGenerate_RandomSeedIsReported(t *testing.T) {
+	code, _, stderr := run("generate", "--width", "4", "--height", "4")
+	require.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "seed: ")
+
+	// Seed 0 is a seed like any other.
+	code, first, stderr := run("generate", "--seed", "0", "--width", "6", "--height", "4")
+	require.Equal(t, ExitOK, code)
+	assert.NotContains(t, stderr, "seed: ")
+	_, second, _ := run("generate", "--seed", "0", "--width", "6", "--height", "4")
+	assert.Equal(t, first, second)
+}
+
+func TestGenerate_Options(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.hexm")
+	code, _, stderr := run("generate", "--seed", "1", "--generator", "shape", "--shape", "circle", "--topology", "cylinder", "--width", "9", "--height", "9", "-o", path)
+	require.Equal(t, ExitOK, code, stderr)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte(hex.MapMagic)))
+
+	loaded, err := loadMap(path)
+	require.NoError(t, err)
+	assert.Equal(t, hex.Cylinder, loaded.Map.GetTopology())
+
+	// An unsupported output format neither creates nor truncates the file.
+	png := filepath.Join(dir, "map.png")
+	code, _, stderr = run("generate", "--seed", "1", "-o", png)
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, `unsupported map format "png"`)
+	assert.NoFileExists(t, png)
+	require.NoError(t, os.WriteFile(png, []byte("keep"), 0o644))
+	code, _, _ = run("generate", "--seed", "1", "-o", png)
+	assert.Equal(t, ExitFailure, code)
+	data, err = os.ReadFile(png)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(data))
+
+	code, _, _ = run("generate", "--width", "0")
+	assert.Equal(t, ExitFailure, code)
+	code, _, _ = run("generate", "--shape", "blob")
+	assert.Equal(t, ExitUsage, code)
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	path := generateMap(t, dir)
+
+	code, out, _ := run("render", "--format", "ascii", path)
+	require.Equal(t, ExitOK, code)
+	assert.True(t, strings.HasPrefix(out, " / \\ / \\"), out)
+
+	code, out, _ = run("render", "--color", "none", "--legend", path)
+	require.Equal(t, ExitOK, code)
+	assert.Contains(t, out, "water (0)")
+	assert.NotContains(t, out, "\x1b[")
+
+	code, out, _ = run("render", "--format", "compact", "--orientation", "flat", path)
+	require.Equal(t, ExitOK, code)
+	assert.Contains(t, out, "layout: flat-odd\n")
+
+	svg := filepath.Join(dir, "map.svg")
+	code, _, _ = run("render", "--labels", "-o", svg, path)
+	require.Equal(t, ExitOK, code)
+	data, err := os.ReadFile(svg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<svg")
+
+	pngPath := filepath.Join(dir, "map.png")
+	code, _, _ = run("render", "--scale", "4", "-o", pngPath, path)
+	require.Equal(t, ExitOK, code)
+	f, err := os.Open(pngPath)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = png.Decode(f)
+	assert.NoError(t, err)
+
+	code, _, stderr := run("render", "--layer", "missing", path)
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, "missing")
+
+	// The output format is checked before the map is read.
+	code, _, stderr = run("render", "-o", filepath.Join(dir, "render.json"), filepath.Join(dir, "missing.hexm"))
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, `unsupported render format "json"`)
+	assert.NoFileExists(t, filepath.Join(dir, "render.json"))
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	path := generateMap(t, dir)
+	original, err := loadMap(path)
+	require.NoError(t, err)
+
+	for _, name := range []string{"map.tmx", "map.tmj", "map.hexm", "copy.json", "map.txt"} {
+		out := filepath.Join(dir, name)
+		code, _, stderr := run("export", "--orientation", "flat", "--parity", "even", "-o", out, path)
+		require.Equal(t, ExitOK, code, stderr)
+
+		loaded, err := loadMap(out)
+		require.NoError(t, err, name)
+		want, err := original.Map.GetGridByName("terrain")
+		require.NoError(t, err)
+		got, err := loaded.Map.GetGridByName("terrain")
+		require.NoError(t, err, name)
+		for i := 0; i < want.GetCellCount(); i++ {
+			wc, _ := want.GetCellAtIndex(i)
+			gc, _ := got.GetCellAtIndex(i)
+			require.NotNil(t, gc, "%s cell %d", name, i)
+			assert.Equal(t, wc.GetValue(), gc.GetValue(), "%s cell %d", name, i)
+		}
+	}
+
+	code, _, stderr := run("export", path)
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, "format")
+
+	code, _, stderr = run("export", "-o", filepath.Join(dir, "map.svg"), path)
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, `unsupported export format "svg"`)
+	assert.NoFileExists(t, filepath.Join(dir, "map.svg"))
+
+	// A failed write leaves the existing file and no temporary file behind.
+	out := filepath.Join(dir, "copy.json")
+	before, err := os.ReadFile(out)
+	require.NoError(t, err)
+	code, _, _ = run("export", "-f", "compact", "-l", "missing", "-o", out, path)
+	assert.Equal(t, ExitFailure, code)
+	after, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.False(t, strings.HasPrefix(e.Name(), "."), "temporary file %s left behind", e.Name())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.txt")
+	// Two islands and a one-hex lake.
+	require.NoError(t, os.WriteFile(path, []byte("name: terrain\n--\n1 1 0 1\n 1 0 1 1\n0 1 1 1\n"), 0o644))
+
+	code, out, _ := run("validate", path)
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, out, "orphan-lake: lake of 1 hexes")
+
+	code, out, _ = run("validate", "--rule", "land-connected", path)
+	assert.Equal(t, ExitOK, code, out)
+
+	code, _, _ = run("validate", "--terrain", "missing", path)
+	assert.Equal(t, ExitFailure, code)
+}
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	m := hex.NewMap(3, 1)
+	cells := [][]hex.Cell{{hex.NewCellWithValue(0, 0, 1), hex.NewCellWithValue(1, 0, 1), nil}}
+	require.NoError(t, m.AddGrid(hex.NewGrid(hex.NewPosition(0, 0), "terrain", 3, 1, cells)))
+	g := game.NewGame()
+	g.SetMap(m)
+	p := game.NewPlayer("red")
+	scout := hex.NewUnit("scout")
+	scout.Move(hex.NewPosition(1, 0))
+	p.AddUnit(scout)
+	g.AddPlayer(p)
+	path := filepath.Join(dir, "save.4hx")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, g.Save(f))
+	require.NoError(t, f.Close())
+
+	code, out, stderr := run("inspect", path)
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Contains(t, out, "format:   game\n")
+	assert.Contains(t, out, "size:     3x1\n")
+	assert.Contains(t, out, "terrain: at 0,0 size 3x1, 2 cells, values (value:count) 1:2\n")
+	assert.Contains(t, out, "red: 1 unit(s)\n    scout at 1,0\n")
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, stderr := run()
+	assert.Equal(t, ExitUsage, code)
+	assert.NotEmpty(t, stderr)
+
+	code, _, _ = run("bogus")
+	assert.Equal(t, ExitUsage, code)
+
+	code, out, _ := run("--help")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, out, "generate")
+
+	code, _, _ = run("render")
+	assert.Equal(t, ExitUsage, code)
+
+	path := generateMap(t, t.TempDir())
+	code, _, stderr = run("inspect", path, "extra")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, "unexpected argument(s): extra")
+	code, _, _ = run("diff", path, path, "third")
+	assert.Equal(t, ExitUsage, code)
+	code, _, _ = run("generate", "--seed", "1", "leftover")
+	assert.Equal(t, ExitUsage, code)
+
+	code, _, stderr = run("inspect", "does-not-exist.json")
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, "does-not-exist.json")
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.txt")
+	after := filepath.Join(dir, "after.txt")
+	require.NoError(t, os.WriteFile(before, []byte("name: terrain\n--\n0 1 1 0\n 1 1 0 0\n"), 0o644))
+	require.NoError(t, os.WriteFile(after, []byte("name: terrain\n--\n0 1 0 0\n 1 1 0\n"), 0o644))
+
+	code, out, _ := run("diff", before, before)
+	require.Equal(t, ExitOK, code)
+	assert.Equal(t, "no differences\n", out)
+
+	code, out, _ = run("diff", before, after)
+	require.Equal(t, ExitOK, code)
+	assert.Equal(t, "terrain: 2 changed cell(s)\n  2,0: 1 -> 0\n  3,1: 0 -> -\n", out)
+
+	code, _, stderr := run("diff", "--exit-code", before, after)
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, "maps differ")
+
+	code, out, _ = run("diff", "--format", "color", "--color", "none", before, after)
+	require.Equal(t, ExitOK, code)
+	assert.Equal(t, " / \\ / \\ / \\ / \\\n| 0 | 1 | * | 0 |\n \\ / \\ / \\ / \\ /\n  | 1 | 1 | 0 |\n   \\ / \\ / \\ /\n", out)
+
+	svg := filepath.Join(dir, "diff.svg")
+	code, _, _ = run("diff", "-o", svg, before, after)
+	require.Equal(t, ExitOK, code)
+	data, err := os.ReadFile(svg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<svg")
+
+	wider := filepath.Join(dir, "wider.txt")
+	require.NoError(t, os.WriteFile(wider, []byte("name: terrain\n--\n0 1 1 0 1\n 1 1 0 0 1\n"), 0o644))
+	code, out, _ = run("diff", before, wider)
+	require.Equal(t, ExitOK, code)
+	assert.Equal(t, "size: 4x2 -> 5x2\nterrain: 2 changed cell(s)\n  4,0: - -> 1\n  4,1: - -> 1\n", out)
+
+	code, _, _ = run("diff", before)
+	assert.Equal(t, ExitUsage, code)
+}
+
+// writeReplay records a short two player game and writes it to dir.
+func writeReplay(t *testing.T, dir, unit string) string {
+	t.Helper()
+	m, err := hex.UnmarshalCompact("name: terrain\n--\n1 1 1\n 1 1 1\n")
+	require.NoError(t, err)
+	gameMap := hex.NewMap(3, 2)
+	require.NoError(t, gameMap.AddGrid(m))
+	g := game.NewGame()
+	g.SetMap(gameMap)
+	for i, name := range []string{"red", "blue"} {
+		p := game.NewPlayer(name)
+		u := hex.NewUnit(unit)
+		u.Move(hex.NewPosition(i*2, 0))
+		p.AddUnit(u)
+		g.AddPlayer(p)
+	}
+	replay, c, err := game.NewReplay(g, 1, game.TurnOptions{Phases: []game.Phase{game.PhaseStart}}, game.Rules{})
+	require.NoError(t, err)
+	for _, cmd := range []game.Command{
+		game.MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(0, 1)},
+		game.EndTurn{Player: 0},
+		game.EndTurn{Player: 1},
+	} {
+		_, err := c.Execute(cmd)
+		require.NoError(t, err)
+	}
+
+	path := filepath.Join(dir, "game.4hxr")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, game.EncodeReplay(f, replay, hex.BinaryOptions{Gzip: true}))
+	require.NoError(t, f.Close())
+	return path
+}
+
+func TestReplay(t *testing.T) {
+	path := writeReplay(t, t.TempDir(), "warrior")
+
+	code, out, stderr := run("replay", "--color", "none", path)
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Equal(t, "turn 1\n"+
+		" / \\ / \\ / \\\n| W | 1 | W |\n \\ / \\ / \\ / \\\n  | 1 | 1 | 1 |\n   \\ / \\ / \\ /\n"+
+		"red: warrior at 0,0\nblue: warrior at 2,0\n"+
+		"  player 0 moves unit 0 to 0,1\n  player 0 ends the turn\n  player 1 ends the turn\n"+
+		"turn 2\n"+
+		" / \\ / \\ / \\\n| 1 | 1 | W |\n \\ / \\ / \\ / \\\n  | W | 1 | 1 |\n   \\ / \\ / \\ /\n"+
+		"red: warrior at 0,1\nblue: warrior at 2,0\n"+
+		"end of replay: 3 action(s)\n", out)
+
+	code, out, _ = run("replay", "--color", "none", "--turn", "2", path)
+	require.Equal(t, ExitOK, code)
+	assert.True(t, strings.HasPrefix(out, "turn 2\n"))
+	assert.Contains(t, out, "red: warrior at 0,1\n")
+	assert.NotContains(t, out, "end of replay")
+
+	code, out, _ = run("replay", "--color", "none", "--turn", "0", path)
+	require.Equal(t, ExitOK, code)
+	assert.Contains(t, out, "red: warrior at 0,0\n", "turn 0 draws the initial game")
+	assert.NotContains(t, out, "moves")
+	code, _, _ = run("replay", "--turn", "-1", path)
+	assert.Equal(t, ExitFailure, code)
+
+	code, _, stderr = run("replay", filepath.Join(t.TempDir(), "missing.4hxr"))
+	assert.Equal(t, ExitFailure, code)
+	assert.Contains(t, stderr, "missing.4hxr")
+}
+
+func TestReplay_UnnamedUnits(t *testing.T) {
+	path := writeReplay(t, t.TempDir(), "")
+
+	code, out, stderr := run("replay", "--color", "none", "--turn", "1", path)
+	require.Equal(t, ExitOK, code, stderr)
+	assert.Contains(t, out, "| ? | 1 | ? |\n", "units without a name are marked with a fallback glyph")
+	assert.NotContains(t, out, string(utf8.RuneError))
+}
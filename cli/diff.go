@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/viz"
+)
+
+type diffCommand struct {
+	layoutFlags
+	Format   string `short:"f" long:"format" choice:"text" choice:"color" choice:"svg" description:"Output format; defaults to svg for .svg output files, else text"`
+	Layer    string `short:"l" long:"layer" description:"Layer to draw (color, svg); defaults to the first changed layer"`
+	Color    string `long:"color" default:"auto" choice:"auto" choice:"truecolor" choice:"256" choice:"ansi" choice:"none" description:"Terminal color profile"`
+	ExitCode bool   `long:"exit-code" description:"Exit with status 1 when the maps differ"`
+	Output   string `short:"o" long:"out" default:"-" description:"Output file, - for stdout"`
+	Args     struct {
+		Before string `positional-arg-name:"before" description:"Map to compare against"`
+		After  string `positional-arg-name:"after" description:"Map to compare"`
+	} `positional-args:"yes" required:"yes"`
+
+	env *env
+}
+
+// errMapsDiffer is returned with --exit-code when the maps differ.
+var errMapsDiffer = errors.New("maps differ")
+
+func (c *diffCommand) Execute(args []string) error {
+	before, err := loadMap(c.Args.Before)
+	if err != nil {
+		return err
+	}
+	after, err := loadMap(c.Args.After)
+	if err != nil {
+		return err
+	}
+	d, err := hex.DiffMaps(before.Map, after.Map)
+	if err != nil {
+		return err
+	}
+	format := c.Format
+	if format == "" {
+		format = "text"
+		if formatFromPath(c.Output, "") == "svg" {
+			format = "svg"
+		}
+	}
+	err = writeOutput(c.Output, c.env.stdout, func(w io.Writer) error {
+		if format == "text" {
+			return writeDiff(w, d)
+		}
+		return c.render(w, d, after.Map, format)
+	})
+	if err == nil && c.ExitCode && !d.Empty() {
+		return errMapsDiffer
+	}
+	return err
+}
+
+// render draws a layer of the after map with its changed hexes highlighted.
+func (c *diffCommand) render(w io.Writer, d *hex.MapDiff, m hex.Map, format string) error {
+	name := c.Layer
+	if name == "" && len(d.Layers) > 0 {
+		name = d.Layers[0].Name
+	}
+	g, err := layer(m, name)
+	if err != nil {
+		return err
+	}
+	changes, _ := d.Layer(g.GetName())
+	switch format {
+	case "color":
+		profile, err := viz.ParseColorProfile(c.Color)
+		if err != nil {
+			return err
+		}
+		if profile == viz.AutoColor && c.Output != "" && c.Output != stdio {
+			profile = viz.NoColor
+		}
+		out, err := viz.RenderColored(m, viz.ColorOptions{
+			ASCIIOptions: viz.ASCIIOptions{Layout: c.layout()},
+			Layer:        g.GetName(),
+			Profile:      profile,
+			Overlay:      viz.DiffOverlay(changes, true),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	case "svg":
+		return viz.RenderSVG(w, m, viz.SVGOptions{
+			Orientation: c.layout().Orientation,
+			Layer:       g.GetName(),
+			Overlay:     viz.DiffOverlay(changes, true),
+		})
+	}
+	return fmt.Errorf("unsupported diff format %q", format)
+}
+
+// writeDiff writes d as text: changes of size and topology, one line per
+// added or removed layer, then the changed cells of each layer as
+// "q,r: before -> after", with - for no cell.
+func writeDiff(w io.Writer, d *hex.MapDiff) error {
+	if d.Empty() {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+	if d.Resized() {
+		fmt.Fprintf(w, "size: %dx%d -> %dx%d\n", d.OldWidth, d.OldHeight, d.NewWidth, d.NewHeight)
+	}
+	if d.TopologyChanged() {
+		fmt.Fprintf(w, "topology: %s -> %s\n", d.OldTopology, d.NewTopology)
+	}
+	for _, name := range d.Added {
+		fmt.Fprintf(w, "+ layer %s\n", name)
+	}
+	for _, name := range d.Removed {
+		fmt.Fprintf(w, "- layer %s\n", name)
+	}
+	for _, l := range d.Layers {
+		fmt.Fprintf(w, "%s: %d changed cell(s)\n", l.Name, len(l.Changes))
+		for _, c := range l.Changes {
+			if _, err := fmt.Fprintf(w, "  %d,%d: %s -> %s\n", c.Position.Q, c.Position.R, cellValue(c.Old), cellValue(c.New)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func cellValue(v *int) string {
+	if v == nil {
+		return "-"
+	}
+	return strconv.Itoa(*v)
+}
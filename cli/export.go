@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/tiled"
+)
+
+type exportCommand struct {
+	layoutFlags
+	mapArg
+	Format  string `short:"f" long:"format" choice:"json" choice:"binary" choice:"compact" choice:"tmx" choice:"tiled-json" description:"Output format; defaults from the output file's extension"`
+	Layer   string `short:"l" long:"layer" description:"Layer to export (compact)"`
+	Tileset string `long:"tileset" description:"Tileset source referenced by Tiled maps"`
+	Output  string `short:"o" long:"out" default:"-" description:"Output file, - for stdout"`
+
+	env *env
+}
+
+func (c *exportCommand) Execute(args []string) error {
+	format := c.Format
+	if format == "" {
+		format = formatFromPath(c.Output, "")
+	}
+	if format == "" {
+		return fmt.Errorf("no output format: pass --format or an output file with a known extension")
+	}
+	if err := checkFormat("export", format, "json", "binary", "compact", "tmx", "tiled-json"); err != nil {
+		return err
+	}
+	loaded, err := loadMap(c.Args.Map)
+	if err != nil {
+		return err
+	}
+	return writeOutput(c.Output, c.env.stdout, func(w io.Writer) error {
+		switch format {
+		case "json", "binary":
+			return writeMap(w, loaded.Map, format)
+		case "compact":
+			g, err := layer(loaded.Map, c.Layer)
+			if err != nil {
+				return err
+			}
+			out, err := hex.MarshalCompact(g, hex.CompactOptions{Layout: c.layout()})
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(w, out)
+			return err
+		case "tmx", "tiled-json":
+			tm, err := tiled.Export(loaded.Map, loaded.Players, tiled.Options{Layout: c.layout(), Tileset: c.Tileset})
+			if err != nil {
+				return err
+			}
+			if format == "tmx" {
+				return tm.WriteTMX(w)
+			}
+			return tm.WriteJSON(w)
+		}
+		return fmt.Errorf("unsupported export format %q", format)
+	})
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/klumhru/4hex/game"
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/tiled"
+)
+
+// stdio is the file name that stands for standard input or output.
+const stdio = "-"
+
+// loadedMap is a map read from a file, with the players of game saves and
+// Tiled maps.
+type loadedMap struct {
+	Map     hex.Map
+	Players []game.Player
+	Format  string
+}
+
+// loadMap reads a map file, detecting its format from its content: binary
+// maps, game saves, Tiled TMX or JSON, compact text or JSON maps.
+func loadMap(path string) (*loadedMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := decodeMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return loaded, nil
+}
+
+func decodeMap(data []byte) (*loadedMap, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte(hex.MapMagic)):
+		m, err := hex.DecodeMapBinary(bytes.NewReader(data))
+		return &loadedMap{Map: m, Format: "binary"}, err
+	case bytes.HasPrefix(data, []byte(game.GameMagic)):
+		g, err := game.DecodeBinary(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if g.GetMap() == nil {
+			return nil, fmt.Errorf("game save has no map")
+		}
+		return &loadedMap{Map: g.GetMap(), Players: g.GetPlayers(), Format: "game"}, nil
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		tm, err := tiled.ReadTMX(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return importTiled(tm, "tmx")
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+		if probe.Type == "map" {
+			tm, err := tiled.ReadJSON(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return importTiled(tm, "tiled-json")
+		}
+		m, err := hex.UnmarshalMap(data)
+		return &loadedMap{Map: m, Format: "json"}, err
+	}
+	g, err := hex.UnmarshalCompact(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("unrecognized map format: %w", err)
+	}
+	m := hex.NewMap(g.GetPosition().Q+g.GetWidth(), g.GetPosition().R+g.GetHeight())
+	if err := m.AddGrid(g); err != nil {
+		return nil, err
+	}
+	return &loadedMap{Map: m, Format: "compact"}, nil
+}
+
+func importTiled(tm *tiled.Map, format string) (*loadedMap, error) {
+	m, players, err := tiled.Import(tm)
+	if err != nil {
+		return nil, err
+	}
+	return &loadedMap{Map: m, Players: players, Format: format}, nil
+}
+
+// formatFromPath guesses an output format from a file extension.
+func formatFromPath(path, fallback string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".hexm", ".bin":
+		return "binary"
+	case ".tmx":
+		return "tmx"
+	case ".tmj":
+		return "tiled-json"
+	case ".txt":
+		return "compact"
+	case ".svg":
+		return "svg"
+	case ".png":
+		return "png"
+	}
+	return fallback
+}
+
+// checkFormat reports an error unless format is one of supported, so commands
+// can reject an output format before doing any work.
+func checkFormat(kind, format string, supported ...string) error {
+	if !slices.Contains(supported, format) {
+		return fmt.Errorf("unsupported %s format %q", kind, format)
+	}
+	return nil
+}
+
+// writeOutput passes path, or stdout for "-", to write. Files are written to
+// a temporary file next to path that replaces it only when write succeeds, so
+// a failed write never truncates or leaves behind a file.
+func writeOutput(path string, stdout io.Writer, write func(w io.Writer) error) (err error) {
+	if path == "" || path == stdio {
+		return write(stdout)
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if err := write(f); err != nil {
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// writeMap writes m as JSON or as a gzipped binary map.
+func writeMap(w io.Writer, m hex.Map, format string) error {
+	switch format {
+	case "json":
+		data, err := hex.MarshalMap(m)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "binary":
+		return hex.EncodeMapBinary(w, m, hex.BinaryOptions{Gzip: true})
+	}
+	return fmt.Errorf("unsupported map format %q", format)
+}
+
+// parseLayout parses an orientation and parity given on the command line.
+func parseLayout(orientation, parity string) hex.OffsetLayout {
+	layout := hex.OffsetLayout{Orientation: hex.PointyTop, Parity: hex.Odd}
+	if orientation == hex.FlatTop.String() {
+		layout.Orientation = hex.FlatTop
+	}
+	if parity == hex.Even.String() {
+		layout.Parity = hex.Even
+	}
+	return layout
+}
+
+// units returns every unit of the players.
+func units(players []game.Player) []hex.Unit {
+	var all []hex.Unit
+	for _, p := range players {
+		for i := 0; i < p.GetUnitCount(); i++ {
+			if u, err := p.GetUnitAt(i); err == nil && u != nil {
+				all = append(all, u)
+			}
+		}
+	}
+	return all
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+)
+
+// generateFlags describe a map to generate; play shares them.
+type generateFlags struct {
+	Seed       *int64  `long:"seed" description:"Random seed; when not set one is picked and reported on stderr"`
+	Width      int     `long:"width" default:"32" description:"Map width in hexes"`
+	Height     int     `long:"height" default:"24" description:"Map height in hexes"`
+	Shape      string  `long:"shape" default:"rectangle" choice:"rectangle" choice:"square" choice:"circle" choice:"triangle" choice:"isosceles" description:"Shape of the land area"`
+	Generator  string  `long:"generator" default:"landmass" choice:"landmass" choice:"shape" description:"Terrain generator"`
+	Continents int     `long:"continents" default:"3" description:"Number of landmasses"`
+	LandRatio  float64 `long:"land-ratio" default:"0.4" description:"Target fraction of land"`
+	Topology   string  `long:"topology" default:"flat" choice:"flat" choice:"cylinder" choice:"torus" description:"How the map's edges connect"`
+}
+
+// build generates the map. Without --seed a random seed is picked and
+// reported to log so the map can be generated again.
+func (f *generateFlags) build(log io.Writer) (hex.Map, error) {
+	if f.Width <= 0 || f.Height <= 0 {
+		return nil, fmt.Errorf("map size %dx%d must be positive", f.Width, f.Height)
+	}
+	var seed int64
+	if f.Seed != nil {
+		seed = *f.Seed
+	} else {
+		seed = time.Now().UnixNano()
+		fmt.Fprintf(log, "seed: %d\n", seed)
+	}
+	topology, err := hex.ParseTopology(f.Topology)
+	if err != nil {
+		return nil, err
+	}
+	shape := f.shape()
+
+	p := generator.NewPipeline()
+	err = p.Add("terrain", func(m hex.Map, rng *rand.Rand) error {
+		var grid hex.Grid
+		var err error
+		if f.Generator == "shape" {
+			grid, err = generator.GridFromShape(shape)
+		} else {
+			grid, err = generator.Landmasses(generator.LandmassOptions{
+				Name:       "terrain",
+				Seed:       rng.Int63(),
+				Continents: f.Continents,
+				LandRatio:  f.LandRatio,
+			})(shape)
+		}
+		if err != nil {
+			return err
+		}
+		return m.AddGrid(grid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	m := hex.NewMap(f.Width, f.Height)
+	m.SetTopology(topology)
+	if _, err := p.Run(m, seed); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// shape returns the area the terrain is generated in, named after the layer.
+func (f *generateFlags) shape() shapes.Shape {
+	size := min(f.Width, f.Height)
+	switch f.Shape {
+	case "square":
+		return shapes.NewSquare(0, 0, size, "terrain")
+	case "circle":
+		return shapes.NewCircle(size/2, size/2, size/2, "terrain")
+	case "triangle":
+		return shapes.NewTriangle(0, 0, size, "terrain")
+	case "isosceles":
+		return shapes.NewIsoscelesTriangle(0, 0, size, "terrain")
+	}
+	return shapes.NewRectangle(0, 0, f.Width, f.Height, "terrain")
+}
+
+type generateCommand struct {
+	generateFlags
+	Format string `short:"f" long:"format" choice:"json" choice:"binary" description:"Output format; defaults from the output file's extension, else json"`
+	Output string `short:"o" long:"out" default:"-" description:"Output file, - for stdout"`
+
+	env *env
+}
+
+func (c *generateCommand) Execute(args []string) error {
+	format := c.Format
+	if format == "" {
+		format = formatFromPath(c.Output, "json")
+	}
+	if err := checkFormat("map", format, "json", "binary"); err != nil {
+		return err
+	}
+	m, err := c.build(c.env.stderr)
+	if err != nil {
+		return err
+	}
+	return writeOutput(c.Output, c.env.stdout, func(w io.Writer) error {
+		return writeMap(w, m, format)
+	})
+}
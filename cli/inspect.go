@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type inspectCommand struct {
+	mapArg
+
+	env *env
+}
+
+func (c *inspectCommand) Execute(args []string) error {
+	loaded, err := loadMap(c.Args.Map)
+	if err != nil {
+		return err
+	}
+	m, out := loaded.Map, c.env.stdout
+	width, height := m.GetDimensions()
+	fmt.Fprintf(out, "format:   %s\n", loaded.Format)
+	fmt.Fprintf(out, "size:     %dx%d\n", width, height)
+	fmt.Fprintf(out, "topology: %s\n", m.GetTopology())
+	fmt.Fprintf(out, "layers:   %d\n", len(m.GetGrids()))
+	for _, g := range m.GetGrids() {
+		counts := make(map[int]int)
+		cells := 0
+		for i := 0; i < g.GetCellCount(); i++ {
+			if cell, _ := g.GetCellAtIndex(i); cell != nil {
+				counts[cell.GetValue()]++
+				cells++
+			}
+		}
+		values := make([]int, 0, len(counts))
+		for v := range counts {
+			values = append(values, v)
+		}
+		slices.Sort(values)
+		histogram := make([]string, len(values))
+		for i, v := range values {
+			histogram[i] = fmt.Sprintf("%d:%d", v, counts[v])
+		}
+		fmt.Fprintf(out, "  %s: at %d,%d size %dx%d, %d cells, values (value:count) %s\n",
+			g.GetName(), g.GetPosition().Q, g.GetPosition().R, g.GetWidth(), g.GetHeight(), cells, strings.Join(histogram, " "))
+	}
+	if len(loaded.Players) > 0 {
+		fmt.Fprintf(out, "players:  %d\n", len(loaded.Players))
+		for _, p := range loaded.Players {
+			fmt.Fprintf(out, "  %s: %d unit(s)\n", p.GetName(), p.GetUnitCount())
+			for i := 0; i < p.GetUnitCount(); i++ {
+				if u, err := p.GetUnitAt(i); err == nil && u != nil {
+					fmt.Fprintf(out, "    %s at %d,%d\n", u.GetName(), u.Position().Q, u.Position().R)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/viz"
+)
+
+type playCommand struct {
+	generateFlags
+	Args struct {
+		Map string `positional-arg-name:"map" description:"Map file; a new map is generated when omitted"`
+	} `positional-args:"yes"`
+
+	env *env
+}
+
+func (c *playCommand) Execute(args []string) error {
+	var m hex.Map
+	if c.Args.Map != "" {
+		loaded, err := loadMap(c.Args.Map)
+		if err != nil {
+			return err
+		}
+		m = loaded.Map
+	} else {
+		var err error
+		if m, err = c.build(c.env.stderr); err != nil {
+			return err
+		}
+	}
+	return viz.RunExplorer(os.Stdin, os.Stdout, viz.NewExplorer(m))
+}
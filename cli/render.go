@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/viz"
+)
+
+// layoutFlags select how hexes are laid out.
+type layoutFlags struct {
+	Orientation string `long:"orientation" default:"pointy" choice:"pointy" choice:"flat" description:"Hex orientation"`
+	Parity      string `long:"parity" default:"odd" choice:"odd" choice:"even" description:"Which rows (pointy) or columns (flat) are shoved"`
+}
+
+func (f layoutFlags) layout() hex.OffsetLayout {
+	return parseLayout(f.Orientation, f.Parity)
+}
+
+// mapArg is the map file positional argument.
+type mapArg struct {
+	Args struct {
+		Map string `positional-arg-name:"map" description:"Map file: JSON, binary, game save, Tiled or compact text"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type renderCommand struct {
+	layoutFlags
+	mapArg
+	Format string  `short:"f" long:"format" choice:"ascii" choice:"color" choice:"compact" choice:"svg" choice:"png" description:"Output format; defaults from the output file's extension, else color"`
+	Layer  string  `short:"l" long:"layer" description:"Layer to draw; defaults to the first"`
+	Color  string  `long:"color" default:"auto" choice:"auto" choice:"truecolor" choice:"256" choice:"ansi" choice:"none" description:"Terminal color profile"`
+	Legend bool    `long:"legend" description:"Print a legend of the values drawn"`
+	Labels bool    `long:"labels" description:"Label hexes with their coordinates (svg)"`
+	Scale  float64 `long:"scale" description:"Hex radius in pixels (svg, png)"`
+	Units  bool    `long:"units" description:"Draw the units of game saves and Tiled maps (svg)"`
+	Output string  `short:"o" long:"out" default:"-" description:"Output file, - for stdout"`
+
+	env *env
+}
+
+func (c *renderCommand) Execute(args []string) error {
+	format := c.Format
+	if format == "" {
+		format = formatFromPath(c.Output, "color")
+	}
+	if err := checkFormat("render", format, "ascii", "color", "compact", "svg", "png"); err != nil {
+		return err
+	}
+	loaded, err := loadMap(c.Args.Map)
+	if err != nil {
+		return err
+	}
+	return writeOutput(c.Output, c.env.stdout, func(w io.Writer) error {
+		return c.render(w, loaded, format)
+	})
+}
+
+func (c *renderCommand) render(w io.Writer, loaded *loadedMap, format string) error {
+	m := loaded.Map
+	switch format {
+	case "ascii":
+		out, err := viz.RenderMapASCII(m, c.Layer, viz.ASCIIOptions{Layout: c.layout()})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	case "color":
+		profile, err := viz.ParseColorProfile(c.Color)
+		if err != nil {
+			return err
+		}
+		if profile == viz.AutoColor && c.Output != "" && c.Output != stdio {
+			profile = viz.NoColor
+		}
+		out, err := viz.RenderColored(m, viz.ColorOptions{
+			ASCIIOptions: viz.ASCIIOptions{Layout: c.layout()},
+			Layer:        c.Layer,
+			Profile:      profile,
+			Legend:       c.Legend,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	case "compact":
+		g, err := layer(m, c.Layer)
+		if err != nil {
+			return err
+		}
+		out, err := hex.MarshalCompact(g, hex.CompactOptions{Layout: c.layout()})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	case "svg":
+		opts := viz.SVGOptions{Orientation: c.layout().Orientation, Size: c.Scale, Layer: c.Layer, Labels: c.Labels}
+		if c.Units {
+			opts.Units = units(loaded.Players)
+		}
+		return viz.RenderSVG(w, m, opts)
+	case "png":
+		return viz.EncodePNG(w, m, viz.ImageOptions{Orientation: c.layout().Orientation, Scale: c.Scale, Layer: c.Layer})
+	}
+	return fmt.Errorf("unsupported render format %q", format)
+}
+
+// layer returns the named layer of m, or the first one when name is empty.
+func layer(m hex.Map, name string) (hex.Grid, error) {
+	if name != "" {
+		return m.GetGridByName(name)
+	}
+	grids := m.GetGrids()
+	if len(grids) == 0 {
+		return nil, fmt.Errorf("map has no layers")
+	}
+	return grids[0], nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/klumhru/4hex/game"
+	"github.com/klumhru/4hex/viz"
+)
+
+// unknownUnitGlyph marks units whose name has no initial to draw.
+const unknownUnitGlyph = '?'
+
+type replayCommand struct {
+	layoutFlags
+	Layer string `short:"l" long:"layer" description:"Layer to draw; defaults to the first"`
+	Color string `long:"color" default:"auto" choice:"auto" choice:"truecolor" choice:"256" choice:"ansi" choice:"none" description:"Terminal color profile"`
+	Turn  *int   `short:"t" long:"turn" description:"Only draw the game at the start of this turn"`
+	Wait  bool   `short:"w" long:"wait" description:"Wait for Enter before each turn"`
+	Args  struct {
+		Replay string `positional-arg-name:"replay" description:"Replay file"`
+	} `positional-args:"yes" required:"yes"`
+
+	env *env
+}
+
+func (c *replayCommand) Execute(args []string) error {
+	f, err := os.Open(c.Args.Replay)
+	if err != nil {
+		return err
+	}
+	replay, err := game.DecodeReplay(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.Args.Replay, err)
+	}
+	profile, err := viz.ParseColorProfile(c.Color)
+	if err != nil {
+		return err
+	}
+
+	if c.Turn != nil {
+		if *c.Turn < 0 {
+			return fmt.Errorf("turn %d cannot be negative", *c.Turn)
+		}
+		g, err := replay.Rebuild(*c.Turn)
+		if err != nil {
+			return err
+		}
+		return c.draw(g, profile)
+	}
+	p, err := replay.Play()
+	if err != nil {
+		return err
+	}
+	input := bufio.NewReader(os.Stdin)
+	out := c.env.stdout
+	for {
+		if err := c.draw(p.Game(), profile); err != nil {
+			return err
+		}
+		if p.Done() {
+			break
+		}
+		if c.Wait {
+			fmt.Fprint(out, "press Enter for the next turn")
+			if _, err := input.ReadString('\n'); err != nil {
+				return nil
+			}
+		}
+		results, err := p.StepTurn()
+		for _, r := range results {
+			fmt.Fprintf(out, "  %s\n", r.Command)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "end of replay: %d action(s)\n", len(replay.Actions))
+	return nil
+}
+
+// draw writes the turn, the map with units marked by their initial, and
+// where each player's units are.
+func (c *replayCommand) draw(g game.Game, profile viz.ColorProfile) error {
+	out := c.env.stdout
+	fmt.Fprintf(out, "turn %d\n", g.GetTurn())
+	overlay := &viz.Overlay{}
+	for _, u := range units(g.GetPlayers()) {
+		r, _ := utf8.DecodeRuneInString(u.GetName())
+		if r == utf8.RuneError {
+			r = unknownUnitGlyph
+		}
+		overlay.Marks = append(overlay.Marks, viz.Mark{Position: u.Position(), Glyph: unicode.ToUpper(r)})
+	}
+	if m := g.GetMap(); m != nil {
+		drawn, err := viz.RenderColored(m, viz.ColorOptions{
+			ASCIIOptions: viz.ASCIIOptions{Layout: c.layout()},
+			Layer:        c.Layer,
+			Profile:      profile,
+			Overlay:      overlay,
+		})
+		if err != nil {
+			return err
+		}
+		io.WriteString(out, drawn)
+	}
+	for _, p := range g.GetPlayers() {
+		var labels []string
+		for _, u := range units([]game.Player{p}) {
+			labels = append(labels, fmt.Sprintf("%s at %d,%d", u.GetName(), u.Position().Q, u.Position().R))
+		}
+		fmt.Fprintf(out, "%s: %s\n", p.GetName(), strings.Join(labels, ", "))
+	}
+	return nil
+}
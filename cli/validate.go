@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+)
+
+type validateCommand struct {
+	mapArg
+	Terrain string   `long:"terrain" default:"terrain" description:"Name of the land and water layer"`
+	Rules   []string `short:"r" long:"rule" choice:"land-connected" choice:"orphan-lakes" choice:"starts-reachable" description:"Rule to check; repeat for several (default orphan-lakes, plus starts-reachable for maps with units)"`
+	MinLake int      `long:"min-lake" default:"2" description:"Smallest lake allowed by orphan-lakes"`
+
+	env *env
+}
+
+func (c *validateCommand) Execute(args []string) error {
+	loaded, err := loadMap(c.Args.Map)
+	if err != nil {
+		return err
+	}
+	terrain := generator.Terrain{Layer: c.Terrain}
+	var starts []hex.Position
+	for _, u := range units(loaded.Players) {
+		starts = append(starts, u.Position())
+	}
+	names := c.Rules
+	if len(names) == 0 {
+		names = []string{"orphan-lakes"}
+		if len(starts) > 0 {
+			names = append(names, "starts-reachable")
+		}
+	}
+	var rules []generator.Rule
+	for _, name := range names {
+		switch name {
+		case "land-connected":
+			rules = append(rules, generator.LandConnected(terrain))
+		case "orphan-lakes":
+			rules = append(rules, generator.NoOrphanLakes(terrain, c.MinLake))
+		case "starts-reachable":
+			rules = append(rules, generator.StartsReachable(terrain, starts))
+		}
+	}
+
+	violations, err := generator.Validate(loaded.Map, rules...)
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		fmt.Fprintln(c.env.stdout, v)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d violation(s)", len(violations))
+	}
+	fmt.Fprintf(c.env.stdout, "ok: %d rule(s) passed\n", len(rules))
+	return nil
+}
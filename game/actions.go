@@ -0,0 +1,299 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// MoveUnit moves a unit to a neighboring passable, unoccupied hex in the
+// movement phase. A unit moves at most Rules.Moves hexes per turn.
+type MoveUnit struct {
+	Player int
+	Unit   int
+	To     hex.Position
+}
+
+func (cmd MoveUnit) Name() string { return "move" }
+func (cmd MoveUnit) player() int  { return cmd.Player }
+
+func (cmd MoveUnit) String() string {
+	return fmt.Sprintf("player %d moves unit %d to %d,%d", cmd.Player, cmd.Unit, cmd.To.Q, cmd.To.R)
+}
+
+func (cmd MoveUnit) validate(c *Commander) error {
+	ref := UnitRef{Player: cmd.Player, Unit: cmd.Unit}
+	u, err := c.unit(cmd, ref)
+	if err != nil {
+		return err
+	}
+	if err := c.checkPhase(cmd, PhaseMovement); err != nil {
+		return err
+	}
+	if c.moved[ref] >= c.rules.Moves {
+		return reject(cmd, RejectNoMovesLeft, "%s has no moves left this turn", u.GetName())
+	}
+	to, ok := c.normalize(cmd.To)
+	if !ok || !c.adjacent(u.Position(), to) {
+		return reject(cmd, RejectOutOfRange, "%s is not next to %s", cmd.To, u.GetName())
+	}
+	if !c.passable(to) {
+		return reject(cmd, RejectImpassable, "%s cannot enter %s", u.GetName(), cmd.To)
+	}
+	if _, _, occupied := c.unitAt(to); occupied {
+		return reject(cmd, RejectOccupied, "%s is occupied", cmd.To)
+	}
+	return nil
+}
+
+func (cmd MoveUnit) apply(c *Commander, r *Result) (func() error, error) {
+	ref := UnitRef{Player: cmd.Player, Unit: cmd.Unit}
+	u, _ := c.game.GetPlayers()[cmd.Player].GetUnitAt(cmd.Unit)
+	from, restoreMoved := u.Position(), c.keepMoved(ref)
+	to, _ := c.normalize(cmd.To)
+	u.Move(to)
+	c.moved[ref]++
+	return func() error {
+		u.Move(from)
+		restoreMoved()
+		return nil
+	}, nil
+}
+
+// Attack fights an enemy unit on a neighboring hex in the combat phase. The game's random number
+// generator decides the winner with Rules.AttackOdds; the loser is destroyed.
+// Attacking uses up the attacker's moves for the turn.
+type Attack struct {
+	Player int
+	Unit   int
+	Target hex.Position
+}
+
+func (cmd Attack) Name() string { return "attack" }
+func (cmd Attack) player() int  { return cmd.Player }
+
+func (cmd Attack) String() string {
+	return fmt.Sprintf("player %d attacks %d,%d with unit %d", cmd.Player, cmd.Target.Q, cmd.Target.R, cmd.Unit)
+}
+
+func (cmd Attack) validate(c *Commander) error {
+	ref := UnitRef{Player: cmd.Player, Unit: cmd.Unit}
+	u, err := c.unit(cmd, ref)
+	if err != nil {
+		return err
+	}
+	if err := c.checkPhase(cmd, PhaseCombat); err != nil {
+		return err
+	}
+	if c.moved[ref] >= c.rules.Moves {
+		return reject(cmd, RejectNoMovesLeft, "%s has no moves left this turn", u.GetName())
+	}
+	target, ok := c.normalize(cmd.Target)
+	if !ok || !c.adjacent(u.Position(), target) {
+		return reject(cmd, RejectOutOfRange, "%s is not next to %s", cmd.Target, u.GetName())
+	}
+	defender, _, found := c.unitAt(target)
+	if !found || defender.Player == cmd.Player {
+		return reject(cmd, RejectNoTarget, "no enemy unit at %s", cmd.Target)
+	}
+	return nil
+}
+
+func (cmd Attack) apply(c *Commander, r *Result) (func() error, error) {
+	attacker := UnitRef{Player: cmd.Player, Unit: cmd.Unit}
+	target, _ := c.normalize(cmd.Target)
+	defender, _, _ := c.unitAt(target)
+	randState, err := c.game.RandState()
+	if err != nil {
+		return nil, err
+	}
+	loser := attacker
+	if c.game.Rand().Float64() < c.rules.AttackOdds {
+		loser = defender
+	}
+	owner := c.game.GetPlayers()[loser.Player]
+	lost, _ := owner.GetUnitAt(loser.Unit)
+	if err := owner.SetUnitAt(loser.Unit, nil); err != nil {
+		return nil, err
+	}
+	restoreMoved := c.keepMoved(attacker)
+	c.moved[attacker] = c.rules.Moves
+	r.Destroyed = append(r.Destroyed, loser)
+	return func() error {
+		if err := owner.SetUnitAt(loser.Unit, lost); err != nil {
+			return err
+		}
+		restoreMoved()
+		return c.game.SetRandState(randState)
+	}, nil
+}
+
+// Found turns a settler into a city on its hex in the production phase.
+// Cities cannot be founded on or next to another city.
+type Found struct {
+	Player int
+	Unit   int
+}
+
+func (cmd Found) Name() string { return "found" }
+func (cmd Found) player() int  { return cmd.Player }
+
+func (cmd Found) String() string {
+	return fmt.Sprintf("player %d founds a city with unit %d", cmd.Player, cmd.Unit)
+}
+
+func (cmd Found) validate(c *Commander) error {
+	u, err := c.unit(cmd, UnitRef{Player: cmd.Player, Unit: cmd.Unit})
+	if err != nil {
+		return err
+	}
+	if err := c.checkPhase(cmd, PhaseProduction); err != nil {
+		return err
+	}
+	if u.GetName() != c.rules.Settler {
+		return reject(cmd, RejectNotSettler, "%s cannot found cities", u.GetName())
+	}
+	at, ok := c.normalize(u.Position())
+	if !ok {
+		return reject(cmd, RejectOutOfRange, "%s is off the map", u.GetName())
+	}
+	for _, p := range append(c.game.GetMap().Neighbors(at), at) {
+		if _, found := c.cityAt(p); found {
+			return reject(cmd, RejectCityTooClose, "city at %s is too close", p)
+		}
+	}
+	return nil
+}
+
+func (cmd Found) apply(c *Commander, r *Result) (func() error, error) {
+	player := c.game.GetPlayers()[cmd.Player]
+	u, _ := player.GetUnitAt(cmd.Unit)
+	at, _ := c.normalize(u.Position())
+	cities, added, err := c.cityLayer()
+	if err != nil {
+		return nil, err
+	}
+	cell, ok := layerCell(cities, at)
+	if !ok {
+		return nil, fmt.Errorf("city layer has no cell at %s", at)
+	}
+	if err := player.SetUnitAt(cmd.Unit, nil); err != nil {
+		return nil, err
+	}
+	previous := cell.GetValue()
+	cell.SetValue(cmd.Player)
+	r.Destroyed = append(r.Destroyed, UnitRef{Player: cmd.Player, Unit: cmd.Unit})
+	r.Cities = append(r.Cities, at)
+	return func() error {
+		if added {
+			if err := c.game.GetMap().RemoveGrid(CityLayer); err != nil {
+				return err
+			}
+		} else {
+			cell.SetValue(previous)
+		}
+		return player.SetUnitAt(cmd.Unit, u)
+	}, nil
+}
+
+// Build makes a city produce a unit on its hex in the production phase, once
+// per turn. The city hex must be free.
+type Build struct {
+	Player int
+	City   hex.Position
+	Unit   string
+}
+
+func (cmd Build) Name() string { return "build" }
+func (cmd Build) player() int  { return cmd.Player }
+
+func (cmd Build) String() string {
+	return fmt.Sprintf("player %d builds %s in the city at %d,%d", cmd.Player, cmd.Unit, cmd.City.Q, cmd.City.R)
+}
+
+func (cmd Build) validate(c *Commander) error {
+	if err := c.checkPlayer(cmd, cmd.Player); err != nil {
+		return err
+	}
+	if err := c.checkPhase(cmd, PhaseProduction); err != nil {
+		return err
+	}
+	at, ok := c.normalize(cmd.City)
+	if !ok {
+		return reject(cmd, RejectOutOfRange, "%s is off the map", cmd.City)
+	}
+	if owner, found := c.cityAt(at); !found || owner != cmd.Player {
+		return reject(cmd, RejectNotYourCity, "player %d has no city at %s", cmd.Player, cmd.City)
+	}
+	if c.built[at] {
+		return reject(cmd, RejectAlreadyBuilt, "city at %s already built this turn", cmd.City)
+	}
+	if !slices.Contains(c.rules.Buildable, cmd.Unit) {
+		return reject(cmd, RejectNotBuildable, "cities cannot build %q", cmd.Unit)
+	}
+	if _, _, occupied := c.unitAt(at); occupied {
+		return reject(cmd, RejectOccupied, "%s is occupied", cmd.City)
+	}
+	return nil
+}
+
+func (cmd Build) apply(c *Commander, r *Result) (func() error, error) {
+	player := c.game.GetPlayers()[cmd.Player]
+	at, _ := c.normalize(cmd.City)
+	u := hex.NewUnit(cmd.Unit)
+	u.Move(at)
+	player.AddUnit(u)
+	c.built[at] = true
+	slot := player.GetUnitCount() - 1
+	r.Created = append(r.Created, UnitRef{Player: cmd.Player, Unit: slot})
+	return func() error {
+		delete(c.built, at)
+		return player.RemoveUnitAt(slot)
+	}, nil
+}
+
+// EndTurn ends a player's turn in any phase. In sequential mode the next player's turn
+// begins; in simultaneous mode the turn advances once every player ended it.
+type EndTurn struct {
+	Player int
+}
+
+func (cmd EndTurn) Name() string { return "end-turn" }
+func (cmd EndTurn) player() int  { return cmd.Player }
+
+func (cmd EndTurn) String() string {
+	return fmt.Sprintf("player %d ends the turn", cmd.Player)
+}
+
+func (cmd EndTurn) validate(c *Commander) error {
+	return c.checkPlayer(cmd, cmd.Player)
+}
+
+func (cmd EndTurn) apply(c *Commander, r *Result) (func() error, error) {
+	if c.turns.Mode() == Simultaneous {
+		c.ended[cmd.Player] = true
+		if len(c.ended) < len(c.game.GetPlayers()) {
+			return nil, nil
+		}
+	}
+	return nil, c.turns.EndTurn()
+}
+
+// AdvancePhase ends the current phase for every player and begins the next
+// one, as TurnManager.Advance does. Replays record it for phases advanced
+// through the turn manager directly.
+type AdvancePhase struct{}
+
+func (cmd AdvancePhase) Name() string { return "advance" }
+func (cmd AdvancePhase) player() int  { return AllPlayers }
+
+func (cmd AdvancePhase) String() string { return "the next phase begins" }
+
+func (cmd AdvancePhase) validate(c *Commander) error {
+	return nil
+}
+
+func (cmd AdvancePhase) apply(c *Commander, r *Result) (func() error, error) {
+	return nil, c.turns.Advance()
+}
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unitAt(t *testing.T, g Game, ref UnitRef) hex.Unit {
+	t.Helper()
+	u, err := g.GetPlayers()[ref.Player].GetUnitAt(ref.Unit)
+	require.NoError(t, err)
+	return u
+}
+
+func TestMoveUnit(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(3, 1)}), RejectOutOfRange)
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(2, 1)}), RejectOccupied)
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, -1)}), RejectOutOfRange)
+
+	result, err := c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(2, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(2, 0)}, result.Command)
+	assert.Equal(t, 1, result.Turn)
+	assert.Equal(t, 0, result.Player)
+	assert.Empty(t, result.Created)
+	assert.Empty(t, result.Destroyed)
+	assert.Equal(t, hex.NewPosition(2, 0), unitAt(t, g, UnitRef{0, 0}).Position())
+
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 0)}), RejectNoMovesLeft)
+
+	c = newTestCommander(t, g, Sequential, Rules{Moves: 2})
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(3, 0)}), RejectImpassable)
+}
+
+func TestMoveUnit_MovesResetEachTurn(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+
+	_, err := c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 2)})
+	require.NoError(t, err)
+	_, err = c.Execute(EndTurn{Player: 0})
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.GetActivePlayerIndex())
+	_, err = c.Execute(EndTurn{Player: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, g.GetTurn())
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 1)})
+	assert.NoError(t, err)
+}
+
+func TestAttack(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{AttackOdds: 1})
+
+	assertRejected(t, c.Validate(Attack{Player: 0, Unit: 0, Target: hex.NewPosition(1, 0)}), RejectNoTarget)
+	assertRejected(t, c.Validate(Attack{Player: 0, Unit: 1, Target: hex.NewPosition(2, 1)}), RejectOutOfRange)
+
+	result, err := c.Execute(Attack{Player: 0, Unit: 0, Target: hex.NewPosition(2, 1)})
+	require.NoError(t, err)
+	assert.Equal(t, []UnitRef{{Player: 1, Unit: 0}}, result.Destroyed)
+	assert.Nil(t, unitAt(t, g, UnitRef{1, 0}))
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(2, 1)}), RejectNoMovesLeft)
+
+	g = newCommandGame(t)
+	c = newTestCommander(t, g, Sequential, Rules{AttackOdds: 1e-12})
+	result, err = c.Execute(Attack{Player: 0, Unit: 0, Target: hex.NewPosition(2, 1)})
+	require.NoError(t, err)
+	assert.Equal(t, []UnitRef{{Player: 0, Unit: 0}}, result.Destroyed)
+	assert.Nil(t, unitAt(t, g, UnitRef{0, 0}))
+	assert.NotNil(t, unitAt(t, g, UnitRef{1, 0}))
+}
+
+func TestFoundAndBuild(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+
+	assertRejected(t, c.Validate(Found{Player: 0, Unit: 0}), RejectNotSettler)
+	result, err := c.Execute(Found{Player: 0, Unit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []hex.Position{hex.NewPosition(0, 0)}, result.Cities)
+	assert.Equal(t, []UnitRef{{Player: 0, Unit: 1}}, result.Destroyed)
+	assert.Nil(t, unitAt(t, g, UnitRef{0, 1}))
+
+	cities, err := g.GetMap().GetGridByName(CityLayer)
+	require.NoError(t, err)
+	cell, _ := cities.GetCellAt(0, 0)
+	assert.Equal(t, 0, cell.GetValue())
+	cell, _ = cities.GetCellAt(1, 0)
+	assert.Equal(t, NoCity, cell.GetValue())
+
+	assertRejected(t, c.Validate(Build{Player: 0, City: hex.NewPosition(1, 0), Unit: "Warrior"}), RejectNotYourCity)
+	assertRejected(t, c.Validate(Build{Player: 0, City: hex.NewPosition(0, 0), Unit: "Dragon"}), RejectNotBuildable)
+	result, err = c.Execute(Build{Player: 0, City: hex.NewPosition(0, 0), Unit: "Settler"})
+	require.NoError(t, err)
+	assert.Equal(t, []UnitRef{{Player: 0, Unit: 2}}, result.Created)
+	settler := unitAt(t, g, UnitRef{0, 2})
+	assert.Equal(t, "Settler", settler.GetName())
+	assert.Equal(t, hex.NewPosition(0, 0), settler.Position())
+	assertRejected(t, c.Validate(Build{Player: 0, City: hex.NewPosition(0, 0), Unit: "Warrior"}), RejectAlreadyBuilt)
+
+	// The new settler is on the city and next to it, so it cannot found another.
+	assertRejected(t, c.Validate(Found{Player: 0, Unit: 2}), RejectCityTooClose)
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 2, To: hex.NewPosition(0, 1)})
+	require.NoError(t, err)
+	assertRejected(t, c.Validate(Found{Player: 0, Unit: 2}), RejectCityTooClose)
+}
+
+func TestBuild_Occupied(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+	_, err := c.Execute(Found{Player: 0, Unit: 1})
+	require.NoError(t, err)
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(0, 1)})
+	require.NoError(t, err)
+	_, err = c.Execute(EndTurn{Player: 0})
+	require.NoError(t, err)
+	_, err = c.Execute(EndTurn{Player: 1})
+	require.NoError(t, err)
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(0, 0)})
+	require.NoError(t, err)
+	assertRejected(t, c.Validate(Build{Player: 0, City: hex.NewPosition(0, 0), Unit: "Warrior"}), RejectOccupied)
+}
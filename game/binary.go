@@ -0,0 +1,211 @@
+package game
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// GameMagic identifies a binary game file.
+const GameMagic = "4HXG"
+
+// BinaryVersion is the version written into binary game files. Decoding
+// accepts every version up to and including this one.
+const BinaryVersion = 3
+
+// maxSaveCount bounds decoded player and unit counts, guarding against corrupt input.
+const maxSaveCount = 1 << 20
+
+// maxSaveName bounds decoded player and unit names.
+const maxSaveName = 1 << 12
+
+// gameState is the decoded content of a game file, before it becomes a Game.
+type gameState struct {
+	gameMap   hex.Map
+	players   []playerState
+	turn      int
+	phase     Phase
+	active    int
+	randState []byte // nil keeps the generator of a new game
+}
+
+type playerState struct {
+	name  string
+	units []*unitState // nil entries are empty unit slots
+}
+
+type unitState struct {
+	name     string
+	position hex.Position
+}
+
+// gameMigrations upgrade a decoded state from the version at their key to the next one.
+var gameMigrations = map[int]func(*gameState) error{
+	// Version 1 files predate turns and saved randomness.
+	1: func(s *gameState) error {
+		s.turn = 1
+		return nil
+	},
+	// Version 2 files predate phases; they resume at the start of the first
+	// player's turn, which the zero values already are.
+}
+
+// EncodeBinary writes the game's map, players, units, turn, phase, active
+// player and random number generator state as a binary game file.
+func EncodeBinary(w io.Writer, g Game, opts hex.BinaryOptions) error {
+	if g == nil {
+		return fmt.Errorf("cannot encode nil game")
+	}
+	payload, err := hex.WriteBinaryHeader(w, GameMagic, BinaryVersion, opts)
+	if err != nil {
+		return err
+	}
+	bw := hex.NewBinaryWriter(payload)
+	if err := writeGameState(bw, g); err != nil {
+		return err
+	}
+	if err := bw.Err(); err != nil {
+		return err
+	}
+	return payload.Close()
+}
+
+// DecodeBinary reads a binary game file written by EncodeBinary, upgrading
+// older versions.
+func DecodeBinary(r io.Reader) (Game, error) {
+	version, payload, err := hex.ReadBinaryHeader(r, GameMagic)
+	if err != nil {
+		return nil, err
+	}
+	return readGame(hex.NewBinaryReader(payload), int(version))
+}
+
+// readGame reads a game payload of the given version, upgrading it to a Game.
+func readGame(br *hex.BinaryReader, version int) (Game, error) {
+	if version < 1 || version > BinaryVersion {
+		return nil, fmt.Errorf("unsupported binary game version %d", version)
+	}
+	state, err := readGameState(br, version)
+	if err != nil {
+		return nil, err
+	}
+	for v := version; v < BinaryVersion; v++ {
+		if migrate, ok := gameMigrations[v]; ok {
+			if err := migrate(state); err != nil {
+				return nil, fmt.Errorf("failed to migrate game from version %d: %w", v, err)
+			}
+		}
+	}
+	return state.build()
+}
+
+func writeGameState(bw *hex.BinaryWriter, g Game) error {
+	randState, err := g.RandState()
+	if err != nil {
+		return fmt.Errorf("failed to save random state: %w", err)
+	}
+	m := g.GetMap()
+	bw.WriteBool(m != nil)
+	if m != nil {
+		bw.WriteUvarint(hex.FormatVersion)
+		hex.WriteMapPayload(bw, m)
+	}
+	bw.WriteUvarint(uint64(len(g.GetPlayers())))
+	for _, p := range g.GetPlayers() {
+		bw.WriteString(p.GetName())
+		bw.WriteUvarint(uint64(p.GetUnitCount()))
+		for i := 0; i < p.GetUnitCount(); i++ {
+			u, _ := p.GetUnitAt(i)
+			bw.WriteBool(u != nil)
+			if u != nil {
+				bw.WriteString(u.GetName())
+				bw.WriteInt(u.Position().Q)
+				bw.WriteInt(u.Position().R)
+			}
+		}
+	}
+	bw.WriteInt(g.GetTurn())
+	bw.WriteString(string(randState))
+	bw.WriteUvarint(uint64(g.GetPhase()))
+	bw.WriteInt(g.GetActivePlayerIndex())
+	return nil
+}
+
+// readGameState reads a payload of the given version. Fields added in later
+// versions are read only when present.
+func readGameState(br *hex.BinaryReader, version int) (*gameState, error) {
+	state := &gameState{}
+	if br.ReadBool() {
+		m, err := hex.ReadMapPayload(br, int(br.ReadUvarint()))
+		if err != nil {
+			return nil, err
+		}
+		state.gameMap = m
+	}
+	players := br.ReadUvarint()
+	if players > maxSaveCount {
+		return nil, fmt.Errorf("player count %d exceeds limit", players)
+	}
+	for i := uint64(0); i < players && br.Err() == nil; i++ {
+		p := playerState{name: br.ReadString(maxSaveName)}
+		units := br.ReadUvarint()
+		if units > maxSaveCount {
+			return nil, fmt.Errorf("unit count %d exceeds limit", units)
+		}
+		for j := uint64(0); j < units && br.Err() == nil; j++ {
+			if !br.ReadBool() {
+				p.units = append(p.units, nil)
+				continue
+			}
+			u := &unitState{name: br.ReadString(maxSaveName)}
+			u.position.Q = br.ReadInt()
+			u.position.R = br.ReadInt()
+			p.units = append(p.units, u)
+		}
+		state.players = append(state.players, p)
+	}
+	if version >= 2 {
+		state.turn = br.ReadInt()
+		state.randState = []byte(br.ReadString(maxSaveName))
+	}
+	if version >= 3 {
+		state.phase = Phase(br.ReadUvarint())
+		state.active = br.ReadInt()
+		if _, ok := phaseNames[state.phase]; !ok && br.Err() == nil {
+			return nil, fmt.Errorf("unknown phase %d", int(state.phase))
+		}
+	}
+	if err := br.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read game: %w", err)
+	}
+	return state, nil
+}
+
+// build turns a decoded state into a Game.
+func (s *gameState) build() (Game, error) {
+	g := NewGame()
+	g.SetMap(s.gameMap)
+	g.SetTurn(s.turn)
+	g.SetPhase(s.phase)
+	g.SetActivePlayerIndex(s.active)
+	if s.randState != nil {
+		if err := g.SetRandState(s.randState); err != nil {
+			return nil, fmt.Errorf("failed to restore random state: %w", err)
+		}
+	}
+	for _, ps := range s.players {
+		p := NewPlayer(ps.name)
+		for _, us := range ps.units {
+			if us == nil {
+				p.AddUnit(nil)
+				continue
+			}
+			u := hex.NewUnit(us.name)
+			u.Move(us.position)
+			p.AddUnit(u)
+		}
+		g.AddPlayer(p)
+	}
+	return g, nil
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSavedGame(t *testing.T) Game {
+	g := NewGame()
+	m := hex.NewMap(12, 8)
+	require.NoError(t, m.AddGrid(newValueGrid("terrain", 12, 8, 1)))
+	g.SetMap(m)
+
+	alice := NewPlayer("Alice")
+	warrior := hex.NewUnit("Warrior")
+	warrior.Move(hex.NewPosition(3, 4))
+	alice.AddUnit(warrior)
+	alice.AddUnit(nil)
+	settler := hex.NewUnit("Settler")
+	settler.Move(hex.NewPosition(-1, 2))
+	alice.AddUnit(settler)
+	g.AddPlayer(alice)
+	g.AddPlayer(NewPlayer("Bob"))
+	return g
+}
+
+func assertGamesEqual(t *testing.T, want, got Game) {
+	assert := assert.New(t)
+	if want.GetMap() == nil {
+		assert.Nil(got.GetMap())
+	} else {
+		wantMap, err := hex.MarshalMap(want.GetMap())
+		require.NoError(t, err)
+		gotMap, err := hex.MarshalMap(got.GetMap())
+		require.NoError(t, err)
+		assert.JSONEq(string(wantMap), string(gotMap))
+	}
+	require.Len(t, got.GetPlayers(), len(want.GetPlayers()))
+	for i, wp := range want.GetPlayers() {
+		gp := got.GetPlayers()[i]
+		assert.Equal(wp.GetName(), gp.GetName())
+		require.Equal(t, wp.GetUnitCount(), gp.GetUnitCount())
+		for j := 0; j < wp.GetUnitCount(); j++ {
+			wu, _ := wp.GetUnitAt(j)
+			gu, _ := gp.GetUnitAt(j)
+			if wu == nil {
+				assert.Nil(gu)
+				continue
+			}
+			require.NotNil(t, gu)
+			assert.Equal(wu.GetName(), gu.GetName())
+			assert.Equal(wu.Position(), gu.Position())
+		}
+	}
+}
+
+func TestGameBinary_RoundTrip(t *testing.T) {
+	for _, gz := range []bool{false, true} {
+		for name, g := range map[string]Game{"full": newSavedGame(t), "empty": NewGame()} {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeBinary(&buf, g, hex.BinaryOptions{Gzip: gz}), name)
+			decoded, err := DecodeBinary(&buf)
+			require.NoError(t, err, name)
+			assertGamesEqual(t, g, decoded)
+		}
+	}
+}
+
+func TestGameBinary_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeBinary(&buf, newSavedGame(t), hex.BinaryOptions{}))
+	data := buf.Bytes()
+
+	_, err := DecodeBinary(bytes.NewReader(data[:len(data)-3]))
+	assert.Error(t, err, "truncated")
+	_, err = DecodeBinary(bytes.NewReader(append([]byte(hex.MapMagic), data[4:]...)))
+	assert.Error(t, err, "map file is not a game file")
+	_, err = DecodeBinary(bytes.NewReader(append([]byte(GameMagic), append([]byte{99, 0}, data[6:]...)...)))
+	assert.Error(t, err, "future version")
+	assert.Error(t, EncodeBinary(&buf, nil, hex.BinaryOptions{}))
+}
@@ -0,0 +1,506 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+)
+
+// Command is a game action issued by a player. UI, AI and network code all
+// issue commands through a Commander, which validates them against the game
+// state before applying them and can undo them within the turn. Commands
+// belong to a phase of the turn, such as PhaseMovement for moves, and are
+// rejected outside it when the turn manager uses that phase.
+type Command interface {
+	// Name identifies the kind of command, such as "move".
+	Name() string
+	// String describes the command for people, such as "player 0 moves unit
+	// 0 to 1,0".
+	String() string
+	// player returns the index of the player issuing the command.
+	player() int
+	// validate returns a *Rejection when the command cannot be applied.
+	validate(c *Commander) error
+	// apply changes the game. It runs only after validate passed. It returns
+	// the inverse restoring the game and the Commander's bookkeeping exactly,
+	// or nil when the command cannot be undone.
+	apply(c *Commander, r *Result) (inverse func() error, err error)
+}
+
+// UnitRef identifies a unit by its player index and unit slot.
+type UnitRef struct {
+	Player int
+	Unit   int
+}
+
+// Result describes what an applied command did.
+type Result struct {
+	Command Command
+	// Turn and Player are the turn and player index the command was issued in.
+	Turn   int
+	Player int
+	// Created and Destroyed list the units the command added and removed.
+	Created   []UnitRef
+	Destroyed []UnitRef
+	// Cities lists the positions of cities founded by the command.
+	Cities []hex.Position
+
+	inverse func() error
+}
+
+// Undoable reports whether the command can be undone.
+func (r *Result) Undoable() bool {
+	return r.inverse != nil
+}
+
+// Reason is why a command was rejected.
+type Reason int
+
+// Reasons a command is rejected.
+const (
+	RejectUnknownPlayer Reason = iota
+	RejectNotYourTurn
+	RejectUnknownUnit
+	RejectOutOfRange
+	RejectImpassable
+	RejectOccupied
+	RejectNoMovesLeft
+	RejectNoTarget
+	RejectNotSettler
+	RejectCityTooClose
+	RejectNotYourCity
+	RejectAlreadyBuilt
+	RejectNotBuildable
+	RejectWrongPhase
+)
+
+var reasonNames = map[Reason]string{
+	RejectUnknownPlayer: "unknown player",
+	RejectNotYourTurn:   "not your turn",
+	RejectUnknownUnit:   "unknown unit",
+	RejectOutOfRange:    "out of range",
+	RejectImpassable:    "impassable",
+	RejectOccupied:      "occupied",
+	RejectNoMovesLeft:   "no moves left",
+	RejectNoTarget:      "no target",
+	RejectNotSettler:    "not a settler",
+	RejectCityTooClose:  "city too close",
+	RejectNotYourCity:   "not your city",
+	RejectAlreadyBuilt:  "already built",
+	RejectNotBuildable:  "not buildable",
+	RejectWrongPhase:    "wrong phase",
+}
+
+// String implements the Stringer interface for Reason.
+func (r Reason) String() string {
+	if name, ok := reasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// Rejection is the error returned for a command that failed validation.
+// The game is unchanged.
+type Rejection struct {
+	Command string
+	Reason  Reason
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%s rejected (%s): %s", r.Command, r.Reason, r.Message)
+}
+
+func reject(cmd Command, reason Reason, format string, args ...any) *Rejection {
+	return &Rejection{Command: cmd.Name(), Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// CityLayer is the map layer holding cities. Each cell stores the index of
+// the player owning a city there, or NoCity.
+const CityLayer = "cities"
+
+// NoCity marks a cell of the city layer without a city.
+const NoCity = -1
+
+// Rules are the game rules commands are validated against.
+type Rules struct {
+	// Terrain names the layer units move over. Without it every hex on the
+	// map is passable. Defaults to "terrain".
+	Terrain string
+	// Land lists the terrain values units may enter. Defaults to generator.Land.
+	Land []int
+	// Moves is the number of hexes a unit may move per turn. Defaults to 1.
+	Moves int
+	// AttackOdds is the chance that an attacker wins. Defaults to 0.5.
+	AttackOdds float64
+	// Settler names the unit that founds cities. Defaults to "Settler".
+	Settler string
+	// Buildable names the units cities may build. Defaults to Warrior and Settler.
+	Buildable []string
+}
+
+func (r *Rules) defaults() {
+	if r.Terrain == "" {
+		r.Terrain = "terrain"
+	}
+	if len(r.Land) == 0 {
+		r.Land = []int{generator.Land}
+	}
+	if r.Moves <= 0 {
+		r.Moves = 1
+	}
+	if r.AttackOdds <= 0 {
+		r.AttackOdds = 0.5
+	}
+	if r.Settler == "" {
+		r.Settler = "Settler"
+	}
+	if len(r.Buildable) == 0 {
+		r.Buildable = []string{"Warrior", r.Settler}
+	}
+}
+
+// Commander validates and applies commands against a game. It tracks what
+// units and cities have done this turn, which resets whenever the turn or
+// active player changes; that bookkeeping is not saved with the game.
+//
+// Players undo and redo their own commands back to the start of the phase.
+// A command can only be undone while no command another player applied after
+// it is still in effect, since that command may depend on it. Ending a turn
+// cannot be undone and drops the player's redo stack; the turn, phase or
+// active player changing clears every stack.
+type Commander struct {
+	game  Game
+	turns *TurnManager
+	rules Rules
+
+	turn, active int
+	phase        Phase
+	moved        map[UnitRef]int
+	built        map[hex.Position]bool
+	ended        map[int]bool
+	undo         []*Result         // undoable commands of every player, oldest first
+	redo         map[int][]*Result // undone commands per player
+	replay       *Replay           // records applied commands when not nil
+	applying     bool              // a command is being applied
+}
+
+// NewCommander creates a Commander for g, ending turns through turns.
+func NewCommander(g Game, turns *TurnManager, rules Rules) (*Commander, error) {
+	if g == nil {
+		return nil, fmt.Errorf("game cannot be nil")
+	}
+	if turns == nil || turns.game != g {
+		return nil, fmt.Errorf("turn manager must manage the same game")
+	}
+	rules.defaults()
+	c := &Commander{game: g, turns: turns, rules: rules}
+	c.sync()
+	return c, nil
+}
+
+// Game returns the game commands are applied to.
+func (c *Commander) Game() Game {
+	return c.game
+}
+
+// Turns returns the turn manager commands end turns through.
+func (c *Commander) Turns() *TurnManager {
+	return c.turns
+}
+
+// Validate checks cmd against the game state without applying it. It
+// returns a *Rejection when cmd cannot be applied.
+func (c *Commander) Validate(cmd Command) error {
+	if cmd == nil {
+		return fmt.Errorf("command cannot be nil")
+	}
+	c.sync()
+	return cmd.validate(c)
+}
+
+// Execute validates cmd and applies it. A rejected command returns a
+// *Rejection and leaves the game unchanged. Executing a command clears the
+// redo stack of its player.
+func (c *Commander) Execute(cmd Command) (*Result, error) {
+	r, err := c.execute(cmd)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.redo, cmd.player())
+	c.record(cmd)
+	if r.Undoable() {
+		c.undo = append(c.undo, r)
+	}
+	return r, nil
+}
+
+func (c *Commander) execute(cmd Command) (*Result, error) {
+	if err := c.checkRecording(); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(cmd); err != nil {
+		return nil, err
+	}
+	r := &Result{Command: cmd, Turn: c.game.GetTurn(), Player: c.game.GetActivePlayerIndex()}
+	c.applying = true
+	inverse, err := cmd.apply(c, r)
+	c.applying = false
+	if err != nil {
+		return nil, err
+	}
+	r.inverse = inverse
+	return r, nil
+}
+
+// CanUndo reports whether the player can undo a command.
+func (c *Commander) CanUndo(player int) bool {
+	_, err := c.lastUndoable(player)
+	return err == nil
+}
+
+// CanRedo reports whether the player has an undone command to redo.
+func (c *Commander) CanRedo(player int) bool {
+	c.sync()
+	return len(c.redo[player]) > 0
+}
+
+// Undo reverts the last command the player applied this phase and returns
+// its result.
+func (c *Commander) Undo(player int) (*Result, error) {
+	r, err := c.lastUndoable(player)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.inverse(); err != nil {
+		return nil, fmt.Errorf("failed to undo %s: %w", r.Command.Name(), err)
+	}
+	c.undo = c.undo[:len(c.undo)-1]
+	c.redo[player] = append(c.redo[player], r)
+	if c.replay != nil {
+		// Commands applied since were not undoable, so the player's command
+		// is the last recorded one equal to it.
+		for i := len(c.replay.Actions) - 1; i >= 0; i-- {
+			if c.replay.Actions[i] == r.Command {
+				c.replay.Actions = slices.Delete(c.replay.Actions, i, i+1)
+				break
+			}
+		}
+	}
+	return r, nil
+}
+
+// lastUndoable returns the result the player would undo.
+func (c *Commander) lastUndoable(player int) (*Result, error) {
+	c.sync()
+	if c.ended[player] {
+		return nil, fmt.Errorf("player %d has ended the turn", player)
+	}
+	if len(c.undo) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+	r := c.undo[len(c.undo)-1]
+	if other := r.Command.player(); other != player {
+		return nil, fmt.Errorf("player %d cannot undo past a command of player %d", player, other)
+	}
+	return r, nil
+}
+
+// Redo applies the last command the player undid again and returns its new
+// result. Commands drawing randomness repeat their outcome, since undoing
+// restores the game's random number generator.
+func (c *Commander) Redo(player int) (*Result, error) {
+	c.sync()
+	stack := c.redo[player]
+	if len(stack) == 0 {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+	r, err := c.execute(stack[len(stack)-1].Command)
+	if err != nil {
+		return nil, err
+	}
+	c.redo[player] = stack[:len(stack)-1]
+	c.undo = append(c.undo, r)
+	c.record(r.Command)
+	return r, nil
+}
+
+// record appends an applied command to the replay being recorded.
+func (c *Commander) record(cmd Command) {
+	if c.replay != nil {
+		c.replay.Actions = append(c.replay.Actions, cmd)
+		c.replay.at = positionOf(c.game)
+	}
+}
+
+// recordAdvance records a phase advanced through the turn manager directly,
+// so playback advances it too.
+func (c *Commander) recordAdvance() {
+	if !c.applying {
+		c.record(AdvancePhase{})
+	}
+}
+
+// checkRecording fails when the game moved to another turn, phase or player
+// without going through the turn manager, which a replay cannot record.
+func (c *Commander) checkRecording() error {
+	if c.replay == nil {
+		return nil
+	}
+	if at := positionOf(c.game); at != c.replay.at {
+		return fmt.Errorf("game moved from %s to %s outside the turn manager; the replay cannot record it", c.replay.at, at)
+	}
+	return nil
+}
+
+// sync clears the undo and redo stacks when the phase changed, and resets the
+// per-turn bookkeeping when a new turn or player's turn began.
+func (c *Commander) sync() {
+	turn, active, phase := c.game.GetTurn(), c.game.GetActivePlayerIndex(), c.game.GetPhase()
+	if c.moved != nil && turn == c.turn && active == c.active && phase == c.phase {
+		return
+	}
+	c.phase = phase
+	c.undo, c.redo = nil, make(map[int][]*Result)
+	if c.moved != nil && turn == c.turn && active == c.active {
+		return
+	}
+	c.turn, c.active = turn, active
+	c.moved = make(map[UnitRef]int)
+	c.built = make(map[hex.Position]bool)
+	c.ended = make(map[int]bool)
+}
+
+// keepMoved returns a function restoring the moves ref has used to their
+// current count.
+func (c *Commander) keepMoved(ref UnitRef) func() {
+	moved, ok := c.moved[ref]
+	return func() {
+		if ok {
+			c.moved[ref] = moved
+		} else {
+			delete(c.moved, ref)
+		}
+	}
+}
+
+// checkPlayer rejects commands from unknown players and players whose turn it is not.
+func (c *Commander) checkPlayer(cmd Command, player int) error {
+	if player < 0 || player >= len(c.game.GetPlayers()) {
+		return reject(cmd, RejectUnknownPlayer, "no player %d", player)
+	}
+	active := c.game.GetActivePlayerIndex()
+	if (active != AllPlayers && active != player) || c.ended[player] {
+		return reject(cmd, RejectNotYourTurn, "player %d cannot act now", player)
+	}
+	return nil
+}
+
+// checkPhase rejects commands issued outside their phase. Games whose turn
+// manager does not use the phase allow the command in any phase.
+func (c *Commander) checkPhase(cmd Command, phase Phase) error {
+	if current := c.game.GetPhase(); current != phase && slices.Contains(c.turns.Phases(), phase) {
+		return reject(cmd, RejectWrongPhase, "%s is only allowed in the %s phase, not %s", cmd.Name(), phase, current)
+	}
+	return nil
+}
+
+// unit returns a unit of the player, which must exist.
+func (c *Commander) unit(cmd Command, ref UnitRef) (hex.Unit, error) {
+	if err := c.checkPlayer(cmd, ref.Player); err != nil {
+		return nil, err
+	}
+	u, err := c.game.GetPlayers()[ref.Player].GetUnitAt(ref.Unit)
+	if err != nil || u == nil {
+		return nil, reject(cmd, RejectUnknownUnit, "player %d has no unit %d", ref.Player, ref.Unit)
+	}
+	return u, nil
+}
+
+// normalize wraps pos into the map, reporting false when it is off the map.
+func (c *Commander) normalize(pos hex.Position) (hex.Position, bool) {
+	m := c.game.GetMap()
+	if m == nil {
+		return pos, false
+	}
+	return m.Normalize(pos)
+}
+
+// adjacent reports whether two map positions are neighbors.
+func (c *Commander) adjacent(a, b hex.Position) bool {
+	m := c.game.GetMap()
+	return m != nil && m.Distance(a, b) == 1
+}
+
+// passable reports whether units may enter pos.
+func (c *Commander) passable(pos hex.Position) bool {
+	terrain, err := c.game.GetMap().GetGridByName(c.rules.Terrain)
+	if err != nil {
+		return true
+	}
+	cell, _ := layerCell(terrain, pos)
+	return cell != nil && slices.Contains(c.rules.Land, cell.GetValue())
+}
+
+// unitAt returns the unit standing on pos, if any.
+func (c *Commander) unitAt(pos hex.Position) (UnitRef, hex.Unit, bool) {
+	for i, p := range c.game.GetPlayers() {
+		for j := 0; j < p.GetUnitCount(); j++ {
+			u, _ := p.GetUnitAt(j)
+			if u == nil {
+				continue
+			}
+			if at, ok := c.normalize(u.Position()); ok && at == pos {
+				return UnitRef{Player: i, Unit: j}, u, true
+			}
+		}
+	}
+	return UnitRef{}, nil, false
+}
+
+// cityAt returns the owner of the city on pos, if any.
+func (c *Commander) cityAt(pos hex.Position) (int, bool) {
+	cities, err := c.game.GetMap().GetGridByName(CityLayer)
+	if err != nil {
+		return NoCity, false
+	}
+	cell, _ := layerCell(cities, pos)
+	if cell == nil || cell.GetValue() == NoCity {
+		return NoCity, false
+	}
+	return cell.GetValue(), true
+}
+
+// cityLayer returns the city layer, adding an empty one covering the map
+// when the map has none yet. It reports whether the layer was added.
+func (c *Commander) cityLayer() (hex.Grid, bool, error) {
+	m := c.game.GetMap()
+	if cities, err := m.GetGridByName(CityLayer); err == nil {
+		return cities, false, nil
+	}
+	width, height := m.GetDimensions()
+	cells := make([][]hex.Cell, height)
+	for r := range cells {
+		cells[r] = make([]hex.Cell, width)
+		for q := range cells[r] {
+			cells[r][q] = hex.NewCellWithValue(q, r, NoCity)
+		}
+	}
+	cities := hex.NewGrid(hex.NewPosition(0, 0), CityLayer, width, height, cells)
+	if err := m.AddGrid(cities); err != nil {
+		return nil, false, err
+	}
+	return cities, true, nil
+}
+
+// layerCell returns the cell of g at a map position, wrapping it into the grid.
+func layerCell(g hex.Grid, pos hex.Position) (hex.Cell, bool) {
+	local, ok := g.Normalize(pos.Sub(g.GetPosition()))
+	if !ok {
+		return nil, false
+	}
+	cell, _ := g.GetCellAtPosition(local)
+	return cell, cell != nil
+}
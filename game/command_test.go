@@ -0,0 +1,349 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCommandGame returns a two player game on a 6x4 land map with water at
+// (3,0). Alice has a Warrior at (1,1) and a Settler at (0,0); Bob has a
+// Warrior at (2,1).
+func newCommandGame(t *testing.T) Game {
+	t.Helper()
+	g := NewGame()
+	m := hex.NewMap(6, 4)
+	terrain := newValueGrid("terrain", 6, 4, generator.Land)
+	cell, err := terrain.GetCellAt(3, 0)
+	require.NoError(t, err)
+	cell.SetValue(generator.Water)
+	require.NoError(t, m.AddGrid(terrain))
+	g.SetMap(m)
+
+	place := func(p Player, name string, q, r int) {
+		u := hex.NewUnit(name)
+		u.Move(hex.NewPosition(q, r))
+		p.AddUnit(u)
+	}
+	alice, bob := NewPlayer("Alice"), NewPlayer("Bob")
+	place(alice, "Warrior", 1, 1)
+	place(alice, "Settler", 0, 0)
+	place(bob, "Warrior", 2, 1)
+	g.AddPlayer(alice)
+	g.AddPlayer(bob)
+	return g
+}
+
+// newTestCommander returns a Commander for g whose turns have a single phase,
+// so commands of every kind can be issued together.
+func newTestCommander(t *testing.T, g Game, mode TurnMode, rules Rules) *Commander {
+	t.Helper()
+	turns, err := NewTurnManager(g, TurnOptions{Phases: []Phase{PhaseStart}, Mode: mode})
+	require.NoError(t, err)
+	c, err := NewCommander(g, turns, rules)
+	require.NoError(t, err)
+	return c
+}
+
+// assertRejected checks that err is a *Rejection for the given reason.
+func assertRejected(t *testing.T, err error, reason Reason) {
+	t.Helper()
+	var rejection *Rejection
+	require.True(t, errors.As(err, &rejection), "expected a rejection, got %v", err)
+	assert.Equal(t, reason, rejection.Reason, rejection.Error())
+}
+
+func TestReason_String(t *testing.T) {
+	assert.Equal(t, "not your turn", RejectNotYourTurn.String())
+	assert.Equal(t, "Reason(99)", Reason(99).String())
+}
+
+func TestCommand_String(t *testing.T) {
+	for want, cmd := range map[string]Command{
+		"player 0 moves unit 1 to 2,3":               MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(2, 3)},
+		"player 1 attacks 4,0 with unit 2":           Attack{Player: 1, Unit: 2, Target: hex.NewPosition(4, 0)},
+		"player 0 founds a city with unit 3":         Found{Player: 0, Unit: 3},
+		"player 1 builds Warrior in the city at 0,1": Build{Player: 1, City: hex.NewPosition(0, 1), Unit: "Warrior"},
+		"player 0 ends the turn":                     EndTurn{Player: 0},
+		"the next phase begins":                      AdvancePhase{},
+	} {
+		assert.Equal(t, want, cmd.String())
+	}
+}
+
+func TestRejection_Error(t *testing.T) {
+	r := &Rejection{Command: "move", Reason: RejectOccupied, Message: "hex is occupied"}
+	assert.EqualError(t, r, "move rejected (occupied): hex is occupied")
+}
+
+func TestNewCommander_Errors(t *testing.T) {
+	g := NewGame()
+	turns, err := NewTurnManager(g, TurnOptions{})
+	require.NoError(t, err)
+	_, err = NewCommander(nil, turns, Rules{})
+	assert.Error(t, err)
+	_, err = NewCommander(g, nil, Rules{})
+	assert.Error(t, err)
+	_, err = NewCommander(NewGame(), turns, Rules{})
+	assert.Error(t, err, "the turn manager must belong to the game")
+}
+
+func TestCommander_ChecksPlayer(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+
+	assertRejected(t, c.Validate(EndTurn{Player: 5}), RejectUnknownPlayer)
+	assertRejected(t, c.Validate(EndTurn{Player: 1}), RejectNotYourTurn)
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 9, To: hex.NewPosition(1, 2)}), RejectUnknownUnit)
+	assert.NoError(t, c.Validate(EndTurn{Player: 0}))
+	_, err := c.Execute(nil)
+	assert.Error(t, err)
+}
+
+func TestCommander_ChecksPhase(t *testing.T) {
+	g := newCommandGame(t)
+	turns, err := NewTurnManager(g, TurnOptions{})
+	require.NoError(t, err)
+	c, err := NewCommander(g, turns, Rules{})
+	require.NoError(t, err)
+	move := MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(0, 1)}
+	attack := Attack{Player: 0, Unit: 0, Target: hex.NewPosition(2, 1)}
+	found := Found{Player: 0, Unit: 1}
+
+	assertRejected(t, c.Validate(move), RejectWrongPhase)
+	assert.NoError(t, c.Validate(EndTurn{Player: 0}), "turns can end in any phase")
+
+	require.NoError(t, turns.Advance())
+	require.Equal(t, PhaseMovement, g.GetPhase())
+	_, err = c.Execute(move)
+	require.NoError(t, err)
+	assertRejected(t, c.Validate(attack), RejectWrongPhase)
+	assert.True(t, c.CanUndo(0))
+
+	require.NoError(t, turns.Advance())
+	assert.False(t, c.CanUndo(0), "undo stops at the start of the phase")
+	assertRejected(t, c.Validate(found), RejectWrongPhase)
+	_, err = c.Execute(attack)
+	require.NoError(t, err)
+
+	require.NoError(t, turns.Advance())
+	_, err = c.Execute(found)
+	require.NoError(t, err)
+	assertRejected(t, c.Validate(Build{Player: 0, City: hex.NewPosition(0, 2), Unit: "Warrior"}), RejectNotYourCity)
+
+	// Commands whose phase the turns do not use are allowed in any phase.
+	g = newCommandGame(t)
+	turns, err = NewTurnManager(g, TurnOptions{Phases: []Phase{PhaseStart, PhaseCombat}})
+	require.NoError(t, err)
+	c, err = NewCommander(g, turns, Rules{})
+	require.NoError(t, err)
+	assert.NoError(t, c.Validate(move))
+	assertRejected(t, c.Validate(attack), RejectWrongPhase)
+}
+
+func TestCommander_RejectedLeavesGameUnchanged(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+	before := newCommandGame(t)
+
+	_, err := c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(2, 1)})
+	assertRejected(t, err, RejectOccupied)
+	assertGamesEqual(t, before, g)
+}
+
+func TestCommander_Simultaneous(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Simultaneous, Rules{})
+
+	_, err := c.Execute(MoveUnit{Player: 1, Unit: 0, To: hex.NewPosition(3, 1)})
+	require.NoError(t, err)
+	_, err = c.Execute(EndTurn{Player: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, g.GetTurn(), "the turn waits for every player")
+	assertRejected(t, c.Validate(EndTurn{Player: 1}), RejectNotYourTurn)
+
+	result, err := c.Execute(EndTurn{Player: 0})
+	require.NoError(t, err)
+	assert.Equal(t, AllPlayers, result.Player)
+	assert.Equal(t, 2, g.GetTurn())
+	assert.NoError(t, c.Validate(EndTurn{Player: 1}))
+}
+
+// snapshot encodes the whole game, including its random number generator.
+func snapshot(t *testing.T, g Game) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, EncodeBinary(&buf, g, hex.BinaryOptions{}))
+	return buf.Bytes()
+}
+
+func TestCommander_UndoRestoresState(t *testing.T) {
+	g := newCommandGame(t)
+	g.Seed(3)
+	c := newTestCommander(t, g, Sequential, Rules{Moves: 2})
+	commands := []Command{
+		MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(0, 1)},
+		Found{Player: 0, Unit: 1},
+		Build{Player: 0, City: hex.NewPosition(0, 1), Unit: "Warrior"},
+		Attack{Player: 0, Unit: 0, Target: hex.NewPosition(2, 1)},
+	}
+
+	var states [][]byte
+	for _, cmd := range commands {
+		states = append(states, snapshot(t, g))
+		result, err := c.Execute(cmd)
+		require.NoError(t, err, cmd.Name())
+		assert.True(t, result.Undoable())
+
+		after := snapshot(t, g)
+		_, err = c.Undo(0)
+		require.NoError(t, err)
+		assert.Equal(t, states[len(states)-1], snapshot(t, g), "undoing %s", cmd.Name())
+		_, err = c.Redo(0)
+		require.NoError(t, err)
+		assert.Equal(t, after, snapshot(t, g), "redoing %s", cmd.Name())
+	}
+	for i := len(commands) - 1; i >= 0; i-- {
+		result, err := c.Undo(0)
+		require.NoError(t, err)
+		assert.Equal(t, commands[i], result.Command)
+		assert.Equal(t, states[i], snapshot(t, g))
+	}
+	assert.False(t, c.CanUndo(0))
+	_, err := c.Undo(0)
+	assert.Error(t, err)
+
+	// Every undone command can be redone in order.
+	for range commands {
+		_, err := c.Redo(0)
+		require.NoError(t, err)
+	}
+	assert.False(t, c.CanRedo(0))
+	_, err = c.Redo(0)
+	assert.Error(t, err)
+}
+
+func TestCommander_UndoRestoresBookkeeping(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+	move := MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 2)}
+
+	_, err := c.Execute(move)
+	require.NoError(t, err)
+	assertRejected(t, c.Validate(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 1)}), RejectNoMovesLeft)
+	_, err = c.Undo(0)
+	require.NoError(t, err)
+	assert.NoError(t, c.Validate(move))
+}
+
+func TestCommander_NewCommandClearsRedo(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+
+	_, err := c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 2)})
+	require.NoError(t, err)
+	_, err = c.Undo(0)
+	require.NoError(t, err)
+	assert.True(t, c.CanRedo(0))
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(1, 0)})
+	require.NoError(t, err)
+	assert.False(t, c.CanRedo(0))
+	assert.True(t, c.CanUndo(0))
+}
+
+func TestCommander_UndoStopsAtTurnBoundary(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Sequential, Rules{})
+
+	_, err := c.Execute(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 2)})
+	require.NoError(t, err)
+	result, err := c.Execute(EndTurn{Player: 0})
+	require.NoError(t, err)
+	assert.False(t, result.Undoable())
+	assert.False(t, c.CanUndo(0))
+
+	_, err = c.Execute(MoveUnit{Player: 1, Unit: 0, To: hex.NewPosition(3, 1)})
+	require.NoError(t, err)
+	_, err = c.Undo(0)
+	assert.Error(t, err, "player 0 cannot undo player 1's move")
+	_, err = c.Undo(1)
+	require.NoError(t, err)
+	assert.False(t, c.CanUndo(1), "player 0's move belongs to an earlier turn")
+	assert.Equal(t, hex.NewPosition(1, 2), unitAt(t, g, UnitRef{0, 0}).Position())
+
+	// Turns advanced outside the commander also clear the stacks.
+	_, err = c.Execute(MoveUnit{Player: 1, Unit: 0, To: hex.NewPosition(3, 1)})
+	require.NoError(t, err)
+	require.NoError(t, c.turns.EndTurn())
+	assert.False(t, c.CanUndo(1))
+}
+
+func TestCommander_SimultaneousUndo(t *testing.T) {
+	g := newCommandGame(t)
+	c := newTestCommander(t, g, Simultaneous, Rules{})
+	aliceMove := MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(1, 2)}
+	bobMove := MoveUnit{Player: 1, Unit: 0, To: hex.NewPosition(3, 1)}
+
+	_, err := c.Execute(aliceMove)
+	require.NoError(t, err)
+	_, err = c.Execute(EndTurn{Player: 1})
+	require.NoError(t, err)
+	assert.True(t, c.CanUndo(0), "Bob ending his turn keeps Alice's history")
+	assert.False(t, c.CanUndo(1))
+	_, err = c.Undo(0)
+	require.NoError(t, err)
+	assert.Equal(t, hex.NewPosition(1, 1), unitAt(t, g, UnitRef{0, 0}).Position())
+	_, err = c.Redo(0)
+	require.NoError(t, err)
+	assert.Equal(t, hex.NewPosition(1, 2), unitAt(t, g, UnitRef{0, 0}).Position())
+
+	// A player cannot undo past a later command of another player, which
+	// may depend on theirs.
+	g = newCommandGame(t)
+	c = newTestCommander(t, g, Simultaneous, Rules{})
+	_, err = c.Execute(aliceMove)
+	require.NoError(t, err)
+	_, err = c.Execute(bobMove)
+	require.NoError(t, err)
+	assert.False(t, c.CanUndo(0))
+	_, err = c.Undo(0)
+	assert.ErrorContains(t, err, "past a command of player 1")
+	_, err = c.Undo(1)
+	require.NoError(t, err)
+	assert.False(t, c.CanRedo(0))
+	assert.True(t, c.CanRedo(1))
+	_, err = c.Undo(0)
+	require.NoError(t, err)
+
+	// Ending the turn drops only that player's redo stack.
+	_, err = c.Execute(EndTurn{Player: 1})
+	require.NoError(t, err)
+	assert.False(t, c.CanRedo(1))
+	assert.True(t, c.CanRedo(0))
+
+	// The turn advancing clears every stack.
+	_, err = c.Execute(EndTurn{Player: 0})
+	require.NoError(t, err)
+	assert.Equal(t, 2, g.GetTurn())
+	assert.False(t, c.CanRedo(0))
+}
+
+func TestCommander_RedoRepeatsRandomOutcome(t *testing.T) {
+	for seed := uint64(0); seed < 8; seed++ {
+		g := newCommandGame(t)
+		g.Seed(seed)
+		c := newTestCommander(t, g, Sequential, Rules{})
+		first, err := c.Execute(Attack{Player: 0, Unit: 0, Target: hex.NewPosition(2, 1)})
+		require.NoError(t, err)
+		_, err = c.Undo(0)
+		require.NoError(t, err)
+		again, err := c.Redo(0)
+		require.NoError(t, err)
+		assert.Equal(t, first.Destroyed, again.Destroyed, "seed %d", seed)
+	}
+}
@@ -1,6 +1,9 @@
 package game
 
 import (
+	"io"
+	"math/rand/v2"
+
 	"github.com/klumhru/4hex/hex"
 )
 
@@ -8,12 +11,50 @@ import (
 type Game interface {
 	SetMap(m hex.Map)
 	AddPlayer(p Player)
+	// GetMap returns the game map, or nil if none has been set.
+	GetMap() hex.Map
+	// GetPlayers returns the players in the order they were added.
+	GetPlayers() []Player
+	// GetTurn returns the current turn number, starting at 1.
+	GetTurn() int
+	// SetTurn sets the current turn number.
+	SetTurn(turn int)
+	// GetPhase returns the current phase of the turn.
+	GetPhase() Phase
+	// SetPhase sets the current phase of the turn.
+	SetPhase(phase Phase)
+	// GetActivePlayerIndex returns the index of the player whose turn it is,
+	// or AllPlayers when every player acts at once.
+	GetActivePlayerIndex() int
+	// SetActivePlayerIndex sets the index of the player whose turn it is.
+	SetActivePlayerIndex(index int)
+	// GetActivePlayer returns the player whose turn it is, or nil when every
+	// player acts at once or the index is out of range.
+	GetActivePlayer() Player
+	// Rand returns the game's random number generator. All game randomness
+	// should come from it so that saved games continue identically.
+	Rand() *rand.Rand
+	// Seed resets the random number generator to a known state.
+	Seed(seed uint64)
+	// RandState returns the serialized state of the random number generator.
+	RandState() ([]byte, error)
+	// SetRandState restores a state returned by RandState.
+	SetRandState(state []byte) error
+	// Save writes the whole game to w in the binary game format.
+	Save(w io.Writer) error
+	// Load replaces the whole game with one read from r.
+	Load(r io.Reader) error
 }
 
 // concreteGame implements the Game interface.
 type concreteGame struct {
 	gameMap hex.Map
 	players []Player
+	turn    int
+	phase   Phase
+	active  int
+	source  *rand.PCG
+	rng     *rand.Rand
 }
 
 func (g *concreteGame) SetMap(m hex.Map) {
@@ -24,7 +65,76 @@ func (g *concreteGame) AddPlayer(p Player) {
 	g.players = append(g.players, p)
 }
 
+func (g *concreteGame) GetMap() hex.Map {
+	return g.gameMap
+}
+
+func (g *concreteGame) GetPlayers() []Player {
+	return g.players
+}
+
+func (g *concreteGame) GetTurn() int {
+	return g.turn
+}
+
+func (g *concreteGame) SetTurn(turn int) {
+	g.turn = turn
+}
+
+func (g *concreteGame) GetPhase() Phase {
+	return g.phase
+}
+
+func (g *concreteGame) SetPhase(phase Phase) {
+	g.phase = phase
+}
+
+func (g *concreteGame) GetActivePlayerIndex() int {
+	return g.active
+}
+
+func (g *concreteGame) SetActivePlayerIndex(index int) {
+	g.active = index
+}
+
+func (g *concreteGame) GetActivePlayer() Player {
+	if g.active < 0 || g.active >= len(g.players) {
+		return nil
+	}
+	return g.players[g.active]
+}
+
+func (g *concreteGame) Rand() *rand.Rand {
+	return g.rng
+}
+
+func (g *concreteGame) Seed(seed uint64) {
+	g.source.Seed(seed, seed)
+}
+
+func (g *concreteGame) RandState() ([]byte, error) {
+	return g.source.MarshalBinary()
+}
+
+func (g *concreteGame) SetRandState(state []byte) error {
+	return g.source.UnmarshalBinary(state)
+}
+
+func (g *concreteGame) Save(w io.Writer) error {
+	return EncodeBinary(w, g, hex.BinaryOptions{Gzip: true})
+}
+
+func (g *concreteGame) Load(r io.Reader) error {
+	loaded, err := DecodeBinary(r)
+	if err != nil {
+		return err
+	}
+	*g = *loaded.(*concreteGame)
+	return nil
+}
+
 // NewGame creates a new Game.
 func NewGame() Game {
-	return &concreteGame{players: []Player{}}
+	source := rand.NewPCG(0, 0)
+	return &concreteGame{players: []Player{}, turn: 1, source: source, rng: rand.New(source)}
 }
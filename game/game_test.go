@@ -100,3 +100,17 @@ func TestConcreteGame_Integration(t *testing.T) {
 	assert.Contains(cg.players, playerAlice)
 	assert.Contains(cg.players, playerBob)
 }
+
+func TestConcreteGame_Getters(t *testing.T) {
+	assert := assert.New(t)
+	game := NewGame()
+	assert.Nil(game.GetMap())
+	assert.Empty(game.GetPlayers())
+
+	m := hex.NewMap(3, 3)
+	p := NewPlayer("Alice")
+	game.SetMap(m)
+	game.AddPlayer(p)
+	assert.Equal(m, game.GetMap())
+	assert.Equal([]Player{p}, game.GetPlayers())
+}
@@ -13,6 +13,8 @@ type Player interface {
 	GetUnitCount() int
 	GetUnitAt(index int) (hex.Unit, error)
 	SetUnitAt(index int, unit hex.Unit) error
+	// RemoveUnitAt removes the unit slot at index, shifting later units down.
+	RemoveUnitAt(index int) error
 }
 
 // concretePlayer implements the Player interface.
@@ -54,6 +56,16 @@ func (p *concretePlayer) SetUnitAt(index int, unit hex.Unit) error {
 	return nil
 }
 
+// RemoveUnitAt removes the unit slot at the specified index.
+// Returns an error if the index is out of bounds.
+func (p *concretePlayer) RemoveUnitAt(index int) error {
+	if index < 0 || index >= len(p.units) {
+		return fmt.Errorf("index out of bounds: %d", index)
+	}
+	p.units = append(p.units[:index], p.units[index+1:]...)
+	return nil
+}
+
 // NewPlayer creates a new Player.
 func NewPlayer(name string) Player {
 	return &concretePlayer{name: name, units: []hex.Unit{}}
@@ -115,3 +115,20 @@ func TestConcretePlayer_SetUnitAt(t *testing.T) {
 	err = pEmpty.SetUnitAt(0, u3)
 	assert.Error(t, err, "SetUnitAt(0) on an empty player should return an error")
 }
+
+func TestConcretePlayer_RemoveUnitAt(t *testing.T) {
+	p := NewPlayer("TestPlayer")
+	u1 := hex.NewUnit("Warrior")
+	u2 := hex.NewUnit("Archer")
+	p.AddUnit(u1)
+	p.AddUnit(nil)
+	p.AddUnit(u2)
+
+	assert.NoError(t, p.RemoveUnitAt(1))
+	assert.Equal(t, 2, p.GetUnitCount())
+	unit, _ := p.GetUnitAt(1)
+	assert.Equal(t, u2, unit, "later units shift down")
+
+	assert.Error(t, p.RemoveUnitAt(-1))
+	assert.Error(t, p.RemoveUnitAt(2))
+}
@@ -0,0 +1,375 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// ReplayMagic identifies a replay file.
+const ReplayMagic = "4HXR"
+
+// ReplayVersion is the version written into replay files.
+const ReplayVersion = 1
+
+// maxReplayActions bounds decoded action counts, guarding against corrupt input.
+const maxReplayActions = 1 << 24
+
+// Replay records everything needed to rebuild a game exactly: the seed, the
+// game before the first action, including its map and players, the turn
+// options and rules, and every action applied in order.
+type Replay struct {
+	Seed    uint64
+	Turns   TurnOptions
+	Rules   Rules
+	Actions []Command
+
+	initial []byte       // the game before the first action, as a game file
+	at      turnPosition // where the recorded game is after the last action
+}
+
+// turnPosition is the turn, phase and active player of a game.
+type turnPosition struct {
+	turn   int
+	phase  Phase
+	player int
+}
+
+func positionOf(g Game) turnPosition {
+	return turnPosition{turn: g.GetTurn(), phase: g.GetPhase(), player: g.GetActivePlayerIndex()}
+}
+
+func (p turnPosition) String() string {
+	return fmt.Sprintf("turn %d, %s phase, player %d", p.turn, p.phase, p.player)
+}
+
+// NewReplay seeds g, records its state and returns a Commander whose applied
+// commands are recorded in the replay. Undone commands are dropped from it.
+// Phases advanced through the Commander's turn manager directly are recorded
+// as AdvancePhase actions. Changing the game's turn, phase or active player
+// any other way makes the Commander reject further commands, since playback
+// could not follow.
+func NewReplay(g Game, seed uint64, turns TurnOptions, rules Rules) (*Replay, *Commander, error) {
+	if g == nil {
+		return nil, nil, fmt.Errorf("game cannot be nil")
+	}
+	g.Seed(seed)
+	var buf bytes.Buffer
+	if err := EncodeBinary(&buf, g, hex.BinaryOptions{}); err != nil {
+		return nil, nil, err
+	}
+	tm, err := NewTurnManager(g, turns)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := NewCommander(g, tm, rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &Replay{Seed: seed, Turns: turns, Rules: rules, initial: buf.Bytes(), at: positionOf(g)}
+	c.replay = r
+	tm.advanced = c.recordAdvance
+	return r, c, nil
+}
+
+// Initial returns a copy of the game as it was before the first action.
+func (r *Replay) Initial() (Game, error) {
+	if r.initial == nil {
+		return nil, fmt.Errorf("replay has no initial game")
+	}
+	return DecodeBinary(bytes.NewReader(r.initial))
+}
+
+// Play starts playing the replay back from the initial game. Hooks that
+// change the game must be registered on the playback's turn manager before
+// stepping, as they were when recording, for the game to come out the same.
+func (r *Replay) Play() (*Playback, error) {
+	g, err := r.Initial()
+	if err != nil {
+		return nil, err
+	}
+	tm, err := NewTurnManager(g, r.Turns)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCommander(g, tm, r.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Playback{replay: r, commander: c}, nil
+}
+
+// Rebuild plays the replay back up to the start of the given turn, or to the
+// end when the game never reaches it, and returns the game.
+func (r *Replay) Rebuild(turn int) (Game, error) {
+	p, err := r.Play()
+	if err != nil {
+		return nil, err
+	}
+	for !p.Done() && p.Game().GetTurn() < turn {
+		if _, err := p.Step(); err != nil {
+			return nil, err
+		}
+	}
+	return p.Game(), nil
+}
+
+// Playback applies the actions of a replay one at a time.
+type Playback struct {
+	replay    *Replay
+	commander *Commander
+	next      int
+}
+
+// Game returns the game being played back.
+func (p *Playback) Game() Game {
+	return p.commander.Game()
+}
+
+// Turns returns the turn manager of the game being played back.
+func (p *Playback) Turns() *TurnManager {
+	return p.commander.Turns()
+}
+
+// Done reports whether every action has been applied.
+func (p *Playback) Done() bool {
+	return p.next >= len(p.replay.Actions)
+}
+
+// Step applies the next action. It returns io.EOF when every action has been
+// applied, and an error naming the action when it is rejected, which means
+// playback has diverged from the recording.
+func (p *Playback) Step() (*Result, error) {
+	if p.Done() {
+		return nil, io.EOF
+	}
+	cmd := p.replay.Actions[p.next]
+	result, err := p.commander.Execute(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("replay action %d (%s): %w", p.next, cmd.Name(), err)
+	}
+	p.next++
+	return result, nil
+}
+
+// StepTurn applies actions until the turn changes or the replay ends, and
+// returns their results.
+func (p *Playback) StepTurn() ([]*Result, error) {
+	turn := p.Game().GetTurn()
+	var results []*Result
+	for !p.Done() && p.Game().GetTurn() == turn {
+		result, err := p.Step()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Action kinds in replay files.
+const (
+	actionMove = iota
+	actionAttack
+	actionFound
+	actionBuild
+	actionEndTurn
+	actionAdvance
+)
+
+// EncodeReplay writes r as a replay file.
+func EncodeReplay(w io.Writer, r *Replay, opts hex.BinaryOptions) error {
+	if r == nil {
+		return fmt.Errorf("cannot encode nil replay")
+	}
+	initial, err := r.Initial()
+	if err != nil {
+		return err
+	}
+	payload, err := hex.WriteBinaryHeader(w, ReplayMagic, ReplayVersion, opts)
+	if err != nil {
+		return err
+	}
+	bw := hex.NewBinaryWriter(payload)
+	bw.WriteUvarint(r.Seed)
+	bw.WriteUvarint(uint64(r.Turns.Mode))
+	bw.WriteUvarint(uint64(len(r.Turns.Phases)))
+	for _, phase := range r.Turns.Phases {
+		bw.WriteUvarint(uint64(phase))
+	}
+	writeRules(bw, r.Rules)
+	bw.WriteUvarint(BinaryVersion)
+	if err := writeGameState(bw, initial); err != nil {
+		return err
+	}
+	bw.WriteUvarint(uint64(len(r.Actions)))
+	for _, cmd := range r.Actions {
+		if err := writeAction(bw, cmd); err != nil {
+			return err
+		}
+	}
+	if err := bw.Err(); err != nil {
+		return err
+	}
+	return payload.Close()
+}
+
+// DecodeReplay reads a replay file written by EncodeReplay.
+func DecodeReplay(r io.Reader) (*Replay, error) {
+	version, payload, err := hex.ReadBinaryHeader(r, ReplayMagic)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > ReplayVersion {
+		return nil, fmt.Errorf("unsupported replay version %d", version)
+	}
+	br := hex.NewBinaryReader(payload)
+	replay := &Replay{Seed: br.ReadUvarint()}
+	replay.Turns.Mode = TurnMode(br.ReadUvarint())
+	phases := br.ReadUvarint()
+	if phases > uint64(len(phaseNames)) {
+		return nil, fmt.Errorf("phase count %d exceeds limit", phases)
+	}
+	for i := uint64(0); i < phases; i++ {
+		replay.Turns.Phases = append(replay.Turns.Phases, Phase(br.ReadUvarint()))
+	}
+	if replay.Rules, err = readRules(br); err != nil {
+		return nil, err
+	}
+	initial, err := readGame(br, int(br.ReadUvarint()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read initial game: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := EncodeBinary(&buf, initial, hex.BinaryOptions{}); err != nil {
+		return nil, err
+	}
+	replay.initial = buf.Bytes()
+
+	actions := br.ReadUvarint()
+	if actions > maxReplayActions {
+		return nil, fmt.Errorf("action count %d exceeds limit", actions)
+	}
+	for i := uint64(0); i < actions && br.Err() == nil; i++ {
+		cmd, err := readAction(br)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+		replay.Actions = append(replay.Actions, cmd)
+	}
+	if err := br.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read replay: %w", err)
+	}
+	return replay, nil
+}
+
+func writeRules(bw *hex.BinaryWriter, rules Rules) {
+	bw.WriteString(rules.Terrain)
+	bw.WriteUvarint(uint64(len(rules.Land)))
+	for _, v := range rules.Land {
+		bw.WriteInt(v)
+	}
+	bw.WriteInt(rules.Moves)
+	bw.WriteUvarint(math.Float64bits(rules.AttackOdds))
+	bw.WriteString(rules.Settler)
+	bw.WriteUvarint(uint64(len(rules.Buildable)))
+	for _, name := range rules.Buildable {
+		bw.WriteString(name)
+	}
+}
+
+func readRules(br *hex.BinaryReader) (Rules, error) {
+	rules := Rules{Terrain: br.ReadString(maxSaveName)}
+	land := br.ReadUvarint()
+	if land > maxSaveCount {
+		return rules, fmt.Errorf("land value count %d exceeds limit", land)
+	}
+	for i := uint64(0); i < land && br.Err() == nil; i++ {
+		rules.Land = append(rules.Land, br.ReadInt())
+	}
+	rules.Moves = br.ReadInt()
+	rules.AttackOdds = math.Float64frombits(br.ReadUvarint())
+	rules.Settler = br.ReadString(maxSaveName)
+	buildable := br.ReadUvarint()
+	if buildable > maxSaveCount {
+		return rules, fmt.Errorf("buildable count %d exceeds limit", buildable)
+	}
+	for i := uint64(0); i < buildable && br.Err() == nil; i++ {
+		rules.Buildable = append(rules.Buildable, br.ReadString(maxSaveName))
+	}
+	return rules, br.Err()
+}
+
+func writeAction(bw *hex.BinaryWriter, cmd Command) error {
+	switch cmd := cmd.(type) {
+	case MoveUnit:
+		bw.WriteUvarint(actionMove)
+		bw.WriteInt(cmd.Player)
+		bw.WriteInt(cmd.Unit)
+		bw.WriteInt(cmd.To.Q)
+		bw.WriteInt(cmd.To.R)
+	case Attack:
+		bw.WriteUvarint(actionAttack)
+		bw.WriteInt(cmd.Player)
+		bw.WriteInt(cmd.Unit)
+		bw.WriteInt(cmd.Target.Q)
+		bw.WriteInt(cmd.Target.R)
+	case Found:
+		bw.WriteUvarint(actionFound)
+		bw.WriteInt(cmd.Player)
+		bw.WriteInt(cmd.Unit)
+	case Build:
+		bw.WriteUvarint(actionBuild)
+		bw.WriteInt(cmd.Player)
+		bw.WriteInt(cmd.City.Q)
+		bw.WriteInt(cmd.City.R)
+		bw.WriteString(cmd.Unit)
+	case EndTurn:
+		bw.WriteUvarint(actionEndTurn)
+		bw.WriteInt(cmd.Player)
+	case AdvancePhase:
+		bw.WriteUvarint(actionAdvance)
+		bw.WriteInt(AllPlayers)
+	default:
+		return fmt.Errorf("cannot encode %s command", cmd.Name())
+	}
+	return nil
+}
+
+func readAction(br *hex.BinaryReader) (Command, error) {
+	kind := br.ReadUvarint()
+	player := br.ReadInt()
+	position := func() hex.Position {
+		q := br.ReadInt()
+		return hex.NewPosition(q, br.ReadInt())
+	}
+	var cmd Command
+	switch kind {
+	case actionMove:
+		unit := br.ReadInt()
+		cmd = MoveUnit{Player: player, Unit: unit, To: position()}
+	case actionAttack:
+		unit := br.ReadInt()
+		cmd = Attack{Player: player, Unit: unit, Target: position()}
+	case actionFound:
+		cmd = Found{Player: player, Unit: br.ReadInt()}
+	case actionBuild:
+		city := position()
+		cmd = Build{Player: player, City: city, Unit: br.ReadString(maxSaveName)}
+	case actionEndTurn:
+		cmd = EndTurn{Player: player}
+	case actionAdvance:
+		cmd = AdvancePhase{}
+	default:
+		if br.Err() == nil {
+			return nil, fmt.Errorf("unknown action kind %d", kind)
+		}
+	}
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordReplay plays a few turns of the command test game, undoing one
+// command, and returns the replay with snapshots taken at the start of each
+// turn and at the end.
+func recordReplay(t *testing.T) (*Replay, map[int][]byte, []byte) {
+	t.Helper()
+	g := newCommandGame(t)
+	replay, c, err := NewReplay(g, 42, TurnOptions{Phases: []Phase{PhaseStart}}, Rules{Moves: 2})
+	require.NoError(t, err)
+	turns := map[int][]byte{1: snapshot(t, g)}
+	run := func(cmd Command) {
+		t.Helper()
+		_, err := c.Execute(cmd)
+		require.NoError(t, err, cmd.Name())
+		if _, seen := turns[g.GetTurn()]; !seen {
+			turns[g.GetTurn()] = snapshot(t, g)
+		}
+	}
+
+	run(MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(0, 1)})
+	run(Found{Player: 0, Unit: 1})
+	run(MoveUnit{Player: 0, Unit: 0, To: hex.NewPosition(2, 0)})
+	_, err = c.Undo(0)
+	require.NoError(t, err)
+	run(EndTurn{Player: 0})
+	run(MoveUnit{Player: 1, Unit: 0, To: hex.NewPosition(1, 2)})
+	run(EndTurn{Player: 1})
+	run(Build{Player: 0, City: hex.NewPosition(0, 1), Unit: "Warrior"})
+	run(Attack{Player: 0, Unit: 0, Target: hex.NewPosition(1, 2)})
+	run(EndTurn{Player: 0})
+	run(EndTurn{Player: 1})
+	run(EndTurn{Player: 0})
+	return replay, turns, snapshot(t, g)
+}
+
+func TestReplay_Records(t *testing.T) {
+	replay, _, _ := recordReplay(t)
+	assert.Equal(t, uint64(42), replay.Seed)
+	assert.Len(t, replay.Actions, 10, "the undone move is not recorded")
+	assert.Equal(t, EndTurn{Player: 0}, replay.Actions[2])
+
+	initial, err := replay.Initial()
+	require.NoError(t, err)
+	assertGamesEqual(t, newCommandGame(t), initial)
+}
+
+func TestReplay_RebuildsEveryTurn(t *testing.T) {
+	replay, turns, final := recordReplay(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeReplay(&buf, replay, hex.BinaryOptions{Gzip: true}))
+	decoded, err := DecodeReplay(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, replay.Actions, decoded.Actions)
+	assert.Equal(t, replay.Rules, decoded.Rules)
+
+	for turn, want := range turns {
+		g, err := decoded.Rebuild(turn)
+		require.NoError(t, err)
+		assert.Equal(t, want, snapshot(t, g), "turn %d", turn)
+	}
+	g, err := decoded.Rebuild(100)
+	require.NoError(t, err)
+	assert.Equal(t, final, snapshot(t, g))
+}
+
+func TestPlayback_Step(t *testing.T) {
+	replay, _, _ := recordReplay(t)
+	p, err := replay.Play()
+	require.NoError(t, err)
+
+	results, err := p.StepTurn()
+	require.NoError(t, err)
+	assert.Len(t, results, 5, "turn 1 ends with Bob's end-turn")
+	assert.Equal(t, 2, p.Game().GetTurn())
+
+	result, err := p.Step()
+	require.NoError(t, err)
+	assert.Equal(t, Build{Player: 0, City: hex.NewPosition(0, 1), Unit: "Warrior"}, result.Command)
+	for !p.Done() {
+		_, err := p.Step()
+		require.NoError(t, err)
+	}
+	_, err = p.Step()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestPlayback_Diverged(t *testing.T) {
+	replay, _, _ := recordReplay(t)
+	replay.Actions = append([]Command{EndTurn{Player: 1}}, replay.Actions...)
+	_, err := replay.Rebuild(100)
+	var rejection *Rejection
+	assert.ErrorAs(t, err, &rejection)
+	assert.Contains(t, err.Error(), "replay action 0 (end-turn)")
+}
+
+func TestDecodeReplay_Errors(t *testing.T) {
+	_, err := DecodeReplay(bytes.NewReader([]byte("4HXG")))
+	assert.Error(t, err)
+
+	replay, _, _ := recordReplay(t)
+	var buf bytes.Buffer
+	require.NoError(t, EncodeReplay(&buf, replay, hex.BinaryOptions{}))
+	data := buf.Bytes()
+	_, err = DecodeReplay(bytes.NewReader(data[:len(data)-3]))
+	assert.Error(t, err, "truncated files fail")
+
+	// The file ends with the kind and player of the final end-turn.
+	bad := bytes.Clone(data)
+	bad[len(bad)-2] = 99
+	_, err = DecodeReplay(bytes.NewReader(bad))
+	assert.ErrorContains(t, err, "unknown action kind 99")
+
+	assert.Error(t, EncodeReplay(&buf, &Replay{}, hex.BinaryOptions{}))
+}
+
+func TestReplay_RecordsTurnManagerAdvances(t *testing.T) {
+	g := newCommandGame(t)
+	replay, c, err := NewReplay(g, 7, TurnOptions{}, Rules{Moves: 2})
+	require.NoError(t, err)
+	skipStart := func(tm *TurnManager) Hook {
+		return func(g Game) error { return tm.Advance() }
+	}
+	tm := c.Turns()
+	tm.OnPhase(PhaseStart, skipStart(tm))
+
+	require.NoError(t, tm.Advance(), "start to movement, outside the commander")
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(0, 1)})
+	require.NoError(t, err)
+	require.NoError(t, tm.EndTurn(), "the hook skips Bob's start phase")
+	assert.Equal(t, PhaseMovement, g.GetPhase())
+	_, err = c.Execute(MoveUnit{Player: 1, Unit: 0, To: hex.NewPosition(1, 2)})
+	require.NoError(t, err)
+	_, err = c.Execute(EndTurn{Player: 1})
+	require.NoError(t, err)
+	final := snapshot(t, g)
+
+	advances := 0
+	for _, cmd := range replay.Actions {
+		if cmd == (AdvancePhase{}) {
+			advances++
+		}
+	}
+	assert.Equal(t, 5, advances, "hook and end-turn advances are not recorded twice")
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeReplay(&buf, replay, hex.BinaryOptions{}))
+	decoded, err := DecodeReplay(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, replay.Actions, decoded.Actions)
+
+	p, err := decoded.Play()
+	require.NoError(t, err)
+	p.Turns().OnPhase(PhaseStart, skipStart(p.Turns()))
+	for !p.Done() {
+		_, err := p.Step()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, final, snapshot(t, p.Game()))
+}
+
+func TestReplay_RejectsUnrecordedTurnChanges(t *testing.T) {
+	g := newCommandGame(t)
+	_, c, err := NewReplay(g, 7, TurnOptions{}, Rules{Moves: 2})
+	require.NoError(t, err)
+	g.SetPhase(PhaseMovement)
+	_, err = c.Execute(MoveUnit{Player: 0, Unit: 1, To: hex.NewPosition(0, 1)})
+	assert.ErrorContains(t, err, "outside the turn manager")
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGame_SaveLoadContinuesIdentically(t *testing.T) {
+	assert := assert.New(t)
+	g := newSavedGame(t)
+	g.Seed(1234)
+	g.SetTurn(17)
+	g.SetPhase(PhaseCombat)
+	g.SetActivePlayerIndex(1)
+	for i := 0; i < 10; i++ {
+		g.Rand().IntN(100) // advance the generator mid-game
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, g.Save(&buf))
+	saved := bytes.Clone(buf.Bytes())
+
+	loaded := NewGame()
+	require.NoError(t, loaded.Load(&buf))
+	assertGamesEqual(t, g, loaded)
+	assert.Equal(17, loaded.GetTurn())
+	assert.Equal(PhaseCombat, loaded.GetPhase())
+	assert.Equal("Bob", loaded.GetActivePlayer().GetName())
+
+	for i := 0; i < 50; i++ {
+		assert.Equal(g.Rand().Uint64(), loaded.Rand().Uint64(), "draw %d should match after loading", i)
+	}
+
+	var again bytes.Buffer
+	reloaded := NewGame()
+	require.NoError(t, reloaded.Load(bytes.NewReader(saved)))
+	require.NoError(t, reloaded.Save(&again))
+	assert.Equal(saved, again.Bytes(), "saving a loaded game should reproduce the file")
+}
+
+func TestGame_LoadVersion1(t *testing.T) {
+	assert := assert.New(t)
+	// A version 1 file: map, players and units only.
+	var buf bytes.Buffer
+	payload, err := hex.WriteBinaryHeader(&buf, GameMagic, 1, hex.BinaryOptions{})
+	require.NoError(t, err)
+	bw := hex.NewBinaryWriter(payload)
+	bw.WriteBool(false)
+	bw.WriteUvarint(1)
+	bw.WriteString("Alice")
+	bw.WriteUvarint(1)
+	bw.WriteBool(true)
+	bw.WriteString("Scout")
+	bw.WriteInt(2)
+	bw.WriteInt(3)
+	require.NoError(t, bw.Err())
+
+	g := NewGame()
+	require.NoError(t, g.Load(&buf))
+	assert.Nil(g.GetMap())
+	assert.Equal(1, g.GetTurn(), "migrated games start at turn 1")
+	assert.Equal(PhaseStart, g.GetPhase())
+	assert.Equal(0, g.GetActivePlayerIndex())
+	require.Len(t, g.GetPlayers(), 1)
+	u, err := g.GetPlayers()[0].GetUnitAt(0)
+	require.NoError(t, err)
+	assert.Equal("Scout", u.GetName())
+	assert.Equal(hex.NewPosition(2, 3), u.Position())
+	assert.Equal(NewGame().Rand().Uint64(), g.Rand().Uint64(), "migrated games use a fresh generator")
+}
+
+func TestGame_LoadErrorKeepsState(t *testing.T) {
+	g := newSavedGame(t)
+	g.SetTurn(5)
+	assert.Error(t, g.Load(bytes.NewReader([]byte("garbage"))))
+	assert.Equal(t, 5, g.GetTurn())
+	assert.Len(t, g.GetPlayers(), 2)
+}
+
+func TestGame_RandState(t *testing.T) {
+	g := NewGame()
+	g.Seed(9)
+	state, err := g.RandState()
+	require.NoError(t, err)
+	first := g.Rand().Uint64()
+	require.NoError(t, g.SetRandState(state))
+	assert.Equal(t, first, g.Rand().Uint64())
+	assert.Error(t, g.SetRandState([]byte("bad")))
+}
@@ -0,0 +1,247 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+)
+
+// StartOptions configures PlaceStarts.
+type StartOptions struct {
+	// TerrainLayer is the name of the layer that decides where players may start.
+	TerrainLayer string
+	// Habitable lists the terrain values a start may be placed on.
+	// Defaults to generator.Land.
+	Habitable []int
+	// ResourceLayer optionally names a layer whose cell values are resource amounts.
+	// When empty, every start reports zero resources.
+	ResourceLayer string
+	// Radius is the distance around a start within which resources are counted.
+	// Defaults to 2.
+	Radius int
+	// MinDistance is the minimum number of hexes between two starts.
+	// Placement fails if it cannot be met.
+	MinDistance int
+	// Seed drives the randomized search; the same seed yields the same starts.
+	Seed int64
+	// Attempts is the number of randomized restarts to try. Defaults to 32.
+	Attempts int
+}
+
+// StartInfo describes a single start position in a FairnessReport.
+type StartInfo struct {
+	Player    string
+	Position  hex.Position
+	Resources int
+	// Nearest is the distance to the closest other start, or 0 for a single player.
+	Nearest int
+}
+
+// FairnessReport summarizes how balanced a set of start positions is.
+type FairnessReport struct {
+	Starts []StartInfo
+	// MinDistance is the smallest distance between any two starts.
+	MinDistance int
+	// MinResources and MaxResources bound the per-start resource totals.
+	MinResources int
+	MaxResources int
+}
+
+// Spread returns the difference between the richest and poorest start.
+func (r *FairnessReport) Spread() int {
+	return r.MaxResources - r.MinResources
+}
+
+// PlaceStarts chooses one start position per player on habitable land of the
+// map. Candidate sets are grown by farthest-point sampling from random first
+// picks and then locally adjusted to even out resources; the set with the
+// widest spacing and smallest resource spread wins. Returned positions are in
+// map coordinates, in the same order as players. Distances and resource radii
+// wrap with the map's topology.
+func PlaceStarts(m hex.Map, players []Player, opts StartOptions) ([]hex.Position, *FairnessReport, error) {
+	if m == nil {
+		return nil, nil, fmt.Errorf("map cannot be nil")
+	}
+	if len(players) == 0 {
+		return nil, nil, fmt.Errorf("no players to place")
+	}
+	terrain, err := m.GetGridByName(opts.TerrainLayer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("terrain layer: %w", err)
+	}
+	var resources hex.Grid
+	if opts.ResourceLayer != "" {
+		if resources, err = m.GetGridByName(opts.ResourceLayer); err != nil {
+			return nil, nil, fmt.Errorf("resource layer: %w", err)
+		}
+	}
+	habitable := opts.Habitable
+	if len(habitable) == 0 {
+		habitable = []int{generator.Land}
+	}
+	radius := opts.Radius
+	if radius == 0 {
+		radius = 2
+	}
+	attempts := opts.Attempts
+	if attempts == 0 {
+		attempts = 32
+	}
+
+	var candidates []hex.Position
+	for i := 0; i < terrain.GetCellCount(); i++ {
+		cell, _ := terrain.GetCellAtIndex(i)
+		if cell != nil && slices.Contains(habitable, cell.GetValue()) {
+			candidates = append(candidates, cell.GetPosition().Add(terrain.GetPosition()))
+		}
+	}
+	if len(candidates) < len(players) {
+		return nil, nil, fmt.Errorf("only %d habitable cells for %d players", len(candidates), len(players))
+	}
+
+	score := make(map[hex.Position]int, len(candidates))
+	for _, c := range candidates {
+		score[c] = resourcesAround(m, resources, c, radius)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	var best []hex.Position
+	bestCost := math.Inf(1)
+	for a := 0; a < attempts; a++ {
+		starts := spreadStarts(m, candidates, len(players), rng)
+		balanceStarts(m, starts, candidates, score, opts.MinDistance)
+		minDist := minPairDistance(m, starts)
+		if len(players) > 1 && minDist < opts.MinDistance {
+			continue
+		}
+		lo, hi := resourceBounds(starts, score)
+		// Spacing dominates; resource spread breaks ties between similar
+		// spacings. Scores can be negative, so scale by the largest magnitude.
+		cost := -float64(minDist) + float64(hi-lo)/float64(1+max(hi, -lo))
+		if cost < bestCost {
+			best, bestCost = starts, cost
+		}
+	}
+	if best == nil {
+		return nil, nil, fmt.Errorf("could not place %d starts at least %d hexes apart", len(players), opts.MinDistance)
+	}
+
+	return best, newFairnessReport(m, players, best, score), nil
+}
+
+// spreadStarts picks n candidates by farthest-point sampling from a random first pick.
+func spreadStarts(m hex.Map, candidates []hex.Position, n int, rng *rand.Rand) []hex.Position {
+	starts := []hex.Position{candidates[rng.Intn(len(candidates))]}
+	for len(starts) < n {
+		var next hex.Position
+		nextDist := -1
+		for _, c := range candidates {
+			d := math.MaxInt
+			for _, s := range starts {
+				d = min(d, m.Distance(c, s))
+			}
+			if d > nextDist {
+				next, nextDist = c, d
+			}
+		}
+		starts = append(starts, next)
+	}
+	return starts
+}
+
+// balanceStarts nudges each start to a nearby candidate when doing so moves its
+// resources closer to the group average without breaking the minimum distance.
+func balanceStarts(m hex.Map, starts, candidates []hex.Position, score map[hex.Position]int, minDistance int) {
+	const nudge = 2
+	for pass := 0; pass < 3; pass++ {
+		total := 0
+		for _, s := range starts {
+			total += score[s]
+		}
+		mean := float64(total) / float64(len(starts))
+		for i, s := range starts {
+			best, bestDev := s, math.Abs(float64(score[s])-mean)
+			for _, c := range candidates {
+				if m.Distance(c, s) > nudge || c == s {
+					continue
+				}
+				dev := math.Abs(float64(score[c]) - mean)
+				if dev >= bestDev || !keepsDistance(m, starts, i, c, minDistance) {
+					continue
+				}
+				best, bestDev = c, dev
+			}
+			starts[i] = best
+		}
+	}
+}
+
+func keepsDistance(m hex.Map, starts []hex.Position, skip int, c hex.Position, minDistance int) bool {
+	for j, s := range starts {
+		if j != skip && (s == c || m.Distance(s, c) < minDistance) {
+			return false
+		}
+	}
+	return true
+}
+
+func minPairDistance(m hex.Map, starts []hex.Position) int {
+	if len(starts) < 2 {
+		return 0
+	}
+	d := math.MaxInt
+	for i := range starts {
+		for j := i + 1; j < len(starts); j++ {
+			d = min(d, m.Distance(starts[i], starts[j]))
+		}
+	}
+	return d
+}
+
+func resourceBounds(starts []hex.Position, score map[hex.Position]int) (int, int) {
+	lo, hi := math.MaxInt, math.MinInt
+	for _, s := range starts {
+		lo, hi = min(lo, score[s]), max(hi, score[s])
+	}
+	return lo, hi
+}
+
+// resourcesAround sums resource values within radius of pos, given in map
+// coordinates, wrapping with the map's topology.
+func resourcesAround(m hex.Map, resources hex.Grid, pos hex.Position, radius int) int {
+	if resources == nil {
+		return 0
+	}
+	total := 0
+	for _, p := range m.Range(pos, radius) {
+		cell, err := resources.GetCellAtPosition(p.Sub(resources.GetPosition()))
+		if err == nil && cell != nil {
+			total += cell.GetValue()
+		}
+	}
+	return total
+}
+
+func newFairnessReport(m hex.Map, players []Player, starts []hex.Position, score map[hex.Position]int) *FairnessReport {
+	report := &FairnessReport{MinDistance: minPairDistance(m, starts)}
+	report.MinResources, report.MaxResources = resourceBounds(starts, score)
+	for i, s := range starts {
+		nearest := 0
+		for j, o := range starts {
+			if d := m.Distance(s, o); i != j && (nearest == 0 || d < nearest) {
+				nearest = d
+			}
+		}
+		report.Starts = append(report.Starts, StartInfo{
+			Player:    players[i].GetName(),
+			Position:  s,
+			Resources: score[s],
+			Nearest:   nearest,
+		})
+	}
+	return report
+}
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newValueGrid builds a width x height grid whose cells all hold value.
+func newValueGrid(name string, width, height, value int) hex.Grid {
+	cells := make([][]hex.Cell, height)
+	for r := range cells {
+		cells[r] = make([]hex.Cell, width)
+		for q := range cells[r] {
+			cells[r][q] = hex.NewCellWithValue(q, r, value)
+		}
+	}
+	return hex.NewGrid(hex.Position{}, name, width, height, cells)
+}
+
+func newStartMap(t *testing.T) hex.Map {
+	m := hex.NewMap(20, 20)
+	terrain := newValueGrid("terrain", 20, 20, generator.Land)
+	// A band of water down the middle that must never host a start.
+	for r := 0; r < 20; r++ {
+		for q := 8; q < 12; q++ {
+			cell, _ := terrain.GetCellAt(q, r)
+			cell.SetValue(generator.Water)
+		}
+	}
+	resources := newValueGrid("resources", 20, 20, 0)
+	for _, p := range []hex.Position{{Q: 2, R: 2}, {Q: 3, R: 15}, {Q: 16, R: 4}, {Q: 17, R: 17}, {Q: 5, R: 9}} {
+		cell, _ := resources.GetCellAtPosition(p)
+		cell.SetValue(3)
+	}
+	require.NoError(t, m.AddGrid(terrain))
+	require.NoError(t, m.AddGrid(resources))
+	return m
+}
+
+func TestPlaceStarts(t *testing.T) {
+	assert := assert.New(t)
+	m := newStartMap(t)
+	players := []Player{NewPlayer("Alice"), NewPlayer("Bob"), NewPlayer("Carol"), NewPlayer("Dave")}
+
+	starts, report, err := PlaceStarts(m, players, StartOptions{
+		TerrainLayer:  "terrain",
+		ResourceLayer: "resources",
+		MinDistance:   6,
+		Seed:          1,
+	})
+	require.NoError(t, err)
+	require.Len(t, starts, len(players))
+
+	terrain, _ := m.GetGridByName("terrain")
+	for i, s := range starts {
+		cell, err := terrain.GetCellAtPosition(s)
+		require.NoError(t, err)
+		assert.Equal(generator.Land, cell.GetValue(), "start %d should be on land", i)
+		for j := i + 1; j < len(starts); j++ {
+			assert.GreaterOrEqual(s.Distance(starts[j]), 6, "starts %d and %d are too close", i, j)
+		}
+	}
+
+	require.Len(t, report.Starts, len(players))
+	for i, info := range report.Starts {
+		assert.Equal(players[i].GetName(), info.Player)
+		assert.Equal(starts[i], info.Position)
+		assert.GreaterOrEqual(info.Nearest, report.MinDistance)
+	}
+	assert.GreaterOrEqual(report.MinDistance, 6)
+	assert.Equal(report.MaxResources-report.MinResources, report.Spread())
+}
+
+func TestPlaceStarts_Deterministic(t *testing.T) {
+	m := newStartMap(t)
+	players := []Player{NewPlayer("A"), NewPlayer("B"), NewPlayer("C")}
+	opts := StartOptions{TerrainLayer: "terrain", ResourceLayer: "resources", Seed: 99}
+	a, _, err := PlaceStarts(m, players, opts)
+	require.NoError(t, err)
+	b, _, err := PlaceStarts(m, players, opts)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+// newStripMap builds a 10x1 map with land and a resource of -1 at both ends.
+func newStripMap(t *testing.T) hex.Map {
+	m := hex.NewMap(10, 1)
+	terrain := newValueGrid("terrain", 10, 1, generator.Water)
+	resources := newValueGrid("resources", 10, 1, 0)
+	for _, q := range []int{0, 9} {
+		cell, _ := terrain.GetCellAt(q, 0)
+		cell.SetValue(generator.Land)
+		cell, _ = resources.GetCellAt(q, 0)
+		cell.SetValue(-1)
+	}
+	require.NoError(t, m.AddGrid(terrain))
+	require.NoError(t, m.AddGrid(resources))
+	return m
+}
+
+func TestPlaceStarts_NegativeResources(t *testing.T) {
+	players := []Player{NewPlayer("A"), NewPlayer("B")}
+	starts, report, err := PlaceStarts(newStripMap(t), players, StartOptions{TerrainLayer: "terrain", ResourceLayer: "resources"})
+	require.NoError(t, err)
+	assert.Len(t, starts, 2)
+	assert.Equal(t, -1, report.MaxResources)
+}
+
+func TestPlaceStarts_Wraps(t *testing.T) {
+	m := newStripMap(t)
+	players := []Player{NewPlayer("A"), NewPlayer("B")}
+	opts := StartOptions{TerrainLayer: "terrain", MinDistance: 2}
+	_, report, err := PlaceStarts(m, players, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 9, report.MinDistance)
+
+	m.SetTopology(hex.Cylinder)
+	_, _, err = PlaceStarts(m, players, opts)
+	assert.Error(t, err, "the ends of a cylinder are neighbors")
+	opts.MinDistance = 0
+	_, report, err = PlaceStarts(m, players, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.MinDistance)
+}
+
+func TestPlaceStarts_Errors(t *testing.T) {
+	m := newStartMap(t)
+	players := []Player{NewPlayer("A"), NewPlayer("B")}
+
+	tests := []struct {
+		name    string
+		m       hex.Map
+		players []Player
+		opts    StartOptions
+	}{
+		{"nil map", nil, players, StartOptions{TerrainLayer: "terrain"}},
+		{"no players", m, nil, StartOptions{TerrainLayer: "terrain"}},
+		{"missing terrain layer", m, players, StartOptions{TerrainLayer: "nope"}},
+		{"missing resource layer", m, players, StartOptions{TerrainLayer: "terrain", ResourceLayer: "nope"}},
+		{"no habitable terrain", m, players, StartOptions{TerrainLayer: "terrain", Habitable: []int{42}}},
+		{"impossible distance", m, players, StartOptions{TerrainLayer: "terrain", MinDistance: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := PlaceStarts(tt.m, tt.players, tt.opts)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package game
+
+import "fmt"
+
+// Phase is a step of a turn.
+type Phase int
+
+const (
+	// PhaseStart begins a turn, for upkeep such as healing and refreshing moves.
+	PhaseStart Phase = iota
+	// PhaseMovement is when units move.
+	PhaseMovement
+	// PhaseCombat is when units attack.
+	PhaseCombat
+	// PhaseProduction is when cities found, build and produce.
+	PhaseProduction
+	// PhaseEnd closes a turn.
+	PhaseEnd
+)
+
+// DefaultPhases are the phases of a turn, in order.
+var DefaultPhases = []Phase{PhaseStart, PhaseMovement, PhaseCombat, PhaseProduction, PhaseEnd}
+
+var phaseNames = map[Phase]string{
+	PhaseStart:      "start",
+	PhaseMovement:   "movement",
+	PhaseCombat:     "combat",
+	PhaseProduction: "production",
+	PhaseEnd:        "end",
+}
+
+// String implements the Stringer interface for Phase.
+func (p Phase) String() string {
+	if name, ok := phaseNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// AllPlayers is the active player index while every player acts at once.
+const AllPlayers = -1
+
+// TurnMode decides how players share a turn.
+type TurnMode int
+
+const (
+	// Sequential players take their whole turn, every phase, one after another.
+	Sequential TurnMode = iota
+	// Simultaneous players go through each phase together.
+	Simultaneous
+)
+
+// Hook is called when a phase begins. The game's turn, phase and active
+// player are already set; in simultaneous mode there is no active player.
+type Hook func(g Game) error
+
+// TurnOptions configures a TurnManager.
+type TurnOptions struct {
+	// Phases are the phases of a turn, in order. Defaults to DefaultPhases.
+	Phases []Phase
+	// Mode selects sequential or simultaneous player turns.
+	Mode TurnMode
+}
+
+// TurnManager advances a Game through turns and phases and runs the hooks
+// game systems register for each phase. The turn, phase and active player
+// live on the Game, so a loaded game resumes where it was saved.
+type TurnManager struct {
+	game   Game
+	phases []Phase
+	mode   TurnMode
+	hooks  map[Phase][]Hook
+
+	depth    int    // nesting of Advance calls, as hooks may advance too
+	advanced func() // called after each Advance not made by a hook
+}
+
+// NewTurnManager creates a TurnManager for g. The game's phase must be one of
+// the configured phases; its active player is reset to match the mode when
+// it does not.
+func NewTurnManager(g Game, opts TurnOptions) (*TurnManager, error) {
+	if g == nil {
+		return nil, fmt.Errorf("game cannot be nil")
+	}
+	phases := opts.Phases
+	if len(phases) == 0 {
+		phases = DefaultPhases
+	}
+	seen := make(map[Phase]bool)
+	for _, p := range phases {
+		if _, ok := phaseNames[p]; !ok {
+			return nil, fmt.Errorf("unknown phase %s", p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("phase %s listed twice", p)
+		}
+		seen[p] = true
+	}
+	if !seen[g.GetPhase()] {
+		return nil, fmt.Errorf("game is in phase %s, which is not configured", g.GetPhase())
+	}
+	switch opts.Mode {
+	case Sequential:
+		if g.GetActivePlayerIndex() == AllPlayers {
+			g.SetActivePlayerIndex(0)
+		}
+	case Simultaneous:
+		g.SetActivePlayerIndex(AllPlayers)
+	default:
+		return nil, fmt.Errorf("unknown turn mode %d", opts.Mode)
+	}
+	return &TurnManager{game: g, phases: phases, mode: opts.Mode, hooks: make(map[Phase][]Hook)}, nil
+}
+
+// OnPhase registers a hook to run whenever phase begins. Hooks of a phase
+// run in the order they were registered.
+func (tm *TurnManager) OnPhase(phase Phase, hook Hook) {
+	tm.hooks[phase] = append(tm.hooks[phase], hook)
+}
+
+// Phases returns the configured phases in order.
+func (tm *TurnManager) Phases() []Phase {
+	return tm.phases
+}
+
+// Mode returns how players share a turn.
+func (tm *TurnManager) Mode() TurnMode {
+	return tm.mode
+}
+
+// Begin runs the hooks of the game's current phase, for starting a new game
+// or resuming one whose phase has not begun yet.
+func (tm *TurnManager) Begin() error {
+	return tm.runHooks()
+}
+
+// Advance ends the current phase and begins the next one: the next phase of
+// the turn, the first phase of the next player in sequential mode, or the
+// first phase of the next turn. The game moves on before the hooks of the new
+// phase run, so a failing hook leaves the game in the new phase.
+func (tm *TurnManager) Advance() error {
+	tm.depth++
+	defer func() {
+		tm.depth--
+		if tm.depth == 0 && tm.advanced != nil {
+			tm.advanced()
+		}
+	}()
+	g := tm.game
+	if next := tm.phaseIndex() + 1; next < len(tm.phases) {
+		g.SetPhase(tm.phases[next])
+		return tm.runHooks()
+	}
+	g.SetPhase(tm.phases[0])
+	if tm.mode == Sequential {
+		if next := g.GetActivePlayerIndex() + 1; next < len(g.GetPlayers()) {
+			g.SetActivePlayerIndex(next)
+			return tm.runHooks()
+		}
+		g.SetActivePlayerIndex(0)
+	}
+	g.SetTurn(g.GetTurn() + 1)
+	return tm.runHooks()
+}
+
+// EndTurn advances through the remaining phases until the next player's turn
+// in sequential mode, or the next turn in simultaneous mode, begins.
+func (tm *TurnManager) EndTurn() error {
+	g := tm.game
+	turn, player := g.GetTurn(), g.GetActivePlayerIndex()
+	for g.GetTurn() == turn && g.GetActivePlayerIndex() == player {
+		if err := tm.Advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tm *TurnManager) phaseIndex() int {
+	for i, p := range tm.phases {
+		if p == tm.game.GetPhase() {
+			return i
+		}
+	}
+	return 0
+}
+
+func (tm *TurnManager) runHooks() error {
+	g := tm.game
+	for _, hook := range tm.hooks[g.GetPhase()] {
+		if err := hook(g); err != nil {
+			return fmt.Errorf("turn %d, %s phase: %w", g.GetTurn(), g.GetPhase(), err)
+		}
+	}
+	return nil
+}
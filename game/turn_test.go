@@ -0,0 +1,139 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTurnGame returns a game with the named players.
+func newTurnGame(names ...string) Game {
+	g := NewGame()
+	for _, name := range names {
+		g.AddPlayer(NewPlayer(name))
+	}
+	return g
+}
+
+// recordPhases registers a hook on every phase that logs the turn, phase and
+// active player.
+func recordPhases(tm *TurnManager, log *[]string) {
+	for _, phase := range tm.Phases() {
+		tm.OnPhase(phase, func(g Game) error {
+			player := "all"
+			if p := g.GetActivePlayer(); p != nil {
+				player = p.GetName()
+			}
+			*log = append(*log, fmt.Sprintf("%d %s %s", g.GetTurn(), player, g.GetPhase()))
+			return nil
+		})
+	}
+}
+
+func TestPhase_String(t *testing.T) {
+	assert.Equal(t, "production", PhaseProduction.String())
+	assert.Equal(t, "Phase(9)", Phase(9).String())
+}
+
+func TestTurnManager_Sequential(t *testing.T) {
+	g := newTurnGame("Alice", "Bob")
+	tm, err := NewTurnManager(g, TurnOptions{Phases: []Phase{PhaseStart, PhaseMovement, PhaseEnd}})
+	require.NoError(t, err)
+	var log []string
+	recordPhases(tm, &log)
+
+	require.NoError(t, tm.Begin())
+	for i := 0; i < 6; i++ {
+		require.NoError(t, tm.Advance())
+	}
+	assert.Equal(t, []string{
+		"1 Alice start", "1 Alice movement", "1 Alice end",
+		"1 Bob start", "1 Bob movement", "1 Bob end",
+		"2 Alice start",
+	}, log)
+	assert.Equal(t, 2, g.GetTurn())
+	assert.Equal(t, PhaseStart, g.GetPhase())
+	assert.Equal(t, "Alice", g.GetActivePlayer().GetName())
+}
+
+func TestTurnManager_Simultaneous(t *testing.T) {
+	g := newTurnGame("Alice", "Bob")
+	tm, err := NewTurnManager(g, TurnOptions{Mode: Simultaneous})
+	require.NoError(t, err)
+	assert.Equal(t, AllPlayers, g.GetActivePlayerIndex())
+	assert.Nil(t, g.GetActivePlayer())
+	var log []string
+	recordPhases(tm, &log)
+
+	require.NoError(t, tm.EndTurn())
+	assert.Equal(t, []string{"1 all movement", "1 all combat", "1 all production", "1 all end", "2 all start"}, log)
+	assert.Equal(t, 2, g.GetTurn())
+}
+
+func TestTurnManager_EndTurn(t *testing.T) {
+	g := newTurnGame("Alice", "Bob")
+	tm, err := NewTurnManager(g, TurnOptions{})
+	require.NoError(t, err)
+	produced := 0
+	tm.OnPhase(PhaseProduction, func(g Game) error {
+		produced++
+		return nil
+	})
+
+	require.NoError(t, tm.Advance())
+	assert.Equal(t, PhaseMovement, g.GetPhase())
+	require.NoError(t, tm.EndTurn())
+	assert.Equal(t, "Bob", g.GetActivePlayer().GetName())
+	assert.Equal(t, PhaseStart, g.GetPhase())
+	require.NoError(t, tm.EndTurn())
+	assert.Equal(t, 2, g.GetTurn())
+	assert.Equal(t, "Alice", g.GetActivePlayer().GetName())
+	assert.Equal(t, 2, produced)
+}
+
+func TestTurnManager_HookError(t *testing.T) {
+	g := newTurnGame("Alice")
+	tm, err := NewTurnManager(g, TurnOptions{})
+	require.NoError(t, err)
+	boom := errors.New("boom")
+	calls := 0
+	tm.OnPhase(PhaseCombat, func(Game) error { return boom })
+	tm.OnPhase(PhaseCombat, func(Game) error {
+		calls++
+		return nil
+	})
+
+	err = tm.EndTurn()
+	assert.ErrorIs(t, err, boom)
+	assert.EqualError(t, err, "turn 1, combat phase: boom")
+	assert.Equal(t, PhaseCombat, g.GetPhase(), "the game stays in the phase whose hook failed")
+	assert.Zero(t, calls, "later hooks do not run")
+}
+
+func TestTurnManager_Resume(t *testing.T) {
+	g := newTurnGame("Alice", "Bob")
+	g.SetTurn(4)
+	g.SetPhase(PhaseCombat)
+	g.SetActivePlayerIndex(1)
+	tm, err := NewTurnManager(g, TurnOptions{})
+	require.NoError(t, err)
+	require.NoError(t, tm.EndTurn())
+	assert.Equal(t, 5, g.GetTurn())
+	assert.Equal(t, 0, g.GetActivePlayerIndex())
+}
+
+func TestNewTurnManager_Errors(t *testing.T) {
+	_, err := NewTurnManager(nil, TurnOptions{})
+	assert.Error(t, err)
+	_, err = NewTurnManager(NewGame(), TurnOptions{Phases: []Phase{PhaseStart, PhaseStart}})
+	assert.Error(t, err)
+	_, err = NewTurnManager(NewGame(), TurnOptions{Phases: []Phase{Phase(42)}})
+	assert.Error(t, err)
+	_, err = NewTurnManager(NewGame(), TurnOptions{Phases: []Phase{PhaseMovement}})
+	assert.Error(t, err, "a new game starts in a phase that is not configured")
+	_, err = NewTurnManager(NewGame(), TurnOptions{Mode: TurnMode(7)})
+	assert.Error(t, err)
+}
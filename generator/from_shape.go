@@ -6,6 +6,7 @@ import (
 )
 
 // GridFromShape implements hex.GenerateGridFunc. It creates a grid from a shapes.Shape parameter.
+// Each cell inside the shape carries the shape's color at that point as its value.
 func GridFromShape(shape shapes.Shape) (hex.Grid, error) {
 	b := shape.GetBounds()
 	name := shape.GetName()
@@ -15,8 +16,8 @@ func GridFromShape(shape shapes.Shape) (hex.Grid, error) {
 	for r := 0; r < b.Height; r++ {
 		cells[r] = make([]hex.Cell, b.Width)
 		for q := 0; q < b.Width; q++ {
-			if _, err := shape.GetColorAt(b.X+q, b.Y+r); err == nil {
-				cells[r][q] = hex.NewCell(q, r)
+			if color, err := shape.GetColorAt(b.X+q, b.Y+r); err == nil {
+				cells[r][q] = hex.NewCellWithValue(q, r, int(color))
 			} else {
 				cells[r][q] = nil
 			}
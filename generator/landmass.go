@@ -0,0 +1,284 @@
+package generator
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+)
+
+// Terrain values written into land/water layers.
+const (
+	Water = 0
+	Land  = 1
+)
+
+// LandmassOptions configures the Landmasses generator.
+type LandmassOptions struct {
+	// Name is the name of the generated layer. Defaults to the shape's name.
+	Name string
+	// Seed drives every random choice; the same seed yields the same layer.
+	Seed int64
+	// Continents is the number of separate landmasses to grow. Defaults to 1.
+	Continents int
+	// Archipelago scatters many small islands instead of a few continents.
+	// Continents then acts as the minimum number of islands.
+	Archipelago bool
+	// LandRatio is the target fraction of cells that become land, in (0, 1].
+	// Defaults to 0.4.
+	LandRatio float64
+	// MinSeparation is the minimum number of water hexes between two
+	// landmasses. Zero allows landmasses to touch and merge.
+	MinSeparation int
+	// OceanBorder is the number of hexes along the edge of the shape that are
+	// always water. Defaults to 1; NoOceanBorder lets land reach the edge.
+	OceanBorder int
+}
+
+// NoOceanBorder turns off the ocean border of LandmassOptions.
+const NoOceanBorder = -1
+
+// islandSize is the approximate number of hexes per island in archipelago mode.
+const islandSize = 6
+
+// Landmasses returns a hex.GenerateGridFunc that fills the shape with land and
+// water. Land is grown outwards from well spread seeds, each stamped with a
+// shapes.Circle, with coastlines shaped by seeded value noise. Growth stops at
+// the target land ratio or when no landmass can expand without breaking the
+// separation or border constraints, so the ratio is a best-effort target.
+func Landmasses(opts LandmassOptions) hex.GenerateGridFunc {
+	return func(shape shapes.Shape) (hex.Grid, error) {
+		if opts.LandRatio < 0 || opts.LandRatio > 1 {
+			return nil, fmt.Errorf("land ratio %.2f must be between 0 and 1", opts.LandRatio)
+		}
+		if opts.MinSeparation < 0 {
+			return nil, fmt.Errorf("separation cannot be negative")
+		}
+		switch {
+		case opts.OceanBorder == 0:
+			opts.OceanBorder = 1
+		case opts.OceanBorder < 0:
+			opts.OceanBorder = 0
+		}
+		base, err := GridFromShape(shape)
+		if err != nil {
+			return nil, err
+		}
+		ratio := opts.LandRatio
+		if ratio == 0 {
+			ratio = 0.4
+		}
+		name := opts.Name
+		if name == "" {
+			name = base.GetName()
+		}
+
+		g := &landmassGen{
+			opts:  opts,
+			grid:  base,
+			owner: make(map[hex.Position]int),
+			rng:   rand.New(rand.NewSource(opts.Seed)),
+			noise: NewNoise(opts.Seed, 6, 3),
+		}
+		interior := g.interior()
+		target := int(math.Round(ratio * float64(countCells(base))))
+		if len(interior) == 0 || target == 0 {
+			return g.build(name), nil
+		}
+
+		count := max(opts.Continents, 1)
+		if opts.Archipelago {
+			count = max(count, target/islandSize)
+		}
+		seeds := g.placeSeeds(interior, count)
+		g.grow(seeds, target)
+		return g.build(name), nil
+	}
+}
+
+// landmassGen holds the working state of a single Landmasses run.
+type landmassGen struct {
+	opts  LandmassOptions
+	grid  hex.Grid
+	owner map[hex.Position]int // landmass index per land cell
+	rng   *rand.Rand
+	noise *Noise
+	land  int
+}
+
+// interior returns every cell at least OceanBorder steps away from the edge of
+// the shape, in row-major order.
+func (g *landmassGen) interior() []hex.Position {
+	var out []hex.Position
+	for r := 0; r < g.grid.GetHeight(); r++ {
+		for q := 0; q < g.grid.GetWidth(); q++ {
+			p := hex.NewPosition(q, r)
+			if g.isInterior(p) {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+func (g *landmassGen) isInterior(p hex.Position) bool {
+	for _, n := range p.Range(g.opts.OceanBorder) {
+		if cell, err := g.grid.GetCellAtPosition(n); err != nil || cell == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// placeSeeds picks count well spread seed positions using best-candidate
+// sampling: each new seed is the candidate farthest from the seeds so far.
+func (g *landmassGen) placeSeeds(interior []hex.Position, count int) []hex.Position {
+	const candidates = 16
+	count = min(count, len(interior))
+	seeds := []hex.Position{interior[g.rng.Intn(len(interior))]}
+	for len(seeds) < count {
+		best, bestDist := hex.Position{}, -1
+		for i := 0; i < candidates; i++ {
+			c := interior[g.rng.Intn(len(interior))]
+			d := math.MaxInt
+			for _, s := range seeds {
+				d = min(d, c.Distance(s))
+			}
+			if d > bestDist {
+				best, bestDist = c, d
+			}
+		}
+		if bestDist <= g.opts.MinSeparation {
+			break // no room for another separate landmass
+		}
+		seeds = append(seeds, best)
+	}
+	return seeds
+}
+
+// grow stamps each seed and expands the landmasses round-robin until target
+// land cells exist or no landmass can expand further.
+func (g *landmassGen) grow(seeds []hex.Position, target int) {
+	radius := 0
+	if !g.opts.Archipelago {
+		radius = int(math.Sqrt(float64(target)/float64(len(seeds))/math.Pi) / 2)
+	}
+	reach := math.Max(1, math.Sqrt(float64(target)/float64(len(seeds))))
+
+	frontiers := make([]*frontier, len(seeds))
+	for i, s := range seeds {
+		frontiers[i] = &frontier{}
+		stamp := shapes.NewCircle(s.Q, s.R, radius, "seed")
+		b := stamp.GetBounds()
+		for y := b.Y; y < b.Y+b.Height; y++ {
+			for x := b.X; x < b.X+b.Width; x++ {
+				if _, err := stamp.GetColorAt(x, y); err == nil && g.land < target {
+					g.claim(hex.NewPosition(x, y), i, s, reach, frontiers[i])
+				}
+			}
+		}
+	}
+
+	for g.land < target {
+		progressed := false
+		for i, f := range frontiers {
+			for f.Len() > 0 && g.land < target {
+				item := heap.Pop(f).(frontierItem)
+				if g.claim(item.pos, i, seeds[i], reach, f) {
+					progressed = true
+					break
+				}
+			}
+		}
+		if !progressed {
+			return
+		}
+	}
+}
+
+// claim marks p as land of landmass i if the constraints allow it and pushes
+// its neighbors onto the landmass's frontier.
+func (g *landmassGen) claim(p hex.Position, i int, seed hex.Position, reach float64, f *frontier) bool {
+	if !g.canClaim(p, i) {
+		return false
+	}
+	g.owner[p] = i
+	g.land++
+	for _, n := range p.Neighbors() {
+		if _, taken := g.owner[n]; taken {
+			continue
+		}
+		priority := g.noise.At(float64(n.Q), float64(n.R)) - 0.5*float64(n.Distance(seed))/reach + 0.05*g.rng.Float64()
+		heap.Push(f, frontierItem{pos: n, priority: priority})
+	}
+	return true
+}
+
+func (g *landmassGen) canClaim(p hex.Position, i int) bool {
+	if _, taken := g.owner[p]; taken {
+		return false
+	}
+	if !g.isInterior(p) {
+		return false
+	}
+	for _, n := range p.Range(g.opts.MinSeparation) {
+		if o, ok := g.owner[n]; ok && o != i {
+			return false
+		}
+	}
+	return true
+}
+
+// build materializes the land/water layer, keeping cells outside the shape nil.
+func (g *landmassGen) build(name string) hex.Grid {
+	width, height := g.grid.GetWidth(), g.grid.GetHeight()
+	cells := make([][]hex.Cell, height)
+	for r := 0; r < height; r++ {
+		cells[r] = make([]hex.Cell, width)
+		for q := 0; q < width; q++ {
+			if cell, _ := g.grid.GetCellAt(q, r); cell == nil {
+				continue
+			}
+			value := Water
+			if _, ok := g.owner[hex.NewPosition(q, r)]; ok {
+				value = Land
+			}
+			cells[r][q] = hex.NewCellWithValue(q, r, value)
+		}
+	}
+	return hex.NewGrid(g.grid.GetPosition(), name, width, height, cells)
+}
+
+// countCells returns the number of non-nil cells in a grid.
+func countCells(grid hex.Grid) int {
+	n := 0
+	for i := 0; i < grid.GetCellCount(); i++ {
+		if cell, _ := grid.GetCellAtIndex(i); cell != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// frontierItem is a candidate cell waiting to be claimed by a landmass.
+type frontierItem struct {
+	pos      hex.Position
+	priority float64
+}
+
+// frontier is a max-heap of frontierItems ordered by priority.
+type frontier []frontierItem
+
+func (f frontier) Len() int           { return len(f) }
+func (f frontier) Less(i, j int) bool { return f[i].priority > f[j].priority }
+func (f frontier) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f *frontier) Push(x any)        { *f = append(*f, x.(frontierItem)) }
+func (f *frontier) Pop() any {
+	old := *f
+	item := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// landComponents labels connected land regions of a land/water layer.
+func landComponents(grid hex.Grid) map[hex.Position]int {
+	labels := make(map[hex.Position]int)
+	next := 0
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		if cell == nil || cell.GetValue() != Land {
+			continue
+		}
+		if _, seen := labels[cell.GetPosition()]; seen {
+			continue
+		}
+		stack := []hex.Position{cell.GetPosition()}
+		labels[cell.GetPosition()] = next
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, n := range p.Neighbors() {
+				c, err := grid.GetCellAtPosition(n)
+				if err != nil || c == nil || c.GetValue() != Land {
+					continue
+				}
+				if _, seen := labels[n]; !seen {
+					labels[n] = next
+					stack = append(stack, n)
+				}
+			}
+		}
+		next++
+	}
+	return labels
+}
+
+func landRatio(grid hex.Grid) float64 {
+	land, total := 0, 0
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		if cell == nil {
+			continue
+		}
+		total++
+		if cell.GetValue() == Land {
+			land++
+		}
+	}
+	return float64(land) / float64(total)
+}
+
+func TestLandmasses_LandRatio(t *testing.T) {
+	for _, ratio := range []float64{0.2, 0.4, 0.6} {
+		grid, err := Landmasses(LandmassOptions{Seed: 7, Continents: 2, LandRatio: ratio, OceanBorder: 1})(shapes.NewRectangle(0, 0, 30, 20, "land"))
+		require.NoError(t, err)
+		assert.InDelta(t, ratio, landRatio(grid), 0.02, "land ratio for target %.1f", ratio)
+	}
+}
+
+func TestLandmasses_OceanBorder(t *testing.T) {
+	assert := assert.New(t)
+	grid, err := Landmasses(LandmassOptions{Seed: 3, Continents: 3, LandRatio: 0.5, OceanBorder: 2})(shapes.NewRectangle(0, 0, 24, 24, "land"))
+	require.NoError(t, err)
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		p := cell.GetPosition()
+		if p.Q < 2 || p.R < 2 || p.Q >= 22 || p.R >= 22 {
+			assert.Equal(Water, cell.GetValue(), "border cell %s should be water", p)
+		}
+	}
+}
+
+func TestLandmasses_DefaultOceanBorder(t *testing.T) {
+	assert := assert.New(t)
+	shape := shapes.NewRectangle(0, 0, 12, 12, "land")
+	grid, err := Landmasses(LandmassOptions{Seed: 3, LandRatio: 1})(shape)
+	require.NoError(t, err)
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		p := cell.GetPosition()
+		if p.Q == 0 || p.R == 0 || p.Q == 11 || p.R == 11 {
+			assert.Equal(Water, cell.GetValue(), "border cell %s should be water", p)
+		}
+	}
+
+	grid, err = Landmasses(LandmassOptions{Seed: 3, LandRatio: 1, OceanBorder: NoOceanBorder})(shape)
+	require.NoError(t, err)
+	assert.Equal(1.0, landRatio(grid), "without a border every cell can be land")
+}
+
+func TestLandmasses_Separation(t *testing.T) {
+	assert := assert.New(t)
+	const separation = 3
+	grid, err := Landmasses(LandmassOptions{Seed: 11, Continents: 4, LandRatio: 0.3, MinSeparation: separation, OceanBorder: 1})(shapes.NewRectangle(0, 0, 40, 30, "land"))
+	require.NoError(t, err)
+
+	labels := landComponents(grid)
+	components := map[int]bool{}
+	for _, l := range labels {
+		components[l] = true
+	}
+	assert.Len(components, 4, "expected one component per continent")
+	for a, la := range labels {
+		for b, lb := range labels {
+			if la != lb {
+				assert.Greater(a.Distance(b), separation, "landmasses at %s and %s are too close", a, b)
+			}
+		}
+	}
+}
+
+func TestLandmasses_Archipelago(t *testing.T) {
+	grid, err := Landmasses(LandmassOptions{Seed: 5, Archipelago: true, LandRatio: 0.2, MinSeparation: 1, OceanBorder: 1})(shapes.NewRectangle(0, 0, 40, 40, "islands"))
+	require.NoError(t, err)
+	components := map[int]bool{}
+	for _, l := range landComponents(grid) {
+		components[l] = true
+	}
+	assert.Greater(t, len(components), 10, "archipelago should produce many islands")
+}
+
+func TestLandmasses_Deterministic(t *testing.T) {
+	opts := LandmassOptions{Seed: 42, Continents: 2, LandRatio: 0.4, MinSeparation: 2}
+	a, err := Landmasses(opts)(shapes.NewRectangle(0, 0, 20, 20, "land"))
+	require.NoError(t, err)
+	b, err := Landmasses(opts)(shapes.NewRectangle(0, 0, 20, 20, "land"))
+	require.NoError(t, err)
+	for i := 0; i < a.GetCellCount(); i++ {
+		ca, _ := a.GetCellAtIndex(i)
+		cb, _ := b.GetCellAtIndex(i)
+		assert.Equal(t, ca.GetValue(), cb.GetValue(), "cell %d differs between runs", i)
+	}
+}
+
+func TestLandmasses_RespectsShapeMask(t *testing.T) {
+	assert := assert.New(t)
+	shape := shapes.NewCircle(10, 10, 8, "round")
+	grid, err := Landmasses(LandmassOptions{Seed: 1, LandRatio: 0.5, OceanBorder: 1})(shape)
+	require.NoError(t, err)
+	b := shape.GetBounds()
+	for r := 0; r < grid.GetHeight(); r++ {
+		for q := 0; q < grid.GetWidth(); q++ {
+			cell, err := grid.GetCellAt(q, r)
+			require.NoError(t, err)
+			_, inside := shape.GetColorAt(b.X+q, b.Y+r)
+			assert.Equal(inside == nil, cell != nil, "mask mismatch at (%d, %d)", q, r)
+		}
+	}
+}
+
+func TestLandmasses_AddLayer(t *testing.T) {
+	m := hex.NewMap(16, 12)
+	require.NoError(t, m.AddLayer(Landmasses(LandmassOptions{Name: "terrain", Seed: 9})))
+	grid, err := m.GetGridByName("terrain")
+	require.NoError(t, err)
+	assert.Equal(t, 16, grid.GetWidth())
+	assert.Equal(t, 12, grid.GetHeight())
+}
+
+func TestLandmasses_InvalidOptions(t *testing.T) {
+	_, err := Landmasses(LandmassOptions{LandRatio: 1.5})(shapes.NewSquare(0, 0, 5, "bad"))
+	assert.Error(t, err)
+	_, err = Landmasses(LandmassOptions{MinSeparation: -1})(shapes.NewSquare(0, 0, 5, "bad"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package generator
+
+import "math"
+
+// Noise is a seeded, deterministic 2D value noise source. The same seed always
+// produces the same field, which keeps generated maps reproducible.
+type Noise struct {
+	seed    int64
+	octaves int
+	scale   float64
+}
+
+// NewNoise creates a Noise source. Scale is the feature size in hexes and
+// octaves the number of layered frequencies; both are clamped to at least 1.
+func NewNoise(seed int64, scale float64, octaves int) *Noise {
+	if scale < 1 {
+		scale = 1
+	}
+	if octaves < 1 {
+		octaves = 1
+	}
+	return &Noise{seed: seed, octaves: octaves, scale: scale}
+}
+
+// At returns the noise value at (x, y) in the range [0, 1).
+func (n *Noise) At(x, y float64) float64 {
+	total, amplitude, norm := 0.0, 1.0, 0.0
+	frequency := 1 / n.scale
+	for o := 0; o < n.octaves; o++ {
+		total += amplitude * n.sample(x*frequency, y*frequency, int64(o))
+		norm += amplitude
+		amplitude /= 2
+		frequency *= 2
+	}
+	return total / norm
+}
+
+// sample interpolates the lattice values surrounding (x, y) for one octave.
+func (n *Noise) sample(x, y float64, octave int64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	ix, iy := int64(x0), int64(y0)
+	fx, fy := smoothstep(x-x0), smoothstep(y-y0)
+
+	v00 := n.lattice(ix, iy, octave)
+	v10 := n.lattice(ix+1, iy, octave)
+	v01 := n.lattice(ix, iy+1, octave)
+	v11 := n.lattice(ix+1, iy+1, octave)
+
+	top := v00 + (v10-v00)*fx
+	bottom := v01 + (v11-v01)*fx
+	return top + (bottom-top)*fy
+}
+
+// lattice hashes an integer lattice point into [0, 1).
+func (n *Noise) lattice(x, y, octave int64) float64 {
+	h := uint64(n.seed) ^ uint64(x)*0x9E3779B97F4A7C15 ^ uint64(y)*0xC2B2AE3D27D4EB4F ^ uint64(octave)*0x165667B19E3779F9
+	h ^= h >> 33
+	h *= 0xFF51AFD7ED558CCD
+	h ^= h >> 33
+	h *= 0xC4CEB9FE1A85EC53
+	h ^= h >> 33
+	return float64(h>>11) / float64(1<<53)
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
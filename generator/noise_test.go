@@ -0,0 +1,34 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoise_RangeAndDeterminism(t *testing.T) {
+	assert := assert.New(t)
+	a := NewNoise(1, 4, 3)
+	b := NewNoise(1, 4, 3)
+	c := NewNoise(2, 4, 3)
+
+	differs := false
+	for y := 0.0; y < 20; y++ {
+		for x := 0.0; x < 20; x++ {
+			v := a.At(x, y)
+			assert.GreaterOrEqual(v, 0.0)
+			assert.Less(v, 1.0)
+			assert.Equal(v, b.At(x, y), "same seed should give same value")
+			if v != c.At(x, y) {
+				differs = true
+			}
+		}
+	}
+	assert.True(differs, "different seeds should give different fields")
+}
+
+func TestNoise_ClampsParameters(t *testing.T) {
+	n := NewNoise(0, 0, 0)
+	assert.Equal(t, 1.0, n.scale)
+	assert.Equal(t, 1, n.octaves)
+}
@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// PassFunc runs one map generation step. The rng is seeded for this pass alone.
+type PassFunc func(m hex.Map, rng *rand.Rand) error
+
+// LayerPass adapts a seeded layer generator, such as Landmasses, into a
+// PassFunc that adds the generated layer to the map.
+func LayerPass(build func(seed int64) hex.GenerateGridFunc) PassFunc {
+	return func(m hex.Map, rng *rand.Rand) error {
+		return m.AddLayer(build(rng.Int63()))
+	}
+}
+
+// pass is a named step of a Pipeline.
+type pass struct {
+	name     string
+	run      PassFunc
+	disabled bool
+}
+
+// Pipeline runs named generation passes against a hex.Map in order.
+// Each pass gets its own rng derived from the run seed and the pass name, so
+// disabling or replacing one pass does not change what the others generate.
+type Pipeline struct {
+	passes []*pass
+}
+
+// PassError reports which pass of a Pipeline failed.
+type PassError struct {
+	Pass string
+	Err  error
+}
+
+func (e *PassError) Error() string {
+	return fmt.Sprintf("pass %s: %v", e.Pass, e.Err)
+}
+
+func (e *PassError) Unwrap() error {
+	return e.Err
+}
+
+// PassResult records the outcome of a single pass.
+type PassResult struct {
+	Name     string
+	Seed     int64
+	Duration time.Duration
+	Skipped  bool
+}
+
+// PipelineReport records a Pipeline run.
+type PipelineReport struct {
+	Seed     int64
+	Passes   []PassResult
+	Duration time.Duration
+}
+
+// NewPipeline creates an empty Pipeline.
+func NewPipeline() *Pipeline {
+	return &Pipeline{}
+}
+
+// Add appends a pass. Pass names must be unique.
+func (p *Pipeline) Add(name string, run PassFunc) error {
+	if run == nil {
+		return fmt.Errorf("pass %s cannot be nil", name)
+	}
+	if _, err := p.find(name); err == nil {
+		return fmt.Errorf("pass %s already exists", name)
+	}
+	p.passes = append(p.passes, &pass{name: name, run: run})
+	return nil
+}
+
+// Replace swaps the function of an existing pass, keeping its position.
+func (p *Pipeline) Replace(name string, run PassFunc) error {
+	if run == nil {
+		return fmt.Errorf("pass %s cannot be nil", name)
+	}
+	ps, err := p.find(name)
+	if err != nil {
+		return err
+	}
+	ps.run = run
+	return nil
+}
+
+// Disable skips a pass in later runs.
+func (p *Pipeline) Disable(name string) error {
+	ps, err := p.find(name)
+	if err != nil {
+		return err
+	}
+	ps.disabled = true
+	return nil
+}
+
+// Enable re-enables a disabled pass.
+func (p *Pipeline) Enable(name string) error {
+	ps, err := p.find(name)
+	if err != nil {
+		return err
+	}
+	ps.disabled = false
+	return nil
+}
+
+// Passes returns the pass names in run order.
+func (p *Pipeline) Passes() []string {
+	names := make([]string, len(p.passes))
+	for i, ps := range p.passes {
+		names[i] = ps.name
+	}
+	return names
+}
+
+// Run executes every enabled pass against m with the given seed. It stops at
+// the first failing pass and returns a *PassError wrapping its error, along
+// with a report of the passes run so far.
+func (p *Pipeline) Run(m hex.Map, seed int64) (*PipelineReport, error) {
+	if m == nil {
+		return nil, fmt.Errorf("map cannot be nil")
+	}
+	report := &PipelineReport{Seed: seed}
+	start := time.Now()
+	defer func() { report.Duration = time.Since(start) }()
+
+	for _, ps := range p.passes {
+		result := PassResult{Name: ps.name, Seed: passSeed(seed, ps.name), Skipped: ps.disabled}
+		if !ps.disabled {
+			passStart := time.Now()
+			err := ps.run(m, rand.New(rand.NewSource(result.Seed)))
+			result.Duration = time.Since(passStart)
+			if err != nil {
+				report.Passes = append(report.Passes, result)
+				return report, &PassError{Pass: ps.name, Err: err}
+			}
+		}
+		report.Passes = append(report.Passes, result)
+	}
+	return report, nil
+}
+
+func (p *Pipeline) find(name string) (*pass, error) {
+	for _, ps := range p.passes {
+		if ps.name == name {
+			return ps, nil
+		}
+	}
+	return nil, fmt.Errorf("pass %s not found", name)
+}
+
+// passSeed derives a pass's seed from the run seed and the pass name.
+func passSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64())
+}
+
+// FailedPass returns the name of the pass that caused err, if any.
+func FailedPass(err error) (string, bool) {
+	var pe *PassError
+	if errors.As(err, &pe) {
+		return pe.Pass, true
+	}
+	return "", false
+}
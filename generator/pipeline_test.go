@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordPass returns a pass that appends its name and first random number to log.
+func recordPass(name string, log *[]string) PassFunc {
+	return func(m hex.Map, rng *rand.Rand) error {
+		*log = append(*log, fmt.Sprintf("%s:%d", name, rng.Int63()))
+		return nil
+	}
+}
+
+func TestPipeline_RunsInOrder(t *testing.T) {
+	assert := assert.New(t)
+	var log []string
+	p := NewPipeline()
+	require.NoError(t, p.Add("landmass", recordPass("landmass", &log)))
+	require.NoError(t, p.Add("biomes", recordPass("biomes", &log)))
+	require.NoError(t, p.Add("rivers", recordPass("rivers", &log)))
+	assert.Equal([]string{"landmass", "biomes", "rivers"}, p.Passes())
+
+	report, err := p.Run(hex.NewMap(4, 4), 1)
+	require.NoError(t, err)
+	require.Len(t, report.Passes, 3)
+	assert.Len(log, 3)
+	for i, name := range p.Passes() {
+		assert.Equal(name, report.Passes[i].Name)
+		assert.Contains(log[i], name+":")
+		assert.False(report.Passes[i].Skipped)
+	}
+	assert.Equal(int64(1), report.Seed)
+	assert.GreaterOrEqual(report.Duration, report.Passes[0].Duration)
+}
+
+func TestPipeline_SeedsAreIndependent(t *testing.T) {
+	assert := assert.New(t)
+	var full, partial []string
+
+	p := NewPipeline()
+	require.NoError(t, p.Add("a", recordPass("a", &full)))
+	require.NoError(t, p.Add("b", recordPass("b", &full)))
+	_, err := p.Run(hex.NewMap(1, 1), 5)
+	require.NoError(t, err)
+
+	q := NewPipeline()
+	require.NoError(t, q.Add("a", recordPass("a", &partial)))
+	require.NoError(t, q.Add("b", recordPass("b", &partial)))
+	require.NoError(t, q.Disable("a"))
+	report, err := q.Run(hex.NewMap(1, 1), 5)
+	require.NoError(t, err)
+
+	assert.True(report.Passes[0].Skipped)
+	assert.Equal([]string{full[1]}, partial, "disabling a should not change b's randomness")
+
+	var other []string
+	r := NewPipeline()
+	require.NoError(t, r.Add("b", recordPass("b", &other)))
+	_, err = r.Run(hex.NewMap(1, 1), 6)
+	require.NoError(t, err)
+	assert.NotEqual(full[1], other[0], "a different seed should change the pass rng")
+}
+
+func TestPipeline_ReplaceAndEnable(t *testing.T) {
+	assert := assert.New(t)
+	var log []string
+	p := NewPipeline()
+	require.NoError(t, p.Add("a", recordPass("a", &log)))
+	require.NoError(t, p.Replace("a", recordPass("replaced", &log)))
+	require.NoError(t, p.Disable("a"))
+	require.NoError(t, p.Enable("a"))
+	_, err := p.Run(hex.NewMap(1, 1), 0)
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	assert.Contains(log[0], "replaced:")
+
+	assert.Error(p.Add("a", recordPass("a", &log)), "duplicate name")
+	assert.Error(p.Add("b", nil), "nil pass")
+	assert.Error(p.Replace("missing", recordPass("x", &log)))
+	assert.Error(p.Replace("a", nil))
+	assert.Error(p.Disable("missing"))
+	assert.Error(p.Enable("missing"))
+}
+
+func TestPipeline_ReportsFailedPass(t *testing.T) {
+	assert := assert.New(t)
+	cause := errors.New("no room for rivers")
+	var log []string
+	p := NewPipeline()
+	require.NoError(t, p.Add("landmass", recordPass("landmass", &log)))
+	require.NoError(t, p.Add("rivers", func(hex.Map, *rand.Rand) error { return cause }))
+	require.NoError(t, p.Add("resources", recordPass("resources", &log)))
+
+	report, err := p.Run(hex.NewMap(1, 1), 0)
+	require.Error(t, err)
+	assert.ErrorIs(err, cause)
+	name, ok := FailedPass(err)
+	assert.True(ok)
+	assert.Equal("rivers", name)
+	assert.Contains(err.Error(), "pass rivers")
+	assert.Len(report.Passes, 2, "report should stop at the failing pass")
+	assert.Len(log, 1, "later passes should not run")
+
+	_, ok = FailedPass(cause)
+	assert.False(ok)
+	_, err = p.Run(nil, 0)
+	assert.Error(err)
+}
+
+func TestLayerPass(t *testing.T) {
+	p := NewPipeline()
+	require.NoError(t, p.Add("landmass", LayerPass(func(seed int64) hex.GenerateGridFunc {
+		return Landmasses(LandmassOptions{Name: "terrain", Seed: seed})
+	})))
+	m := hex.NewMap(10, 10)
+	_, err := p.Run(m, 3)
+	require.NoError(t, err)
+	_, err = m.GetGridByName("terrain")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,234 @@
+package generator
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// NoRegion is the region id of cells that no seed can reach.
+const NoRegion = -1
+
+// CostFunc returns the cost of entering a cell. A negative cost marks the
+// cell as impassable.
+type CostFunc func(cell hex.Cell) int
+
+// RegionOptions configures PartitionRegions.
+type RegionOptions struct {
+	// Name is the name of the region-id layer. Defaults to "<grid>_regions".
+	Name string
+	// Cost weights distances by movement cost. Nil means every step costs 1.
+	Cost CostFunc
+	// Relaxation is the number of Lloyd relaxation iterations. Each iteration
+	// moves every seed to the centroid of its region and partitions again,
+	// which evens out region sizes.
+	Relaxation int
+}
+
+// Regions is the result of partitioning a grid.
+type Regions struct {
+	// Grid is the region-id layer. Each cell holds the index of the seed it
+	// belongs to, or NoRegion. Cells that are nil in the source stay nil.
+	Grid hex.Grid
+	// Seeds are the final seed positions, after any relaxation.
+	Seeds []hex.Position
+	// Adjacency lists, for each region, the sorted ids of bordering regions.
+	Adjacency [][]int
+}
+
+// Sizes returns the number of cells in each region.
+func (r *Regions) Sizes() []int {
+	sizes := make([]int, len(r.Seeds))
+	for i := 0; i < r.Grid.GetCellCount(); i++ {
+		if cell, _ := r.Grid.GetCellAtIndex(i); cell != nil && cell.GetValue() != NoRegion {
+			sizes[cell.GetValue()]++
+		}
+	}
+	return sizes
+}
+
+// PartitionRegions assigns every cell of grid to its nearest seed by hex
+// distance, optionally weighted by movement cost. Seeds are positions local
+// to grid. Ties go to the seed with the lower index.
+func PartitionRegions(grid hex.Grid, seeds []hex.Position, opts RegionOptions) (*Regions, error) {
+	if grid == nil {
+		return nil, fmt.Errorf("grid cannot be nil")
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("at least one seed is required")
+	}
+	for _, s := range seeds {
+		if cell, err := grid.GetCellAtPosition(s); err != nil || cell == nil {
+			return nil, fmt.Errorf("seed %s is not a cell of grid %s", s, grid.GetName())
+		}
+	}
+	if opts.Relaxation < 0 {
+		return nil, fmt.Errorf("relaxation cannot be negative")
+	}
+	name := opts.Name
+	if name == "" {
+		name = grid.GetName() + "_regions"
+	}
+
+	seeds = slices.Clone(seeds)
+	labels := assignRegions(grid, seeds, opts.Cost)
+	for i := 0; i < opts.Relaxation; i++ {
+		moved := false
+		for id, c := range regionCentroids(grid, labels, len(seeds)) {
+			if c != seeds[id] {
+				seeds[id], moved = c, true
+			}
+		}
+		if !moved {
+			break
+		}
+		labels = assignRegions(grid, seeds, opts.Cost)
+	}
+
+	return &Regions{
+		Grid:      buildRegionGrid(grid, labels, name),
+		Seeds:     seeds,
+		Adjacency: regionAdjacency(grid, labels, len(seeds)),
+	}, nil
+}
+
+// assignRegions runs a multi-source Dijkstra from every seed.
+func assignRegions(grid hex.Grid, seeds []hex.Position, cost CostFunc) map[hex.Position]int {
+	labels := make(map[hex.Position]int)
+	dist := make(map[hex.Position]int)
+	queue := &regionQueue{}
+	for id, s := range seeds {
+		if _, ok := dist[s]; ok {
+			continue // duplicate seed; the lower index keeps it
+		}
+		dist[s], labels[s] = 0, id
+		heap.Push(queue, regionItem{pos: s, region: id})
+	}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(regionItem)
+		if item.dist > dist[item.pos] || labels[item.pos] != item.region {
+			continue
+		}
+		for _, n := range item.pos.Neighbors() {
+			cell, err := grid.GetCellAtPosition(n)
+			if err != nil || cell == nil {
+				continue
+			}
+			step := 1
+			if cost != nil {
+				if step = cost(cell); step < 0 {
+					continue
+				}
+			}
+			d := item.dist + step
+			old, seen := dist[n]
+			if !seen || d < old || (d == old && item.region < labels[n]) {
+				dist[n], labels[n] = d, item.region
+				heap.Push(queue, regionItem{pos: n, region: item.region, dist: d})
+			}
+		}
+	}
+	return labels
+}
+
+// regionCentroids returns, for each region, the member cell closest to the
+// region's mean cube coordinate. Empty regions keep no entry.
+func regionCentroids(grid hex.Grid, labels map[hex.Position]int, count int) map[int]hex.Position {
+	sumQ, sumR, n := make([]float64, count), make([]float64, count), make([]int, count)
+	for p, id := range labels {
+		sumQ[id] += float64(p.Q)
+		sumR[id] += float64(p.R)
+		n[id]++
+	}
+	best := make(map[int]hex.Position)
+	bestDist := make([]float64, count)
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		if cell == nil {
+			continue
+		}
+		p := cell.GetPosition()
+		id, ok := labels[p]
+		if !ok {
+			continue
+		}
+		cq, cr := sumQ[id]/float64(n[id]), sumR[id]/float64(n[id])
+		dq, dr := float64(p.Q)-cq, float64(p.R)-cr
+		d := (math.Abs(dq) + math.Abs(dr) + math.Abs(dq+dr)) / 2
+		if _, ok := best[id]; !ok || d < bestDist[id] {
+			best[id], bestDist[id] = p, d
+		}
+	}
+	return best
+}
+
+func regionAdjacency(grid hex.Grid, labels map[hex.Position]int, count int) [][]int {
+	sets := make([]map[int]bool, count)
+	for i := range sets {
+		sets[i] = make(map[int]bool)
+	}
+	for p, id := range labels {
+		for _, n := range p.Neighbors() {
+			if other, ok := labels[n]; ok && other != id {
+				sets[id][other] = true
+			}
+		}
+	}
+	adjacency := make([][]int, count)
+	for id, set := range sets {
+		adjacency[id] = []int{}
+		for other := range set {
+			adjacency[id] = append(adjacency[id], other)
+		}
+		slices.Sort(adjacency[id])
+	}
+	return adjacency
+}
+
+func buildRegionGrid(grid hex.Grid, labels map[hex.Position]int, name string) hex.Grid {
+	width, height := grid.GetWidth(), grid.GetHeight()
+	cells := make([][]hex.Cell, height)
+	for r := 0; r < height; r++ {
+		cells[r] = make([]hex.Cell, width)
+		for q := 0; q < width; q++ {
+			if cell, _ := grid.GetCellAt(q, r); cell == nil {
+				continue
+			}
+			id, ok := labels[hex.NewPosition(q, r)]
+			if !ok {
+				id = NoRegion
+			}
+			cells[r][q] = hex.NewCellWithValue(q, r, id)
+		}
+	}
+	return hex.NewGrid(grid.GetPosition(), name, width, height, cells)
+}
+
+// regionItem is a cell reached from a seed during partitioning.
+type regionItem struct {
+	pos    hex.Position
+	region int
+	dist   int
+}
+
+// regionQueue is a min-heap of regionItems ordered by distance, then region.
+type regionQueue []regionItem
+
+func (q regionQueue) Len() int { return len(q) }
+func (q regionQueue) Less(i, j int) bool {
+	if q[i].dist != q[j].dist {
+		return q[i].dist < q[j].dist
+	}
+	return q[i].region < q[j].region
+}
+func (q regionQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *regionQueue) Push(x any)   { *q = append(*q, x.(regionItem)) }
+func (q *regionQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
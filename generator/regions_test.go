@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionRegions_NearestSeed(t *testing.T) {
+	assert := assert.New(t)
+	grid, err := GridFromShape(shapes.NewRectangle(0, 0, 12, 8, "map"))
+	require.NoError(t, err)
+	seeds := []hex.Position{{Q: 1, R: 1}, {Q: 10, R: 6}, {Q: 6, R: 2}}
+
+	regions, err := PartitionRegions(grid, seeds, RegionOptions{})
+	require.NoError(t, err)
+	assert.Equal("map_regions", regions.Grid.GetName())
+
+	total := 0
+	for _, size := range regions.Sizes() {
+		total += size
+	}
+	assert.Equal(grid.GetCellCount(), total, "every cell should belong to a region")
+
+	for i := 0; i < regions.Grid.GetCellCount(); i++ {
+		cell, _ := regions.Grid.GetCellAtIndex(i)
+		p := cell.GetPosition()
+		own := p.Distance(seeds[cell.GetValue()])
+		for id, s := range seeds {
+			assert.LessOrEqual(own, p.Distance(s), "cell %s is closer to seed %d", p, id)
+		}
+	}
+}
+
+func TestPartitionRegions_Adjacency(t *testing.T) {
+	grid, err := GridFromShape(shapes.NewRectangle(0, 0, 9, 3, "strip"))
+	require.NoError(t, err)
+	regions, err := PartitionRegions(grid, []hex.Position{{Q: 0, R: 1}, {Q: 4, R: 1}, {Q: 8, R: 1}}, RegionOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, [][]int{{1}, {0, 2}, {1}}, regions.Adjacency)
+}
+
+func TestPartitionRegions_Cost(t *testing.T) {
+	assert := assert.New(t)
+	grid, err := GridFromShape(shapes.NewRectangle(0, 0, 10, 1, "row"))
+	require.NoError(t, err)
+	// A wall at q=5 makes the right-hand side unreachable from the left seed.
+	wall, _ := grid.GetCellAt(5, 0)
+	wall.SetValue(1)
+	cost := func(cell hex.Cell) int {
+		if cell.GetValue() == 1 {
+			return -1
+		}
+		return 1
+	}
+
+	regions, err := PartitionRegions(grid, []hex.Position{{Q: 0, R: 0}}, RegionOptions{Cost: cost})
+	require.NoError(t, err)
+	for q := 0; q < 10; q++ {
+		cell, _ := regions.Grid.GetCellAt(q, 0)
+		if q < 5 {
+			assert.Equal(0, cell.GetValue(), "q=%d should be reachable", q)
+		} else {
+			assert.Equal(NoRegion, cell.GetValue(), "q=%d should be unreachable", q)
+		}
+	}
+}
+
+func TestPartitionRegions_Relaxation(t *testing.T) {
+	grid, err := GridFromShape(shapes.NewRectangle(0, 0, 20, 20, "map"))
+	require.NoError(t, err)
+	// Clustered seeds produce very uneven regions until relaxed.
+	seeds := []hex.Position{{Q: 0, R: 0}, {Q: 1, R: 0}, {Q: 0, R: 1}, {Q: 1, R: 1}}
+
+	spread := func(sizes []int) int {
+		lo, hi := sizes[0], sizes[0]
+		for _, s := range sizes {
+			lo, hi = min(lo, s), max(hi, s)
+		}
+		return hi - lo
+	}
+
+	raw, err := PartitionRegions(grid, seeds, RegionOptions{})
+	require.NoError(t, err)
+	relaxed, err := PartitionRegions(grid, seeds, RegionOptions{Relaxation: 10})
+	require.NoError(t, err)
+	assert.Less(t, spread(relaxed.Sizes()), spread(raw.Sizes()))
+	assert.Equal(t, []hex.Position{{Q: 0, R: 0}, {Q: 1, R: 0}, {Q: 0, R: 1}, {Q: 1, R: 1}}, seeds, "input seeds should not be modified")
+}
+
+func TestPartitionRegions_Errors(t *testing.T) {
+	grid, err := GridFromShape(shapes.NewSquare(0, 0, 4, "g"))
+	require.NoError(t, err)
+	_, err = PartitionRegions(nil, []hex.Position{{}}, RegionOptions{})
+	assert.Error(t, err)
+	_, err = PartitionRegions(grid, nil, RegionOptions{})
+	assert.Error(t, err)
+	_, err = PartitionRegions(grid, []hex.Position{{Q: 9, R: 9}}, RegionOptions{})
+	assert.Error(t, err)
+	_, err = PartitionRegions(grid, []hex.Position{{}}, RegionOptions{Relaxation: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+)
+
+// SymmetryOptions configures Symmetric.
+type SymmetryOptions struct {
+	// Fold is the order of rotational symmetry around the center: 1, 2, 3 or 6.
+	// Defaults to 1, meaning no rotation.
+	Fold int
+	// Mirror adds a reflection across the vertical axis through the center.
+	Mirror bool
+	// Center is the hex the symmetry is built around, local to the grid.
+	// Defaults to the middle of the grid.
+	Center *hex.Position
+	// Fill is the value of cells whose symmetric images fall outside the grid,
+	// such as the corners of a rectangular map under 3- or 6-fold symmetry.
+	Fill int
+}
+
+// Symmetric wraps a hex.GenerateGridFunc so its output is symmetric for
+// competitive play. The wrapped generator runs once; its output inside one
+// wedge of the symmetry is then replicated to every other wedge using hex
+// rotations and reflections, so each player's region is identical.
+// Cells that are nil in the generated grid stay nil.
+func Symmetric(f hex.GenerateGridFunc, opts SymmetryOptions) hex.GenerateGridFunc {
+	return func(shape shapes.Shape) (hex.Grid, error) {
+		if f == nil {
+			return nil, fmt.Errorf("generate function cannot be nil")
+		}
+		fold := opts.Fold
+		if fold == 0 {
+			fold = 1
+		}
+		if !slices.Contains([]int{1, 2, 3, 6}, fold) {
+			return nil, fmt.Errorf("fold %d must be 1, 2, 3 or 6", fold)
+		}
+		source, err := f(shape)
+		if err != nil {
+			return nil, err
+		}
+		center := hex.NewPosition((source.GetWidth()-1)/2, (source.GetHeight()-1)/2)
+		if opts.Center != nil {
+			center = *opts.Center
+		}
+
+		group := symmetryGroup(fold, opts.Mirror)
+		// The fundamental wedge starts on the mirror axis and spans 1/|group| of a turn.
+		wedge := 2 * math.Pi / float64(len(group))
+
+		width, height := source.GetWidth(), source.GetHeight()
+		cells := make([][]hex.Cell, height)
+		for r := 0; r < height; r++ {
+			cells[r] = make([]hex.Cell, width)
+			for q := 0; q < width; q++ {
+				cell, _ := source.GetCellAt(q, r)
+				if cell == nil {
+					continue
+				}
+				value := opts.Fill
+				if rep, ok := wedgeRepresentative(source, center, cell.GetPosition(), group, wedge); ok {
+					value = rep.GetValue()
+				}
+				cells[r][q] = hex.NewCellWithValue(q, r, value)
+			}
+		}
+		return hex.NewGrid(source.GetPosition(), source.GetName(), width, height, cells), nil
+	}
+}
+
+// symmetryTransform maps a position relative to the center onto its image.
+type symmetryTransform func(hex.Position) hex.Position
+
+// rotate60 rotates a relative position one sixth of a turn counter-clockwise.
+func rotate60(p hex.Position) hex.Position {
+	return hex.NewPosition(-p.R, -p.S())
+}
+
+// mirrorVertical reflects a relative position across the vertical axis.
+func mirrorVertical(p hex.Position) hex.Position {
+	return hex.NewPosition(p.S(), p.R)
+}
+
+// symmetryGroup lists every transform of the requested symmetry, identity first.
+func symmetryGroup(fold int, mirror bool) []symmetryTransform {
+	steps := 6 / fold
+	var group []symmetryTransform
+	for k := 0; k < fold; k++ {
+		turns := k * steps
+		rot := func(p hex.Position) hex.Position {
+			for i := 0; i < turns; i++ {
+				p = rotate60(p)
+			}
+			return p
+		}
+		group = append(group, rot)
+		if mirror {
+			group = append(group, func(p hex.Position) hex.Position { return rot(mirrorVertical(p)) })
+		}
+	}
+	return group
+}
+
+// wedgeRepresentative returns the cell in the fundamental wedge whose value p
+// should copy. It reports false when any image of p lies outside the grid.
+func wedgeRepresentative(grid hex.Grid, center, p hex.Position, group []symmetryTransform, wedge float64) (hex.Cell, bool) {
+	rel := p.Sub(center)
+	orbit := make([]hex.Position, 0, len(group))
+	for _, g := range group {
+		img := center.Add(g(rel))
+		if cell, err := grid.GetCellAtPosition(img); err != nil || cell == nil {
+			return nil, false
+		}
+		orbit = append(orbit, img)
+	}
+	// Sorting makes the choice depend only on the orbit, not on p, so every
+	// member of the orbit picks the same representative.
+	slices.SortFunc(orbit, func(a, b hex.Position) int {
+		if a.Q != b.Q {
+			return a.Q - b.Q
+		}
+		return a.R - b.R
+	})
+	rep := orbit[0]
+	for _, img := range orbit {
+		if inWedge(img.Sub(center), wedge) {
+			rep = img
+			break
+		}
+	}
+	cell, _ := grid.GetCellAtPosition(rep)
+	return cell, true
+}
+
+// inWedge reports whether a relative position lies in the closed wedge
+// starting at the vertical axis and spanning the given angle.
+func inWedge(rel hex.Position, wedge float64) bool {
+	if rel == (hex.Position{}) {
+		return true
+	}
+	x := math.Sqrt(3) * (float64(rel.Q) + float64(rel.R)/2)
+	y := -1.5 * float64(rel.R)
+	angle := math.Atan2(y, x) - math.Pi/2
+	for angle < 0 {
+		angle += 2 * math.Pi
+	}
+	const eps = 1e-9
+	return angle <= wedge+eps || angle >= 2*math.Pi-eps
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hexagonNoise generates a hexagon of the given radius filled with distinct
+// values, so any symmetry in the output comes from the wrapper.
+func hexagonNoise(radius int) hex.GenerateGridFunc {
+	return func(shape shapes.Shape) (hex.Grid, error) {
+		size := 2*radius + 1
+		center := hex.NewPosition(radius, radius)
+		cells := make([][]hex.Cell, size)
+		for r := range cells {
+			cells[r] = make([]hex.Cell, size)
+			for q := range cells[r] {
+				if center.Distance(hex.NewPosition(q, r)) <= radius {
+					cells[r][q] = hex.NewCellWithValue(q, r, r*size+q)
+				}
+			}
+		}
+		return hex.NewGrid(hex.Position{}, shape.GetName(), size, size, cells), nil
+	}
+}
+
+func valueAt(t *testing.T, grid hex.Grid, p hex.Position) int {
+	cell, err := grid.GetCellAtPosition(p)
+	require.NoError(t, err)
+	require.NotNil(t, cell, "no cell at %s", p)
+	return cell.GetValue()
+}
+
+func TestSymmetric_Rotational(t *testing.T) {
+	const radius = 4
+	center := hex.NewPosition(radius, radius)
+	for _, fold := range []int{2, 3, 6} {
+		grid, err := Symmetric(hexagonNoise(radius), SymmetryOptions{Fold: fold})(shapes.NewSquare(0, 0, 1, "sym"))
+		require.NoError(t, err)
+
+		distinct := map[int]bool{}
+		for _, p := range center.Range(radius) {
+			distinct[valueAt(t, grid, p)] = true
+			img := p.Sub(center)
+			for i := 0; i < 6/fold; i++ {
+				img = rotate60(img)
+			}
+			assert.Equal(t, valueAt(t, grid, p), valueAt(t, grid, center.Add(img)), "fold %d: %s and its rotation differ", fold, p)
+		}
+		cells := len(center.Range(radius))
+		assert.GreaterOrEqual(t, len(distinct), (cells-1)/fold, "fold %d should keep one wedge of distinct values", fold)
+	}
+}
+
+func TestSymmetric_Mirror(t *testing.T) {
+	const radius = 3
+	center := hex.NewPosition(radius, radius)
+	grid, err := Symmetric(hexagonNoise(radius), SymmetryOptions{Mirror: true, Fold: 3})(shapes.NewSquare(0, 0, 1, "sym"))
+	require.NoError(t, err)
+	for _, p := range center.Range(radius) {
+		mirrored := center.Add(mirrorVertical(p.Sub(center)))
+		rotated := center.Add(rotate60(rotate60(p.Sub(center))))
+		assert.Equal(t, valueAt(t, grid, p), valueAt(t, grid, mirrored), "%s should match its mirror", p)
+		assert.Equal(t, valueAt(t, grid, p), valueAt(t, grid, rotated), "%s should match its rotation", p)
+	}
+}
+
+func TestSymmetric_FillOutsideOrbit(t *testing.T) {
+	assert := assert.New(t)
+	// A rectangle in axial coordinates is a rhombus: 2-fold symmetric, but
+	// its corners have no 6-fold images.
+	land := Landmasses(LandmassOptions{Seed: 2, LandRatio: 0.5})
+	grid, err := Symmetric(land, SymmetryOptions{Fold: 6, Fill: 7})(shapes.NewRectangle(0, 0, 9, 9, "rhombus"))
+	require.NoError(t, err)
+	assert.Equal(7, valueAt(t, grid, hex.NewPosition(0, 0)), "corner should be filled")
+	assert.NotEqual(7, valueAt(t, grid, hex.NewPosition(4, 4)), "center has a full orbit")
+
+	grid, err = Symmetric(land, SymmetryOptions{Fold: 2, Fill: 7})(shapes.NewRectangle(0, 0, 9, 9, "rhombus"))
+	require.NoError(t, err)
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		p := cell.GetPosition()
+		assert.NotEqual(7, cell.GetValue(), "%s has a 2-fold image", p)
+		assert.Equal(cell.GetValue(), valueAt(t, grid, hex.NewPosition(8-p.Q, 8-p.R)))
+	}
+}
+
+func TestSymmetric_Errors(t *testing.T) {
+	_, err := Symmetric(nil, SymmetryOptions{Fold: 2})(shapes.NewSquare(0, 0, 3, "x"))
+	assert.Error(t, err)
+	_, err = Symmetric(GridFromShape, SymmetryOptions{Fold: 4})(shapes.NewSquare(0, 0, 3, "x"))
+	assert.Error(t, err)
+}
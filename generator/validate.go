@@ -0,0 +1,343 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// Violation is a single failed playability check.
+type Violation struct {
+	// Rule is the name of the rule that failed.
+	Rule string
+	// Message describes the problem.
+	Message string
+	// Positions are the map positions involved, such as an island or a start.
+	Positions []hex.Position
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Rule is a playability check over a map.
+type Rule interface {
+	// Name returns the rule's identifier, used in violations.
+	Name() string
+	// Check returns the rule's violations on m. It returns an error when the
+	// map cannot be checked at all, for example because a layer is missing.
+	Check(m hex.Map) ([]Violation, error)
+}
+
+// ValidationError carries the violations found by a validation pass.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Sprintf("%d violation(s): %s", len(e.Violations), strings.Join(msgs, "; "))
+}
+
+// Validate runs every rule against m and collects their violations.
+func Validate(m hex.Map, rules ...Rule) ([]Violation, error) {
+	if m == nil {
+		return nil, fmt.Errorf("map cannot be nil")
+	}
+	var violations []Violation
+	for _, rule := range rules {
+		found, err := rule.Check(m)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name(), err)
+		}
+		violations = append(violations, found...)
+	}
+	return violations, nil
+}
+
+// ValidationPass returns a PassFunc that fails with a *ValidationError when
+// any rule is violated, so it can end a Pipeline.
+func ValidationPass(rules ...Rule) PassFunc {
+	return func(m hex.Map, _ *rand.Rand) error {
+		violations, err := Validate(m, rules...)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			return &ValidationError{Violations: violations}
+		}
+		return nil
+	}
+}
+
+// RunUntilValid runs the pipeline on fresh maps from newMap, drawing a new
+// seed after every attempt that fails validation. Other pass errors are
+// returned immediately. The seed of the successful run is in its report.
+func RunUntilValid(p *Pipeline, newMap func() hex.Map, seed int64, attempts int) (hex.Map, *PipelineReport, error) {
+	if attempts < 1 {
+		return nil, nil, fmt.Errorf("attempts must be at least 1, got %d", attempts)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		m := newMap()
+		report, err := p.Run(m, seed)
+		if err == nil {
+			return m, report, nil
+		}
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			return nil, report, err
+		}
+		lastErr = err
+		seed = rng.Int63()
+	}
+	return nil, nil, fmt.Errorf("no valid map after %d attempts: %w", attempts, lastErr)
+}
+
+// Terrain tells rules how to read a terrain layer.
+type Terrain struct {
+	// Layer is the name of the terrain layer.
+	Layer string
+	// Land lists the values that count as land. Defaults to Land.
+	Land []int
+	// Impassable lists values that neither land nor sea movement can cross.
+	// Cells that are neither land nor impassable are water.
+	Impassable []int
+}
+
+func (t Terrain) isLand(v int) bool {
+	if len(t.Land) == 0 {
+		return v == Land
+	}
+	return slices.Contains(t.Land, v)
+}
+
+func (t Terrain) isWater(v int) bool {
+	return !t.isLand(v) && !slices.Contains(t.Impassable, v)
+}
+
+// components labels the connected cells of grid that match, returning each
+// cell's component and the cells of each component in local coordinates.
+func components(grid hex.Grid, match func(v int) bool) (map[hex.Position]int, [][]hex.Position) {
+	labels := make(map[hex.Position]int)
+	var groups [][]hex.Position
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		if cell == nil || !match(cell.GetValue()) {
+			continue
+		}
+		if _, seen := labels[cell.GetPosition()]; seen {
+			continue
+		}
+		id := len(groups)
+		group := []hex.Position{cell.GetPosition()}
+		labels[cell.GetPosition()] = id
+		for next := 0; next < len(group); next++ {
+			for _, n := range grid.Neighbors(group[next]) {
+				c, _ := grid.GetCellAtPosition(n)
+				if _, seen := labels[n]; seen || c == nil || !match(c.GetValue()) {
+					continue
+				}
+				labels[n] = id
+				group = append(group, n)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return labels, groups
+}
+
+// toMap converts local grid positions to map positions.
+func toMap(grid hex.Grid, positions ...hex.Position) []hex.Position {
+	out := make([]hex.Position, len(positions))
+	for i, p := range positions {
+		out[i] = p.Add(grid.GetPosition())
+	}
+	return out
+}
+
+// toLocal converts a map position to a normalized local grid position.
+func toLocal(grid hex.Grid, p hex.Position) (hex.Position, bool) {
+	local, ok := grid.Normalize(p.Sub(grid.GetPosition()))
+	if !ok {
+		return local, false
+	}
+	cell, _ := grid.GetCellAtPosition(local)
+	return local, cell != nil
+}
+
+// landConnected implements LandConnected.
+type landConnected struct {
+	terrain Terrain
+	islands []hex.Position
+}
+
+// LandConnected requires all land to form one landmass, except for islands
+// explicitly allowed by giving any map position on them.
+func LandConnected(terrain Terrain, islands ...hex.Position) Rule {
+	return &landConnected{terrain: terrain, islands: islands}
+}
+
+func (r *landConnected) Name() string { return "land-connected" }
+
+func (r *landConnected) Check(m hex.Map) ([]Violation, error) {
+	grid, err := m.GetGridByName(r.terrain.Layer)
+	if err != nil {
+		return nil, err
+	}
+	labels, groups := components(grid, r.terrain.isLand)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	allowed := make(map[int]bool)
+	for _, p := range r.islands {
+		if local, ok := toLocal(grid, p); ok {
+			if id, land := labels[local]; land {
+				allowed[id] = true
+			}
+		}
+	}
+	// The largest landmass is the mainland; everything else must be listed.
+	main := 0
+	for id, g := range groups {
+		if len(g) > len(groups[main]) {
+			main = id
+		}
+	}
+	var violations []Violation
+	for id, g := range groups {
+		if id == main || allowed[id] {
+			continue
+		}
+		violations = append(violations, Violation{
+			Rule:      r.Name(),
+			Message:   fmt.Sprintf("unlisted island of %d hexes at %s", len(g), toMap(grid, g[0])[0]),
+			Positions: toMap(grid, g...),
+		})
+	}
+	return violations, nil
+}
+
+// startsReachable implements StartsReachable.
+type startsReachable struct {
+	terrain Terrain
+	starts  []hex.Position
+}
+
+// StartsReachable requires every start to be reachable from the first one by
+// moving over land or sea, never crossing impassable terrain.
+func StartsReachable(terrain Terrain, starts []hex.Position) Rule {
+	return &startsReachable{terrain: terrain, starts: starts}
+}
+
+func (r *startsReachable) Name() string { return "start-reachable" }
+
+func (r *startsReachable) Check(m hex.Map) ([]Violation, error) {
+	grid, err := m.GetGridByName(r.terrain.Layer)
+	if err != nil {
+		return nil, err
+	}
+	passable := func(v int) bool { return r.terrain.isLand(v) || r.terrain.isWater(v) }
+	labels, _ := components(grid, passable)
+
+	var violations []Violation
+	first := -1
+	for i, s := range r.starts {
+		local, ok := toLocal(grid, s)
+		id, reachable := labels[local]
+		if !ok || !reachable {
+			violations = append(violations, Violation{Rule: r.Name(), Message: fmt.Sprintf("start %d at %s is not on passable terrain", i, s), Positions: []hex.Position{s}})
+			continue
+		}
+		if first < 0 {
+			first = id
+		} else if id != first {
+			violations = append(violations, Violation{Rule: r.Name(), Message: fmt.Sprintf("start %d at %s cannot be reached from the other starts", i, s), Positions: []hex.Position{s}})
+		}
+	}
+	return violations, nil
+}
+
+// noOrphanLakes implements NoOrphanLakes.
+type noOrphanLakes struct {
+	terrain Terrain
+	minSize int
+}
+
+// NoOrphanLakes rejects bodies of water smaller than minSize hexes.
+// A minSize of 0 defaults to 2, rejecting single-hex lakes.
+func NoOrphanLakes(terrain Terrain, minSize int) Rule {
+	if minSize == 0 {
+		minSize = 2
+	}
+	return &noOrphanLakes{terrain: terrain, minSize: minSize}
+}
+
+func (r *noOrphanLakes) Name() string { return "orphan-lake" }
+
+func (r *noOrphanLakes) Check(m hex.Map) ([]Violation, error) {
+	grid, err := m.GetGridByName(r.terrain.Layer)
+	if err != nil {
+		return nil, err
+	}
+	_, groups := components(grid, r.terrain.isWater)
+	var violations []Violation
+	for _, g := range groups {
+		if len(g) < r.minSize {
+			violations = append(violations, Violation{
+				Rule:      r.Name(),
+				Message:   fmt.Sprintf("lake of %d hexes at %s", len(g), toMap(grid, g[0])[0]),
+				Positions: toMap(grid, g...),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// resourceMinimum implements ResourceMinimum.
+type resourceMinimum struct {
+	layer   string
+	starts  []hex.Position
+	radius  int
+	minimum int
+}
+
+// ResourceMinimum requires the resource values within radius of each start to
+// add up to at least minimum.
+func ResourceMinimum(layer string, starts []hex.Position, radius, minimum int) Rule {
+	return &resourceMinimum{layer: layer, starts: starts, radius: radius, minimum: minimum}
+}
+
+func (r *resourceMinimum) Name() string { return "resource-minimum" }
+
+func (r *resourceMinimum) Check(m hex.Map) ([]Violation, error) {
+	grid, err := m.GetGridByName(r.layer)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for i, s := range r.starts {
+		total := 0
+		for _, p := range grid.Range(s.Sub(grid.GetPosition()), r.radius) {
+			if cell, _ := grid.GetCellAtPosition(p); cell != nil {
+				total += cell.GetValue()
+			}
+		}
+		if total < r.minimum {
+			violations = append(violations, Violation{
+				Rule:      r.Name(),
+				Message:   fmt.Sprintf("start %d at %s has %d resources, needs %d", i, s, total, r.minimum),
+				Positions: []hex.Position{s},
+			})
+		}
+	}
+	return violations, nil
+}
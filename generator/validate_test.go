@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gridFromRows builds a grid whose rows are given top to bottom; -1 leaves a nil cell.
+func gridFromRows(name string, rows [][]int) hex.Grid {
+	cells := make([][]hex.Cell, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]hex.Cell, len(row))
+		for q, v := range row {
+			if v >= 0 {
+				cells[r][q] = hex.NewCellWithValue(q, r, v)
+			}
+		}
+	}
+	return hex.NewGrid(hex.Position{}, name, len(rows[0]), len(rows), cells)
+}
+
+func mapWith(t *testing.T, grids ...hex.Grid) hex.Map {
+	m := hex.NewMap(grids[0].GetWidth(), grids[0].GetHeight())
+	for _, g := range grids {
+		require.NoError(t, m.AddGrid(g))
+	}
+	return m
+}
+
+var testTerrain = Terrain{Layer: "terrain", Impassable: []int{9}}
+
+func TestLandConnected(t *testing.T) {
+	assert := assert.New(t)
+	m := mapWith(t, gridFromRows("terrain", [][]int{
+		{1, 1, 1, 0, 0, 0},
+		{1, 1, 0, 0, 1, 0},
+		{0, 0, 0, 0, 0, 0},
+		{0, 1, 0, 0, 0, 0},
+	}))
+
+	violations, err := Validate(m, LandConnected(testTerrain))
+	require.NoError(t, err)
+	require.Len(t, violations, 2, "two islands besides the mainland")
+	for _, v := range violations {
+		assert.Equal("land-connected", v.Rule)
+		assert.Len(v.Positions, 1)
+	}
+
+	violations, err = Validate(m, LandConnected(testTerrain, hex.NewPosition(4, 1), hex.NewPosition(1, 3)))
+	require.NoError(t, err)
+	assert.Empty(violations, "listed islands are allowed")
+}
+
+func TestStartsReachable(t *testing.T) {
+	assert := assert.New(t)
+	m := mapWith(t, gridFromRows("terrain", [][]int{
+		{1, 1, 9, 1, 1},
+		{1, 0, 9, 0, 1},
+		{1, 0, 9, 0, 1},
+	}))
+	starts := []hex.Position{{Q: 0, R: 0}, {Q: 1, R: 2}, {Q: 4, R: 0}, {Q: 2, R: 1}}
+
+	violations, err := Validate(m, StartsReachable(testTerrain, starts))
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	assert.Equal([]hex.Position{{Q: 4, R: 0}}, violations[0].Positions, "across the mountains")
+	assert.Equal([]hex.Position{{Q: 2, R: 1}}, violations[1].Positions, "on a mountain")
+
+	violations, err = Validate(m, StartsReachable(testTerrain, starts[:2]))
+	require.NoError(t, err)
+	assert.Empty(violations, "land and sea both connect the first two starts")
+}
+
+func TestNoOrphanLakes(t *testing.T) {
+	assert := assert.New(t)
+	m := mapWith(t, gridFromRows("terrain", [][]int{
+		{0, 0, 0, 0, 0},
+		{1, 1, 1, 1, 1},
+		{1, 0, 1, 0, 0},
+		{1, 1, 1, 1, 1},
+	}))
+
+	violations, err := Validate(m, NoOrphanLakes(testTerrain, 0))
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal("orphan-lake", violations[0].Rule)
+	assert.Equal([]hex.Position{{Q: 1, R: 2}}, violations[0].Positions)
+
+	violations, err = Validate(m, NoOrphanLakes(testTerrain, 3))
+	require.NoError(t, err)
+	assert.Len(violations, 2, "the two-hex lake is also too small")
+}
+
+func TestResourceMinimum(t *testing.T) {
+	assert := assert.New(t)
+	m := mapWith(t, gridFromRows("resources", [][]int{
+		{2, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 3},
+	}))
+	starts := []hex.Position{{Q: 0, R: 1}, {Q: 4, R: 1}, {Q: 2, R: 1}}
+
+	violations, err := Validate(m, ResourceMinimum("resources", starts, 1, 2))
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal([]hex.Position{{Q: 2, R: 1}}, violations[0].Positions)
+}
+
+func TestValidate_Errors(t *testing.T) {
+	_, err := Validate(nil)
+	assert.Error(t, err)
+	m := hex.NewMap(2, 2)
+	for _, rule := range []Rule{
+		LandConnected(testTerrain),
+		StartsReachable(testTerrain, nil),
+		NoOrphanLakes(testTerrain, 0),
+		ResourceMinimum("missing", nil, 1, 1),
+	} {
+		_, err := Validate(m, rule)
+		assert.Error(t, err, rule.Name())
+	}
+}
+
+func TestRunUntilValid(t *testing.T) {
+	assert := assert.New(t)
+	attempts := 0
+	p := NewPipeline()
+	require.NoError(t, p.Add("terrain", func(m hex.Map, rng *rand.Rand) error {
+		attempts++
+		// Only the third attempt produces a connected map.
+		rows := [][]int{{1, 0, 1}}
+		if attempts == 3 {
+			rows = [][]int{{1, 1, 1}}
+		}
+		return m.AddGrid(gridFromRows("terrain", rows))
+	}))
+	require.NoError(t, p.Add("validate", ValidationPass(LandConnected(testTerrain))))
+
+	m, report, err := RunUntilValid(p, func() hex.Map { return hex.NewMap(3, 1) }, 1, 5)
+	require.NoError(t, err)
+	assert.Equal(3, attempts)
+	assert.NotNil(m)
+	assert.NotEqual(int64(1), report.Seed, "retries should use a new seed")
+
+	attempts = -10
+	_, _, err = RunUntilValid(p, func() hex.Map { return hex.NewMap(3, 1) }, 1, 2)
+	require.Error(t, err)
+	var ve *ValidationError
+	assert.True(errors.As(err, &ve))
+	assert.Len(ve.Violations, 1)
+	assert.Contains(err.Error(), "land-connected")
+
+	failing := NewPipeline()
+	require.NoError(t, failing.Add("broken", func(hex.Map, *rand.Rand) error { return errors.New("boom") }))
+	_, _, err = RunUntilValid(failing, func() hex.Map { return hex.NewMap(1, 1) }, 1, 5)
+	assert.ErrorContains(err, "boom")
+
+	_, _, err = RunUntilValid(p, func() hex.Map { return hex.NewMap(3, 1) }, 1, 0)
+	assert.ErrorContains(err, "attempts must be at least 1")
+}
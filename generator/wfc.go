@@ -0,0 +1,308 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"slices"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+)
+
+// maxWFCTiles is the largest tile set a WFCRules can hold; domains are bitsets.
+const maxWFCTiles = 64
+
+// WFCRules holds the tiles and adjacency constraints for WaveFunctionCollapse.
+// Directions are indices into hex.Directions.
+type WFCRules struct {
+	tiles   []int
+	weights []float64
+	index   map[int]int
+	// compat[d][i] is the set of tile indices allowed in direction d of tile i.
+	compat [6][]uint64
+}
+
+// NewWFCRules creates an empty rule set.
+func NewWFCRules() *WFCRules {
+	return &WFCRules{index: make(map[int]int)}
+}
+
+// Tiles returns the registered tile values in insertion order.
+func (r *WFCRules) Tiles() []int {
+	return slices.Clone(r.tiles)
+}
+
+// AddTile registers a tile value with a relative weight. Adding an existing
+// tile updates its weight.
+func (r *WFCRules) AddTile(tile int, weight float64) error {
+	if weight <= 0 {
+		return fmt.Errorf("tile %d weight must be positive, got %g", tile, weight)
+	}
+	if i, ok := r.index[tile]; ok {
+		r.weights[i] = weight
+		return nil
+	}
+	if len(r.tiles) == maxWFCTiles {
+		return fmt.Errorf("rule set is limited to %d tiles", maxWFCTiles)
+	}
+	r.index[tile] = len(r.tiles)
+	r.tiles = append(r.tiles, tile)
+	r.weights = append(r.weights, weight)
+	for d := range r.compat {
+		r.compat[d] = append(r.compat[d], 0)
+	}
+	return nil
+}
+
+// Allow permits tile b to sit in direction d of tile a. The mirrored rule,
+// a in the opposite direction of b, is added as well.
+func (r *WFCRules) Allow(a, direction, b int) error {
+	ia, ok := r.index[a]
+	if !ok {
+		return fmt.Errorf("unknown tile %d", a)
+	}
+	ib, ok := r.index[b]
+	if !ok {
+		return fmt.Errorf("unknown tile %d", b)
+	}
+	if direction < 0 || direction >= 6 {
+		return fmt.Errorf("direction %d must be between 0 and 5", direction)
+	}
+	r.compat[direction][ia] |= 1 << ib
+	r.compat[(direction+3)%6][ib] |= 1 << ia
+	return nil
+}
+
+// AllowAll permits tiles a and b to border each other in every direction.
+func (r *WFCRules) AllowAll(a, b int) error {
+	for d := 0; d < 6; d++ {
+		if err := r.Allow(a, d, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WFCOptions configures WaveFunctionCollapse.
+type WFCOptions struct {
+	// Name is the name of the generated layer. Defaults to the shape's name.
+	Name string
+	// Seed drives tile choices; the same seed yields the same layer.
+	Seed int64
+	// MaxBacktracks bounds the number of contradictions the solver recovers
+	// from before giving up. Defaults to 10000.
+	MaxBacktracks int
+}
+
+// WaveFunctionCollapse returns a hex.GenerateGridFunc that fills every cell of
+// the shape with a tile so that each pair of neighbors satisfies the rules.
+// Cells are collapsed lowest entropy first, constraints are propagated to all
+// six neighbors, and contradictions are resolved by backtracking.
+func WaveFunctionCollapse(rules *WFCRules, opts WFCOptions) hex.GenerateGridFunc {
+	return func(shape shapes.Shape) (hex.Grid, error) {
+		if rules == nil || len(rules.tiles) == 0 {
+			return nil, fmt.Errorf("rules must contain at least one tile")
+		}
+		base, err := GridFromShape(shape)
+		if err != nil {
+			return nil, err
+		}
+		name := opts.Name
+		if name == "" {
+			name = base.GetName()
+		}
+		maxBacktracks := opts.MaxBacktracks
+		if maxBacktracks == 0 {
+			maxBacktracks = 10000
+		}
+
+		s := newWFCSolver(rules, base, rand.New(rand.NewSource(opts.Seed)))
+		if err := s.solve(maxBacktracks); err != nil {
+			return nil, fmt.Errorf("wave function collapse on %s: %w", name, err)
+		}
+		return s.build(name), nil
+	}
+}
+
+// wfcSolver is the working state of one WaveFunctionCollapse run.
+type wfcSolver struct {
+	rules     *WFCRules
+	grid      hex.Grid
+	rng       *rand.Rand
+	positions []hex.Position
+	neighbors [][6]int // index of the neighbor in each direction, or -1
+	domains   []uint64
+	trail     []wfcChange
+	decisions []wfcDecision
+}
+
+// wfcChange records a domain before it was narrowed, for undoing.
+type wfcChange struct {
+	cell   int
+	domain uint64
+}
+
+// wfcDecision records a collapse so it can be retracted on contradiction.
+type wfcDecision struct {
+	cell  int
+	tile  int
+	trail int
+}
+
+func newWFCSolver(rules *WFCRules, grid hex.Grid, rng *rand.Rand) *wfcSolver {
+	s := &wfcSolver{rules: rules, grid: grid, rng: rng}
+	lookup := make(map[hex.Position]int)
+	for i := 0; i < grid.GetCellCount(); i++ {
+		if cell, _ := grid.GetCellAtIndex(i); cell != nil {
+			lookup[cell.GetPosition()] = len(s.positions)
+			s.positions = append(s.positions, cell.GetPosition())
+		}
+	}
+	full := uint64(1)<<len(rules.tiles) - 1
+	if len(rules.tiles) == maxWFCTiles {
+		full = math.MaxUint64
+	}
+	s.neighbors = make([][6]int, len(s.positions))
+	s.domains = make([]uint64, len(s.positions))
+	for i, p := range s.positions {
+		s.domains[i] = full
+		for d := 0; d < 6; d++ {
+			if n, ok := lookup[p.Neighbor(d)]; ok {
+				s.neighbors[i][d] = n
+			} else {
+				s.neighbors[i][d] = -1
+			}
+		}
+	}
+	return s
+}
+
+func (s *wfcSolver) solve(maxBacktracks int) error {
+	all := make([]int, len(s.positions))
+	for i := range all {
+		all[i] = i
+	}
+	if !s.propagate(all) {
+		return fmt.Errorf("rules cannot be satisfied")
+	}
+	backtracks := 0
+	for {
+		cell := s.lowestEntropy()
+		if cell < 0 {
+			return nil
+		}
+		tile := s.pickTile(s.domains[cell])
+		s.decisions = append(s.decisions, wfcDecision{cell: cell, tile: tile, trail: len(s.trail)})
+		s.narrow(cell, 1<<tile)
+		ok := s.propagate([]int{cell})
+		for !ok {
+			if len(s.decisions) == 0 {
+				return fmt.Errorf("rules cannot be satisfied")
+			}
+			if backtracks++; backtracks > maxBacktracks {
+				return fmt.Errorf("gave up after %d backtracks", maxBacktracks)
+			}
+			d := s.decisions[len(s.decisions)-1]
+			s.decisions = s.decisions[:len(s.decisions)-1]
+			s.undo(d.trail)
+			remaining := s.domains[d.cell] &^ (1 << d.tile)
+			s.narrow(d.cell, remaining)
+			ok = remaining != 0 && s.propagate([]int{d.cell})
+		}
+	}
+}
+
+// propagate removes unsupported tiles from neighbors until nothing changes.
+// It returns false if any domain becomes empty.
+func (s *wfcSolver) propagate(queue []int) bool {
+	for len(queue) > 0 {
+		cell := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for d, n := range s.neighbors[cell] {
+			if n < 0 {
+				continue
+			}
+			var allowed uint64
+			for dom := s.domains[cell]; dom != 0; dom &= dom - 1 {
+				allowed |= s.rules.compat[d][bits.TrailingZeros64(dom)]
+			}
+			narrowed := s.domains[n] & allowed
+			if narrowed == s.domains[n] {
+				continue
+			}
+			s.narrow(n, narrowed)
+			if narrowed == 0 {
+				return false
+			}
+			queue = append(queue, n)
+		}
+	}
+	return true
+}
+
+func (s *wfcSolver) narrow(cell int, domain uint64) {
+	s.trail = append(s.trail, wfcChange{cell: cell, domain: s.domains[cell]})
+	s.domains[cell] = domain
+}
+
+func (s *wfcSolver) undo(to int) {
+	for len(s.trail) > to {
+		c := s.trail[len(s.trail)-1]
+		s.trail = s.trail[:len(s.trail)-1]
+		s.domains[c.cell] = c.domain
+	}
+}
+
+// lowestEntropy returns the uncollapsed cell with the lowest weighted Shannon
+// entropy, breaking ties randomly, or -1 if every cell is collapsed.
+func (s *wfcSolver) lowestEntropy() int {
+	best, bestEntropy := -1, math.Inf(1)
+	for i, dom := range s.domains {
+		if bits.OnesCount64(dom) <= 1 {
+			continue
+		}
+		sum, sumLog := 0.0, 0.0
+		for ; dom != 0; dom &= dom - 1 {
+			w := s.rules.weights[bits.TrailingZeros64(dom)]
+			sum += w
+			sumLog += w * math.Log(w)
+		}
+		entropy := math.Log(sum) - sumLog/sum + 1e-6*s.rng.Float64()
+		if entropy < bestEntropy {
+			best, bestEntropy = i, entropy
+		}
+	}
+	return best
+}
+
+// pickTile chooses a tile index from the domain, weighted by tile weight.
+func (s *wfcSolver) pickTile(domain uint64) int {
+	total := 0.0
+	for dom := domain; dom != 0; dom &= dom - 1 {
+		total += s.rules.weights[bits.TrailingZeros64(dom)]
+	}
+	roll := s.rng.Float64() * total
+	last := 0
+	for dom := domain; dom != 0; dom &= dom - 1 {
+		last = bits.TrailingZeros64(dom)
+		if roll -= s.rules.weights[last]; roll < 0 {
+			return last
+		}
+	}
+	return last
+}
+
+func (s *wfcSolver) build(name string) hex.Grid {
+	width, height := s.grid.GetWidth(), s.grid.GetHeight()
+	cells := make([][]hex.Cell, height)
+	for r := range cells {
+		cells[r] = make([]hex.Cell, width)
+	}
+	for i, p := range s.positions {
+		tile := s.rules.tiles[bits.TrailingZeros64(s.domains[i])]
+		cells[p.R][p.Q] = hex.NewCellWithValue(p.Q, p.R, tile)
+	}
+	return hex.NewGrid(s.grid.GetPosition(), name, width, height, cells)
+}
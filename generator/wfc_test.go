@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	tileSea = iota
+	tileCoast
+	tileGrass
+	tileHill
+)
+
+// coastRules builds a rule set where sea and grass must be separated by coast
+// and hills only border grass.
+func coastRules(t *testing.T) *WFCRules {
+	rules := NewWFCRules()
+	require.NoError(t, rules.AddTile(tileSea, 3))
+	require.NoError(t, rules.AddTile(tileCoast, 1))
+	require.NoError(t, rules.AddTile(tileGrass, 2))
+	require.NoError(t, rules.AddTile(tileHill, 1))
+	for _, pair := range [][2]int{{tileSea, tileSea}, {tileSea, tileCoast}, {tileCoast, tileCoast}, {tileCoast, tileGrass}, {tileGrass, tileGrass}, {tileGrass, tileHill}, {tileHill, tileHill}} {
+		require.NoError(t, rules.AllowAll(pair[0], pair[1]))
+	}
+	return rules
+}
+
+// assertRulesHold checks every neighbor pair of the grid against the rules.
+func assertRulesHold(t *testing.T, rules *WFCRules, grid hex.Grid) {
+	for i := 0; i < grid.GetCellCount(); i++ {
+		cell, _ := grid.GetCellAtIndex(i)
+		if cell == nil {
+			continue
+		}
+		a := rules.index[cell.GetValue()]
+		for d := 0; d < 6; d++ {
+			n, err := grid.GetCellAtPosition(cell.GetPosition().Neighbor(d))
+			if err != nil || n == nil {
+				continue
+			}
+			b := rules.index[n.GetValue()]
+			assert.NotZero(t, rules.compat[d][a]&(1<<b), "tile %d may not have %d in direction %d at %s", cell.GetValue(), n.GetValue(), d, cell.GetPosition())
+		}
+	}
+}
+
+func TestWaveFunctionCollapse_SatisfiesRules(t *testing.T) {
+	rules := coastRules(t)
+	for _, shape := range []shapes.Shape{
+		shapes.NewRectangle(0, 0, 16, 12, "rect"),
+		shapes.NewCircle(6, 6, 6, "circle"),
+		shapes.NewIsoscelesTriangle(0, 0, 8, "triangle"),
+	} {
+		grid, err := WaveFunctionCollapse(rules, WFCOptions{Seed: 4})(shape)
+		require.NoError(t, err, shape.GetName())
+		assert.Equal(t, shape.GetName(), grid.GetName())
+		assertRulesHold(t, rules, grid)
+	}
+}
+
+func TestWaveFunctionCollapse_Directional(t *testing.T) {
+	assert := assert.New(t)
+	// Tile 1 may only ever have tile 2 to its east, and tile 2 only tile 1 to
+	// its west, forcing alternating columns along each row.
+	rules := NewWFCRules()
+	require.NoError(t, rules.AddTile(1, 1))
+	require.NoError(t, rules.AddTile(2, 1))
+	require.NoError(t, rules.Allow(1, 0, 2))
+	require.NoError(t, rules.Allow(2, 0, 1))
+	for _, d := range []int{1, 2, 4, 5} {
+		require.NoError(t, rules.Allow(1, d, 1))
+		require.NoError(t, rules.Allow(1, d, 2))
+		require.NoError(t, rules.Allow(2, d, 2))
+	}
+
+	grid, err := WaveFunctionCollapse(rules, WFCOptions{Seed: 8})(shapes.NewRectangle(0, 0, 6, 4, "stripes"))
+	require.NoError(t, err)
+	assertRulesHold(t, rules, grid)
+	for r := 0; r < 4; r++ {
+		for q := 0; q+1 < 6; q++ {
+			a, _ := grid.GetCellAt(q, r)
+			b, _ := grid.GetCellAt(q+1, r)
+			assert.NotEqual(a.GetValue(), b.GetValue(), "row %d should alternate", r)
+		}
+	}
+}
+
+func TestWaveFunctionCollapse_Deterministic(t *testing.T) {
+	rules := coastRules(t)
+	a, err := WaveFunctionCollapse(rules, WFCOptions{Seed: 12})(shapes.NewSquare(0, 0, 10, "a"))
+	require.NoError(t, err)
+	b, err := WaveFunctionCollapse(rules, WFCOptions{Seed: 12})(shapes.NewSquare(0, 0, 10, "a"))
+	require.NoError(t, err)
+	for i := 0; i < a.GetCellCount(); i++ {
+		ca, _ := a.GetCellAtIndex(i)
+		cb, _ := b.GetCellAtIndex(i)
+		assert.Equal(t, ca.GetValue(), cb.GetValue())
+	}
+}
+
+func TestWaveFunctionCollapse_Unsatisfiable(t *testing.T) {
+	rules := NewWFCRules()
+	require.NoError(t, rules.AddTile(1, 1))
+	require.NoError(t, rules.AddTile(2, 1))
+	require.NoError(t, rules.Allow(1, 0, 2)) // no tile may ever sit to the north
+	_, err := WaveFunctionCollapse(rules, WFCOptions{})(shapes.NewSquare(0, 0, 3, "bad"))
+	assert.Error(t, err)
+}
+
+func TestWFCRules_Errors(t *testing.T) {
+	rules := NewWFCRules()
+	assert.Error(t, rules.AddTile(1, 0), "zero weight")
+	require.NoError(t, rules.AddTile(1, 1))
+	assert.Error(t, rules.Allow(1, 0, 2), "unknown tile")
+	assert.Error(t, rules.Allow(3, 0, 1), "unknown tile")
+	assert.Error(t, rules.Allow(1, 6, 1), "bad direction")
+	assert.Equal(t, []int{1}, rules.Tiles())
+
+	_, err := WaveFunctionCollapse(NewWFCRules(), WFCOptions{})(shapes.NewSquare(0, 0, 3, "empty"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,362 @@
+package hex
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MapMagic identifies a binary map file.
+const MapMagic = "4HXM"
+
+// BinaryOptions configures binary encoding.
+type BinaryOptions struct {
+	// Gzip compresses the payload after the header.
+	Gzip bool
+}
+
+// header flags
+const flagGzip = 1 << 0
+
+// BinaryWriter writes varint-encoded values. The first error is kept and
+// every later write becomes a no-op, so callers check Err once at the end.
+type BinaryWriter struct {
+	w   io.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+// NewBinaryWriter creates a BinaryWriter writing to w.
+func NewBinaryWriter(w io.Writer) *BinaryWriter {
+	return &BinaryWriter{w: w}
+}
+
+// Err returns the first error encountered while writing.
+func (bw *BinaryWriter) Err() error {
+	return bw.err
+}
+
+func (bw *BinaryWriter) write(p []byte) {
+	if bw.err == nil {
+		_, bw.err = bw.w.Write(p)
+	}
+}
+
+// WriteUvarint writes an unsigned varint.
+func (bw *BinaryWriter) WriteUvarint(v uint64) {
+	bw.write(bw.buf[:binary.PutUvarint(bw.buf[:], v)])
+}
+
+// WriteVarint writes a signed, zig-zag encoded varint.
+func (bw *BinaryWriter) WriteVarint(v int64) {
+	bw.write(bw.buf[:binary.PutVarint(bw.buf[:], v)])
+}
+
+// WriteInt writes an int as a signed varint.
+func (bw *BinaryWriter) WriteInt(v int) {
+	bw.WriteVarint(int64(v))
+}
+
+// WriteBool writes a single byte, 1 for true.
+func (bw *BinaryWriter) WriteBool(v bool) {
+	b := byte(0)
+	if v {
+		b = 1
+	}
+	bw.write([]byte{b})
+}
+
+// WriteString writes a length-prefixed string.
+func (bw *BinaryWriter) WriteString(s string) {
+	bw.WriteUvarint(uint64(len(s)))
+	bw.write([]byte(s))
+}
+
+// BinaryReader reads values written by a BinaryWriter. Like the writer it
+// keeps the first error and returns zero values afterwards.
+type BinaryReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+// NewBinaryReader creates a BinaryReader reading from r.
+func NewBinaryReader(r io.Reader) *BinaryReader {
+	return &BinaryReader{r: bufio.NewReader(r)}
+}
+
+// Err returns the first error encountered while reading.
+func (br *BinaryReader) Err() error {
+	return br.err
+}
+
+func (br *BinaryReader) fail(err error) {
+	if br.err == nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		br.err = err
+	}
+}
+
+// ReadUvarint reads an unsigned varint.
+func (br *BinaryReader) ReadUvarint() uint64 {
+	if br.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		br.fail(err)
+	}
+	return v
+}
+
+// ReadVarint reads a signed varint.
+func (br *BinaryReader) ReadVarint() int64 {
+	if br.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(br.r)
+	if err != nil {
+		br.fail(err)
+	}
+	return v
+}
+
+// ReadInt reads an int written by WriteInt.
+func (br *BinaryReader) ReadInt() int {
+	return int(br.ReadVarint())
+}
+
+// ReadBool reads a bool written by WriteBool.
+func (br *BinaryReader) ReadBool() bool {
+	if br.err != nil {
+		return false
+	}
+	b, err := br.r.ReadByte()
+	if err != nil {
+		br.fail(err)
+	}
+	return b == 1
+}
+
+// ReadString reads a length-prefixed string, refusing lengths above max.
+func (br *BinaryReader) ReadString(max int) string {
+	n := br.ReadUvarint()
+	if br.err != nil {
+		return ""
+	}
+	if n > uint64(max) {
+		br.fail(fmt.Errorf("string length %d exceeds limit %d", n, max))
+		return ""
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(br.r, buf); err != nil {
+		br.fail(err)
+		return ""
+	}
+	return string(buf)
+}
+
+// WriteBinaryHeader writes a file header of magic, version and flags, and
+// returns the writer for the payload. The payload writer must be closed to
+// flush compressed data; closing it does not close w.
+func WriteBinaryHeader(w io.Writer, magic string, version uint64, opts BinaryOptions) (io.WriteCloser, error) {
+	bw := NewBinaryWriter(w)
+	bw.write([]byte(magic))
+	bw.WriteUvarint(version)
+	flags := uint64(0)
+	if opts.Gzip {
+		flags |= flagGzip
+	}
+	bw.WriteUvarint(flags)
+	if err := bw.Err(); err != nil {
+		return nil, err
+	}
+	if opts.Gzip {
+		return gzip.NewWriter(w), nil
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// ReadBinaryHeader checks the magic of a file header and returns its version
+// and a reader for the payload, decompressing it if needed.
+func ReadBinaryHeader(r io.Reader, magic string) (uint64, io.Reader, error) {
+	br := NewBinaryReader(r)
+	got := make([]byte, len(magic))
+	if _, err := io.ReadFull(br.r, got); err != nil {
+		return 0, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(got) != magic {
+		return 0, nil, fmt.Errorf("bad magic %q, expected %q", got, magic)
+	}
+	version := br.ReadUvarint()
+	flags := br.ReadUvarint()
+	if err := br.Err(); err != nil {
+		return 0, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if flags&^flagGzip != 0 {
+		return 0, nil, fmt.Errorf("unknown header flags %#x", flags)
+	}
+	if flags&flagGzip != 0 {
+		zr, err := gzip.NewReader(br.r)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to open compressed payload: %w", err)
+		}
+		return version, zr, nil
+	}
+	return version, br.r, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// EncodeMapBinary writes m as a binary map file.
+func EncodeMapBinary(w io.Writer, m Map, opts BinaryOptions) error {
+	if m == nil {
+		return fmt.Errorf("cannot encode nil map")
+	}
+	payload, err := WriteBinaryHeader(w, MapMagic, FormatVersion, opts)
+	if err != nil {
+		return err
+	}
+	bw := NewBinaryWriter(payload)
+	WriteMapPayload(bw, m)
+	if err := bw.Err(); err != nil {
+		return err
+	}
+	return payload.Close()
+}
+
+// DecodeMapBinary reads a binary map file written by EncodeMapBinary,
+// upgrading older versions.
+func DecodeMapBinary(r io.Reader) (Map, error) {
+	version, payload, err := ReadBinaryHeader(r, MapMagic)
+	if err != nil {
+		return nil, err
+	}
+	return ReadMapPayload(NewBinaryReader(payload), int(version))
+}
+
+// WriteMapPayload writes m without a file header, for embedding maps in
+// other binary files. Nil cells are run-length encoded.
+func WriteMapPayload(bw *BinaryWriter, m Map) {
+	width, height := m.GetDimensions()
+	bw.WriteInt(width)
+	bw.WriteInt(height)
+	bw.WriteUvarint(uint64(m.GetTopology()))
+	bw.WriteUvarint(uint64(len(m.GetGrids())))
+	for _, g := range m.GetGrids() {
+		writeGridPayload(bw, toGridDocument(g))
+	}
+}
+
+// writeGridPayload writes the grid header followed by its cells as runs.
+// Each run starts with uvarint (length<<1 | nil); non-nil runs are followed
+// by one varint value per cell.
+func writeGridPayload(bw *BinaryWriter, doc *gridDocument) {
+	bw.WriteString(doc.Name)
+	bw.WriteInt(doc.Position.Q)
+	bw.WriteInt(doc.Position.R)
+	bw.WriteInt(doc.Width)
+	bw.WriteInt(doc.Height)
+
+	cells := make([]*int, 0, doc.Width*doc.Height)
+	for _, row := range doc.Cells {
+		cells = append(cells, row...)
+	}
+	for start := 0; start < len(cells); {
+		isNil := cells[start] == nil
+		end := start + 1
+		for end < len(cells) && (cells[end] == nil) == isNil {
+			end++
+		}
+		header := uint64(end-start) << 1
+		if isNil {
+			header |= 1
+		}
+		bw.WriteUvarint(header)
+		if !isNil {
+			for _, v := range cells[start:end] {
+				bw.WriteInt(*v)
+			}
+		}
+		start = end
+	}
+}
+
+// maxDecodedCells bounds the size of a grid decoded from a binary or compact
+// file, guarding against corrupt input.
+const maxDecodedCells = 1 << 26
+
+// ReadMapPayload reads a map written by WriteMapPayload in the given format version.
+func ReadMapPayload(br *BinaryReader, version int) (Map, error) {
+	if version < 1 || version > FormatVersion {
+		return nil, fmt.Errorf("unsupported binary map version %d", version)
+	}
+	doc := &mapDocument{Version: version}
+	doc.Width = br.ReadInt()
+	doc.Height = br.ReadInt()
+	doc.Topology = Topology(br.ReadUvarint()).String()
+	layers := br.ReadUvarint()
+	if err := br.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read map: %w", err)
+	}
+	for i := uint64(0); i < layers; i++ {
+		g, err := readGridPayload(br)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		doc.Layers = append(doc.Layers, *g)
+	}
+	return fromMapDocument(doc)
+}
+
+func readGridPayload(br *BinaryReader) (*gridDocument, error) {
+	doc := &gridDocument{Name: br.ReadString(1 << 16)}
+	doc.Position.Q = br.ReadInt()
+	doc.Position.R = br.ReadInt()
+	doc.Width = br.ReadInt()
+	doc.Height = br.ReadInt()
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+	// Check each dimension before multiplying so the product cannot overflow.
+	if doc.Width < 0 || doc.Height < 0 || doc.Width > maxDecodedCells || doc.Height > maxDecodedCells/max(doc.Width, 1) {
+		return nil, fmt.Errorf("grid %s has invalid dimensions %dx%d", doc.Name, doc.Width, doc.Height)
+	}
+
+	// Grow the cells as runs are read rather than trusting the header, so a
+	// short corrupt file cannot force a large allocation.
+	total := doc.Width * doc.Height
+	cells := make([]*int, 0, min(total, 1<<12))
+	for len(cells) < total {
+		header := br.ReadUvarint()
+		n := int(header >> 1)
+		if br.Err() == nil && (n == 0 || n > total-len(cells)) {
+			return nil, fmt.Errorf("grid %s has a bad run of %d cells", doc.Name, n)
+		}
+		for i := 0; i < n; i++ {
+			if header&1 == 1 {
+				cells = append(cells, nil)
+				continue
+			}
+			v := br.ReadInt()
+			cells = append(cells, &v)
+		}
+		if err := br.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	doc.Cells = make([][]*int, doc.Height)
+	for r := range doc.Cells {
+		doc.Cells[r] = cells[r*doc.Width : (r+1)*doc.Width]
+	}
+	return doc, nil
+}
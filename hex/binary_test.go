@@ -0,0 +1,151 @@
+package hex
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLargeMap returns a size x size map with a dense layer and a mostly nil layer.
+func newLargeMap(size int) Map {
+	m := NewMap(size, size)
+	dense := make([][]Cell, size)
+	sparse := make([][]Cell, size)
+	for r := 0; r < size; r++ {
+		dense[r] = make([]Cell, size)
+		sparse[r] = make([]Cell, size)
+		for q := 0; q < size; q++ {
+			dense[r][q] = NewCellWithValue(q, r, (q*7+r*3)%5)
+			if (q-size/2)*(q-size/2)+(r-size/2)*(r-size/2) < size {
+				sparse[r][q] = NewCellWithValue(q, r, -q)
+			}
+		}
+	}
+	m.AddGrid(NewGrid(Position{}, "terrain", size, size, dense))
+	m.AddGrid(NewGrid(Position{Q: -3, R: 2}, "lake", size, size, sparse))
+	return m
+}
+
+func TestMapBinary_RoundTripMatchesJSON(t *testing.T) {
+	maps := map[string]Map{
+		"empty":     NewMap(0, 0),
+		"no layers": NewMap(4, 4),
+		"sparse": func() Map {
+			m := NewMap(3, 2)
+			m.AddGrid(newSparseGrid("terrain", Position{Q: 1, R: 2}))
+			m.SetTopology(Cylinder)
+			return m
+		}(),
+		"large": newLargeMap(64),
+	}
+	for name, m := range maps {
+		for _, gz := range []bool{false, true} {
+			t.Run(name, func(t *testing.T) {
+				var buf bytes.Buffer
+				require.NoError(t, EncodeMapBinary(&buf, m, BinaryOptions{Gzip: gz}))
+				decoded, err := DecodeMapBinary(&buf)
+				require.NoError(t, err)
+
+				want, err := MarshalMap(m)
+				require.NoError(t, err)
+				got, err := MarshalMap(decoded)
+				require.NoError(t, err)
+				assert.JSONEq(t, string(want), string(got), "binary round trip should match the JSON encoding")
+			})
+		}
+	}
+}
+
+func TestMapBinary_IsCompact(t *testing.T) {
+	m := newLargeMap(256)
+	jsonData, err := MarshalMap(m)
+	require.NoError(t, err)
+
+	var raw, gz bytes.Buffer
+	require.NoError(t, EncodeMapBinary(&raw, m, BinaryOptions{}))
+	require.NoError(t, EncodeMapBinary(&gz, m, BinaryOptions{Gzip: true}))
+
+	assert.Less(t, raw.Len(), len(jsonData)/2, "binary should be much smaller than JSON")
+	assert.Less(t, gz.Len(), raw.Len(), "gzip should shrink the payload further")
+	// The mostly nil layer collapses into a handful of runs.
+	assert.Less(t, raw.Len(), 256*256+4096)
+}
+
+func TestMapBinary_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeMapBinary(&buf, newLargeMap(8), BinaryOptions{}))
+	data := buf.Bytes()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", append([]byte("NOPE"), data[4:]...)},
+		{"future version", append([]byte(MapMagic), append([]byte{99, 0}, data[6:]...)...)},
+		{"unknown flags", append([]byte(MapMagic), append([]byte{1, 8}, data[6:]...)...)},
+		{"truncated", data[:len(data)/2]},
+		{"bad gzip", append([]byte(MapMagic), 1, 1, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeMapBinary(bytes.NewReader(tt.data))
+			assert.Error(t, err)
+		})
+	}
+	assert.Error(t, EncodeMapBinary(&buf, nil, BinaryOptions{}))
+}
+
+func TestMapBinary_OversizedGrid(t *testing.T) {
+	for _, size := range [][2]int{{1 << 62, 4}, {4, 1 << 62}, {1 << 13, 1 << 14}} {
+		var buf bytes.Buffer
+		payload, err := WriteBinaryHeader(&buf, MapMagic, FormatVersion, BinaryOptions{})
+		require.NoError(t, err)
+		bw := NewBinaryWriter(payload)
+		bw.WriteInt(1)
+		bw.WriteInt(1)
+		bw.WriteUvarint(uint64(Flat))
+		bw.WriteUvarint(1)
+		bw.WriteString("huge")
+		bw.WriteInt(0)
+		bw.WriteInt(0)
+		bw.WriteInt(size[0])
+		bw.WriteInt(size[1])
+		require.NoError(t, bw.Err())
+		require.NoError(t, payload.Close())
+
+		_, err = DecodeMapBinary(&buf)
+		assert.ErrorContains(t, err, "invalid dimensions", "%dx%d", size[0], size[1])
+	}
+}
+
+func TestBinaryReaderWriter(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	bw := NewBinaryWriter(&buf)
+	bw.WriteUvarint(300)
+	bw.WriteVarint(-5)
+	bw.WriteInt(42)
+	bw.WriteBool(true)
+	bw.WriteString("hex")
+	require.NoError(t, bw.Err())
+
+	br := NewBinaryReader(&buf)
+	assert.Equal(uint64(300), br.ReadUvarint())
+	assert.Equal(int64(-5), br.ReadVarint())
+	assert.Equal(42, br.ReadInt())
+	assert.True(br.ReadBool())
+	assert.Equal("hex", br.ReadString(10))
+	assert.NoError(br.Err())
+
+	assert.Equal(0, br.ReadInt(), "reads past the end return zero")
+	assert.Error(br.Err())
+
+	buf.Reset()
+	NewBinaryWriter(&buf).WriteString("too long")
+	br = NewBinaryReader(&buf)
+	assert.Equal("", br.ReadString(3))
+	assert.ErrorContains(br.Err(), "exceeds limit")
+}
@@ -3,19 +3,40 @@ package hex
 type Cell interface {
 	// GetPosition returns the position of the cell in the grid.
 	GetPosition() Position
+	// GetValue returns the layer value stored in the cell, such as a terrain id.
+	GetValue() int
+	// SetValue replaces the layer value stored in the cell.
+	SetValue(v int)
 }
 
 type concreteCell struct {
 	position Position
+	value    int
 }
 
 func (c *concreteCell) GetPosition() Position {
 	return c.position
 }
 
+func (c *concreteCell) GetValue() int {
+	return c.value
+}
+
+func (c *concreteCell) SetValue(v int) {
+	c.value = v
+}
+
 // NewCell creates a new Cell with the specified position.
 func NewCell(q, r int) Cell {
 	return &concreteCell{
 		position: Position{Q: q, R: r},
 	}
 }
+
+// NewCellWithValue creates a new Cell with the specified position and layer value.
+func NewCellWithValue(q, r, v int) Cell {
+	return &concreteCell{
+		position: Position{Q: q, R: r},
+		value:    v,
+	}
+}
@@ -72,3 +72,17 @@ func TestNewCell(t *testing.T) {
 		})
 	}
 }
+
+func TestConcreteCell_Value(t *testing.T) {
+	assert := assert.New(t)
+
+	cell := NewCell(1, 2)
+	assert.Equal(0, cell.GetValue(), "NewCell should start with a zero value")
+
+	cell.SetValue(7)
+	assert.Equal(7, cell.GetValue(), "SetValue should update the value")
+
+	valued := NewCellWithValue(3, 4, 9)
+	assert.Equal(Position{Q: 3, R: 4}, valued.GetPosition())
+	assert.Equal(9, valued.GetValue(), "NewCellWithValue should store the value")
+}
@@ -0,0 +1,353 @@
+package hex
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The compact format draws a grid with one rune per hex, staggered like the
+// hexes themselves, under a short header:
+//
+//	name: terrain
+//	layout: pointy-odd
+//	legend: .=0 #=1
+//	--
+//	. # # .
+//	 # . . #
+//	. . # #
+//
+// Pointy layouts write one line per offset row with the hexes of a row two
+// characters apart; shoved rows start one character later. Flat layouts
+// write two lines per offset row with the hexes of a column one character
+// apart; shoved columns sit on the second line. Spaces are missing cells.
+//
+// Every header line is optional, as is the header itself. Without a header, an
+// empty first line is skipped so drawings can follow an opening backquote. Digits stand for
+// their own value unless the legend says otherwise. Origin is the offset
+// coordinate of the first character and defaults to 0,0; position and size
+// restore the grid's bounds, which otherwise fit its cells.
+
+// compactSeparator ends the header of the compact format.
+const compactSeparator = "--"
+
+// CompactOptions configures MarshalCompact.
+type CompactOptions struct {
+	// Layout selects the stagger of the text. Defaults to pointy-odd.
+	Layout OffsetLayout
+	// Legend maps values to runes. Values without an entry use their digit
+	// when 0-9, then unused letters in order.
+	Legend map[int]rune
+}
+
+// MarshalCompact writes the cells of g in the compact text format.
+func MarshalCompact(g Grid, opts CompactOptions) (string, error) {
+	if g == nil {
+		return "", fmt.Errorf("cannot marshal nil grid")
+	}
+	type placed struct {
+		offset OffsetCoord
+		value  int
+	}
+	var cells []placed
+	var values []int
+	seen := make(map[int]bool)
+	minCol, minRow := math.MaxInt, math.MaxInt
+	for i := 0; i < g.GetCellCount(); i++ {
+		cell, _ := g.GetCellAtIndex(i)
+		if cell == nil {
+			continue
+		}
+		o := opts.Layout.ToOffset(cell.GetPosition().Add(g.GetPosition()))
+		cells = append(cells, placed{offset: o, value: cell.GetValue()})
+		minCol, minRow = min(minCol, o.Col), min(minRow, o.Row)
+		if !seen[cell.GetValue()] {
+			seen[cell.GetValue()] = true
+			values = append(values, cell.GetValue())
+		}
+	}
+	if len(cells) == 0 {
+		minCol, minRow = 0, 0
+	}
+	slices.Sort(values)
+	glyphs, err := compactGlyphs(values, opts.Legend)
+	if err != nil {
+		return "", err
+	}
+
+	lines := map[int][]rune{}
+	maxLine := -1
+	for _, c := range cells {
+		x, y := compactPlace(opts.Layout, c.offset, OffsetCoord{Col: minCol, Row: minRow})
+		line := lines[y]
+		for len(line) <= x {
+			line = append(line, ' ')
+		}
+		line[x] = glyphs[c.value]
+		lines[y] = line
+		maxLine = max(maxLine, y)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\n", g.GetName())
+	fmt.Fprintf(&b, "layout: %s-%s\n", opts.Layout.Orientation, opts.Layout.Parity)
+	fmt.Fprintf(&b, "origin: %d,%d\n", minCol, minRow)
+	fmt.Fprintf(&b, "position: %d,%d\n", g.GetPosition().Q, g.GetPosition().R)
+	fmt.Fprintf(&b, "size: %d,%d\n", g.GetWidth(), g.GetHeight())
+	legend := make([]string, len(values))
+	for i, v := range values {
+		legend[i] = fmt.Sprintf("%c=%d", glyphs[v], v)
+	}
+	fmt.Fprintf(&b, "legend: %s\n", strings.Join(legend, " "))
+	b.WriteString(compactSeparator + "\n")
+	for y := 0; y <= maxLine; y++ {
+		b.WriteString(string(lines[y]))
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// compactGlyphs assigns a rune to every value.
+func compactGlyphs(values []int, legend map[int]rune) (map[int]rune, error) {
+	glyphs := make(map[int]rune, len(values))
+	used := make(map[rune]bool)
+	for _, v := range values {
+		if g, ok := legend[v]; ok {
+			if g == ' ' || g == '\n' || g == '\r' {
+				return nil, fmt.Errorf("legend glyph %q for value %d is blank", g, v)
+			}
+			if used[g] {
+				return nil, fmt.Errorf("legend glyph %q is used for more than one value", g)
+			}
+			glyphs[v], used[g] = g, true
+		}
+	}
+	for _, v := range values {
+		if _, ok := glyphs[v]; !ok && v >= 0 && v <= 9 && !used[rune('0'+v)] {
+			glyphs[v], used[rune('0'+v)] = rune('0'+v), true
+		}
+	}
+	letters := []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	for _, v := range values {
+		if _, ok := glyphs[v]; ok {
+			continue
+		}
+		for len(letters) > 0 && used[letters[0]] {
+			letters = letters[1:]
+		}
+		if len(letters) == 0 {
+			return nil, fmt.Errorf("too many distinct values for the compact format, add a legend")
+		}
+		glyphs[v], used[letters[0]] = letters[0], true
+	}
+	return glyphs, nil
+}
+
+// compactPlace returns the text column and line of an offset coordinate.
+func compactPlace(layout OffsetLayout, o, origin OffsetCoord) (int, int) {
+	if layout.Orientation == FlatTop {
+		y := 2 * (o.Row - origin.Row)
+		if layout.Shoved(o.Col) {
+			y++
+		}
+		return o.Col - origin.Col, y
+	}
+	x := 2 * (o.Col - origin.Col)
+	if layout.Shoved(o.Row) {
+		x++
+	}
+	return x, o.Row - origin.Row
+}
+
+// UnmarshalCompact parses a grid written in the compact text format.
+func UnmarshalCompact(text string) (Grid, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	header, body := []string(nil), lines
+	if i := slices.Index(lines, compactSeparator); i >= 0 {
+		header, body = lines[:i], lines[i+1:]
+	}
+
+	name := "layer"
+	var layout OffsetLayout
+	var origin OffsetCoord
+	var root *Position
+	var width, height int
+	hasSize := false
+	glyphs := make(map[rune]int)
+	for d := 0; d <= 9; d++ {
+		glyphs[rune('0'+d)] = d
+	}
+	for n, line := range header {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch strings.TrimSpace(key) {
+		case "name":
+			name = value
+		case "layout":
+			layout, err = parseOffsetLayout(value)
+		case "origin":
+			origin.Col, origin.Row, err = parsePair(value)
+		case "position":
+			var q, r int
+			q, r, err = parsePair(value)
+			root = &Position{Q: q, R: r}
+		case "size":
+			width, height, err = parsePair(value)
+			if err == nil && (width < 0 || height < 0) {
+				err = fmt.Errorf("invalid grid size %dx%d", width, height)
+			}
+			hasSize = true
+		case "legend":
+			err = parseLegend(value, glyphs)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+	}
+	// A headerless drawing may start on the line after an opening backquote.
+	if header == nil && len(body) > 0 && body[0] == "" {
+		body = body[1:]
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+
+	values := make(map[Position]int)
+	lo := Position{Q: math.MaxInt, R: math.MaxInt}
+	hi := Position{Q: math.MinInt, R: math.MinInt}
+	for y, line := range body {
+		for x, r := range []rune(line) {
+			if r == ' ' {
+				continue
+			}
+			value, ok := glyphs[r]
+			if !ok {
+				return nil, fmt.Errorf("drawing line %d, column %d: unknown glyph %q", y+1, x+1, r)
+			}
+			o, ok := compactOffset(layout, x, y, origin)
+			if !ok {
+				return nil, fmt.Errorf("drawing line %d, column %d: glyph %q is not on a hex", y+1, x+1, r)
+			}
+			p := layout.FromOffset(o)
+			values[p] = value
+			lo = Position{Q: min(lo.Q, p.Q), R: min(lo.R, p.R)}
+			hi = Position{Q: max(hi.Q, p.Q), R: max(hi.R, p.R)}
+		}
+	}
+	if len(values) == 0 {
+		lo, hi = Position{}, Position{Q: -1, R: -1}
+	}
+	if root == nil {
+		root = &lo
+	}
+	if !hasSize {
+		width, height = hi.Q-root.Q+1, hi.R-root.R+1
+	}
+	// Check each dimension before multiplying so the product cannot overflow.
+	if width < 0 || height < 0 || width > maxDecodedCells || height > maxDecodedCells/max(width, 1) {
+		return nil, fmt.Errorf("invalid grid size %dx%d", width, height)
+	}
+
+	cells := make([][]Cell, height)
+	for r := range cells {
+		cells[r] = make([]Cell, width)
+	}
+	for p, v := range values {
+		local := p.Sub(*root)
+		if local.Q < 0 || local.R < 0 || local.Q >= width || local.R >= height {
+			return nil, fmt.Errorf("cell at %s lies outside the grid", p)
+		}
+		cells[local.R][local.Q] = NewCellWithValue(local.Q, local.R, v)
+	}
+	return NewGrid(*root, name, width, height, cells), nil
+}
+
+// compactOffset is the inverse of compactPlace. It reports false when the
+// character does not sit on a hex.
+func compactOffset(layout OffsetLayout, x, y int, origin OffsetCoord) (OffsetCoord, bool) {
+	if layout.Orientation == FlatTop {
+		col := origin.Col + x
+		if layout.Shoved(col) {
+			y--
+		}
+		if y < 0 || y%2 != 0 {
+			return OffsetCoord{}, false
+		}
+		return OffsetCoord{Col: col, Row: origin.Row + y/2}, true
+	}
+	row := origin.Row + y
+	if layout.Shoved(row) {
+		x--
+	}
+	if x < 0 || x%2 != 0 {
+		return OffsetCoord{}, false
+	}
+	return OffsetCoord{Col: origin.Col + x/2, Row: row}, true
+}
+
+// parseOffsetLayout parses layouts written as pointy-odd, flat-even and so on.
+func parseOffsetLayout(s string) (OffsetLayout, error) {
+	orientation, parity, _ := strings.Cut(s, "-")
+	var l OffsetLayout
+	switch orientation {
+	case PointyTop.String():
+		l.Orientation = PointyTop
+	case FlatTop.String():
+		l.Orientation = FlatTop
+	default:
+		return l, fmt.Errorf("unknown orientation %q", orientation)
+	}
+	switch parity {
+	case Odd.String():
+		l.Parity = Odd
+	case Even.String():
+		l.Parity = Even
+	default:
+		return l, fmt.Errorf("unknown parity %q", parity)
+	}
+	return l, nil
+}
+
+// parsePair parses two comma separated integers.
+func parsePair(s string) (int, int, error) {
+	a, b, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected two numbers, got %q", s)
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(a))
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(b))
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+
+// parseLegend adds entries written as glyph=value to glyphs.
+func parseLegend(s string, glyphs map[rune]int) error {
+	for _, entry := range strings.Fields(s) {
+		r := []rune(entry)
+		if len(r) < 3 || r[1] != '=' {
+			return fmt.Errorf("invalid legend entry %q", entry)
+		}
+		v, err := strconv.Atoi(string(r[2:]))
+		if err != nil {
+			return fmt.Errorf("invalid legend entry %q: %w", entry, err)
+		}
+		glyphs[r[0]] = v
+	}
+	return nil
+}
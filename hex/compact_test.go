@@ -0,0 +1,132 @@
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var compactLayouts = []OffsetLayout{
+	{Orientation: PointyTop, Parity: Odd},
+	{Orientation: PointyTop, Parity: Even},
+	{Orientation: FlatTop, Parity: Odd},
+	{Orientation: FlatTop, Parity: Even},
+}
+
+func TestCompact_RoundTrip(t *testing.T) {
+	// A flat grid whose top hex sits in a shoved column starts with a blank line.
+	shoved := NewGrid(NewPosition(1, 0), "shoved", 1, 2, [][]Cell{{NewCellWithValue(0, 0, 3)}, {NewCellWithValue(0, 1, 4)}})
+	text, err := MarshalCompact(shoved, CompactOptions{Layout: OffsetLayout{Orientation: FlatTop}})
+	require.NoError(t, err)
+	got, err := UnmarshalCompact(text)
+	require.NoError(t, err, text)
+	assertGridsEqual(t, shoved, got)
+
+	for _, layout := range compactLayouts {
+		for _, pos := range []Position{{Q: 0, R: 0}, {Q: -3, R: 5}} {
+			g := newSparseGrid("terrain", pos)
+			text, err := MarshalCompact(g, CompactOptions{Layout: layout})
+			require.NoError(t, err)
+			got, err := UnmarshalCompact(text)
+			require.NoError(t, err, text)
+			assertGridsEqual(t, g, got)
+		}
+	}
+}
+
+func TestMarshalCompact(t *testing.T) {
+	cells := [][]Cell{
+		{NewCellWithValue(0, 0, 0), NewCellWithValue(1, 0, 1), NewCellWithValue(2, 0, 0)},
+		{NewCellWithValue(0, 1, 1), nil, NewCellWithValue(2, 1, 42)},
+	}
+	g := NewGrid(NewPosition(0, 0), "terrain", 3, 2, cells)
+
+	text, err := MarshalCompact(g, CompactOptions{Legend: map[int]rune{0: '.', 1: '#'}})
+	require.NoError(t, err)
+	assert.Equal(t, "name: terrain\nlayout: pointy-odd\norigin: 0,0\nposition: 0,0\nsize: 3,2\nlegend: .=0 #=1 a=42\n--\n. # .\n #   a\n", text)
+
+	text, err = MarshalCompact(g, CompactOptions{Layout: OffsetLayout{Orientation: FlatTop}})
+	require.NoError(t, err)
+	assert.Equal(t, "name: terrain\nlayout: flat-odd\norigin: 0,0\nposition: 0,0\nsize: 3,2\nlegend: 0=0 1=1 a=42\n--\n0\n 1\n1 0\n\n  a\n", text)
+
+	_, err = MarshalCompact(g, CompactOptions{Legend: map[int]rune{0: '.', 1: '.'}})
+	assert.Error(t, err)
+	_, err = MarshalCompact(g, CompactOptions{Legend: map[int]rune{0: ' '}})
+	assert.Error(t, err)
+	_, err = MarshalCompact(nil, CompactOptions{})
+	assert.Error(t, err)
+}
+
+func TestUnmarshalCompact_Fixture(t *testing.T) {
+	// Fixtures only need the drawing; digits stand for themselves.
+	g, err := UnmarshalCompact(`
+1 1 0
+ 0 1 1
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "layer", g.GetName())
+	assert.Equal(t, NewPosition(0, 0), g.GetPosition())
+	assert.Equal(t, 3, g.GetWidth())
+	assert.Equal(t, 2, g.GetHeight())
+	// Row 1 is shoved, so its first hex is axial (0, 1).
+	for _, c := range []struct {
+		pos   Position
+		value int
+	}{{NewPosition(0, 0), 1}, {NewPosition(2, 0), 0}, {NewPosition(0, 1), 0}, {NewPosition(2, 1), 1}} {
+		cell, err := g.GetCellAtPosition(c.pos)
+		require.NoError(t, err)
+		require.NotNil(t, cell, "%s", c.pos)
+		assert.Equal(t, c.value, cell.GetValue(), "%s", c.pos)
+	}
+
+	g, err = UnmarshalCompact("name: islands\nlegend: ~=0 #=1\n--\n~ # ~\n # ~\n")
+	require.NoError(t, err)
+	assert.Equal(t, "islands", g.GetName())
+	cell, err := g.GetCellAtPosition(NewPosition(1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, cell.GetValue())
+	// Row 1 starts at axial q 0 and has no third hex.
+	assert.Equal(t, 3, g.GetWidth())
+	cell, err = g.GetCellAtPosition(NewPosition(2, 1))
+	require.NoError(t, err)
+	assert.Nil(t, cell)
+}
+
+func TestUnmarshalCompact_Errors(t *testing.T) {
+	for name, text := range map[string]string{
+		"unknown glyph":  "--\n1 x\n",
+		"misaligned":     "--\n1  1\n",
+		"bad layout":     "layout: round\n--\n1\n",
+		"bad parity":     "layout: pointy-third\n--\n1\n",
+		"unknown key":    "colour: red\n--\n1\n",
+		"bad legend":     "legend: .0\n--\n.\n",
+		"bad origin":     "origin: 1\n--\n1\n",
+		"outside bounds": "size: 1,1\n--\n1 1\n",
+		"no key":         "hello\n--\n1\n",
+	} {
+		_, err := UnmarshalCompact(text)
+		assert.Error(t, err, name)
+	}
+
+	g, err := UnmarshalCompact("")
+	require.NoError(t, err)
+	assert.Zero(t, g.GetCellCount())
+}
+
+func TestUnmarshalCompact_InvalidSize(t *testing.T) {
+	for name, text := range map[string]string{
+		"negative":     "size: -5,3\n--\n1\n",
+		"minus one":    "size: -1,-1\n--\n",
+		"too large":    "size: 4000000000,4000000000\n--\n0\n",
+		"far position": "position: -4000000000,0\n--\n1\n",
+	} {
+		_, err := UnmarshalCompact(text)
+		assert.ErrorContains(t, err, "invalid grid size", name)
+	}
+
+	g, err := UnmarshalCompact("size: 3,2\n--\n")
+	require.NoError(t, err)
+	assert.Equal(t, 3, g.GetWidth())
+	assert.Equal(t, 2, g.GetHeight())
+}
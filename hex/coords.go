@@ -0,0 +1,66 @@
+package hex
+
+// Directions lists the six axial neighbor offsets, starting east and
+// proceeding counter-clockwise.
+var Directions = [6]Position{
+	{Q: 1, R: 0},
+	{Q: 1, R: -1},
+	{Q: 0, R: -1},
+	{Q: -1, R: 0},
+	{Q: -1, R: 1},
+	{Q: 0, R: 1},
+}
+
+// Add returns the component-wise sum of two positions.
+func (p Position) Add(o Position) Position {
+	return Position{Q: p.Q + o.Q, R: p.R + o.R}
+}
+
+// Sub returns the component-wise difference of two positions.
+func (p Position) Sub(o Position) Position {
+	return Position{Q: p.Q - o.Q, R: p.R - o.R}
+}
+
+// S returns the implicit third cube coordinate (q + r + s = 0).
+func (p Position) S() int {
+	return -p.Q - p.R
+}
+
+// Neighbor returns the adjacent position in the given direction (0-5).
+// Directions outside that range wrap around.
+func (p Position) Neighbor(direction int) Position {
+	return p.Add(Directions[((direction%6)+6)%6])
+}
+
+// Neighbors returns the six positions adjacent to p, in Directions order.
+func (p Position) Neighbors() []Position {
+	out := make([]Position, 6)
+	for i, d := range Directions {
+		out[i] = p.Add(d)
+	}
+	return out
+}
+
+// Distance returns the number of hex steps between p and o.
+func (p Position) Distance(o Position) int {
+	d := p.Sub(o)
+	return (abs(d.Q) + abs(d.R) + abs(d.S())) / 2
+}
+
+// Range returns every position within n steps of p, including p itself.
+func (p Position) Range(n int) []Position {
+	out := make([]Position, 0, 1+3*n*(n+1))
+	for dq := -n; dq <= n; dq++ {
+		for dr := max(-n, -dq-n); dr <= min(n, -dq+n); dr++ {
+			out = append(out, Position{Q: p.Q + dq, R: p.R + dr})
+		}
+	}
+	return out
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,55 @@
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPosition_Distance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Position
+		want int
+	}{
+		{"same position", Position{Q: 0, R: 0}, Position{Q: 0, R: 0}, 0},
+		{"adjacent east", Position{Q: 0, R: 0}, Position{Q: 1, R: 0}, 1},
+		{"adjacent north-east", Position{Q: 0, R: 0}, Position{Q: 1, R: -1}, 1},
+		{"two steps", Position{Q: 0, R: 0}, Position{Q: 2, R: -1}, 2},
+		{"diagonal in storage", Position{Q: 0, R: 0}, Position{Q: 3, R: 3}, 6},
+		{"negative coordinates", Position{Q: -2, R: 1}, Position{Q: 1, R: -1}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(tt.want, tt.a.Distance(tt.b))
+			assert.Equal(tt.want, tt.b.Distance(tt.a), "Distance should be symmetric")
+		})
+	}
+}
+
+func TestPosition_Neighbors(t *testing.T) {
+	assert := assert.New(t)
+	p := Position{Q: 2, R: 3}
+	neighbors := p.Neighbors()
+	assert.Len(neighbors, 6)
+	for i, n := range neighbors {
+		assert.Equal(1, p.Distance(n), "neighbor %d should be one step away", i)
+		assert.Equal(n, p.Neighbor(i), "Neighbor(%d) should match Neighbors()[%d]", i, i)
+	}
+	assert.Equal(p.Neighbor(0), p.Neighbor(6), "directions should wrap")
+	assert.Equal(p.Neighbor(5), p.Neighbor(-1), "negative directions should wrap")
+}
+
+func TestPosition_Range(t *testing.T) {
+	assert := assert.New(t)
+	center := Position{Q: 1, R: 1}
+	for n := 0; n <= 3; n++ {
+		positions := center.Range(n)
+		assert.Len(positions, 1+3*n*(n+1), "range %d size", n)
+		for _, p := range positions {
+			assert.LessOrEqual(center.Distance(p), n)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package hex
+
+import (
+	"fmt"
+	"slices"
+)
+
+// CellChange is a map position whose cell differs between two layers.
+// Old or New is nil where the before or after layer has no cell.
+type CellChange struct {
+	Position Position
+	Old      *int
+	New      *int
+}
+
+// Added reports whether the cell exists only in the after layer.
+func (c CellChange) Added() bool {
+	return c.Old == nil && c.New != nil
+}
+
+// Removed reports whether the cell exists only in the before layer.
+func (c CellChange) Removed() bool {
+	return c.Old != nil && c.New == nil
+}
+
+// LayerDiff lists the changed cells of a layer found in both maps, sorted
+// by R then Q.
+type LayerDiff struct {
+	Name    string
+	Changes []CellChange
+}
+
+// Positions returns the map positions of the changed cells.
+func (d LayerDiff) Positions() []Position {
+	positions := make([]Position, len(d.Changes))
+	for i, c := range d.Changes {
+		positions[i] = c.Position
+	}
+	return positions
+}
+
+// MapDiff describes how one map differs from another.
+type MapDiff struct {
+	// OldWidth, OldHeight and OldTopology describe the before map, and
+	// NewWidth, NewHeight and NewTopology the after map.
+	OldWidth, OldHeight, NewWidth, NewHeight int
+	OldTopology, NewTopology                 Topology
+	// Added and Removed name the layers found in only the after or before map.
+	Added   []string
+	Removed []string
+	// Layers holds the layers found in both maps that differ, in the order
+	// of the before map.
+	Layers []LayerDiff
+}
+
+// Resized reports whether the map dimensions differ.
+func (d *MapDiff) Resized() bool {
+	return d.OldWidth != d.NewWidth || d.OldHeight != d.NewHeight
+}
+
+// TopologyChanged reports whether the maps wrap differently.
+func (d *MapDiff) TopologyChanged() bool {
+	return d.OldTopology != d.NewTopology
+}
+
+// Empty reports whether the maps have the same size, topology, layers and cells.
+func (d *MapDiff) Empty() bool {
+	return !d.Resized() && !d.TopologyChanged() &&
+		len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Layers) == 0
+}
+
+// Layer returns the changes of the named layer, if it differs.
+func (d *MapDiff) Layer(name string) (LayerDiff, bool) {
+	for _, l := range d.Layers {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return LayerDiff{}, false
+}
+
+// DiffMaps compares the size and topology of two maps, their layers by name
+// and their cells by map position, so a layer that moved but kept its cells
+// in place is unchanged.
+func DiffMaps(before, after Map) (*MapDiff, error) {
+	if before == nil || after == nil {
+		return nil, fmt.Errorf("cannot diff nil maps")
+	}
+	d := &MapDiff{OldTopology: before.GetTopology(), NewTopology: after.GetTopology()}
+	d.OldWidth, d.OldHeight = before.GetDimensions()
+	d.NewWidth, d.NewHeight = after.GetDimensions()
+	for _, g := range after.GetGrids() {
+		if _, err := before.GetGridByName(g.GetName()); err != nil {
+			d.Added = append(d.Added, g.GetName())
+		}
+	}
+	for _, g := range before.GetGrids() {
+		other, err := after.GetGridByName(g.GetName())
+		if err != nil {
+			d.Removed = append(d.Removed, g.GetName())
+			continue
+		}
+		if changes := DiffGrids(g, other); len(changes) > 0 {
+			d.Layers = append(d.Layers, LayerDiff{Name: g.GetName(), Changes: changes})
+		}
+	}
+	return d, nil
+}
+
+// DiffGrids returns the cells that differ between two grids, by map position
+// and sorted by R then Q.
+func DiffGrids(before, after Grid) []CellChange {
+	changes := make(map[Position]*CellChange)
+	values := func(g Grid, set func(c *CellChange, v *int)) {
+		for i := 0; i < g.GetCellCount(); i++ {
+			cell, _ := g.GetCellAtIndex(i)
+			if cell == nil {
+				continue
+			}
+			pos := cell.GetPosition().Add(g.GetPosition())
+			c, ok := changes[pos]
+			if !ok {
+				c = &CellChange{Position: pos}
+				changes[pos] = c
+			}
+			v := cell.GetValue()
+			set(c, &v)
+		}
+	}
+	values(before, func(c *CellChange, v *int) { c.Old = v })
+	values(after, func(c *CellChange, v *int) { c.New = v })
+
+	var out []CellChange
+	for _, c := range changes {
+		if c.Old == nil || c.New == nil || *c.Old != *c.New {
+			out = append(out, *c)
+		}
+	}
+	slices.SortFunc(out, func(a, b CellChange) int {
+		if a.Position.R != b.Position.R {
+			return a.Position.R - b.Position.R
+		}
+		return a.Position.Q - b.Position.Q
+	})
+	return out
+}
@@ -0,0 +1,96 @@
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compactMap(t *testing.T, layers ...string) Map {
+	t.Helper()
+	m := NewMap(4, 3)
+	for _, text := range layers {
+		g, err := UnmarshalCompact(text)
+		require.NoError(t, err)
+		require.NoError(t, m.AddGrid(g))
+	}
+	return m
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestDiffMaps(t *testing.T) {
+	before := compactMap(t,
+		"name: terrain\n--\n0 1 1 0\n 1 1 0 0\n",
+		"name: owner\n--\n1 1\n",
+		"name: rivers\n--\n1\n",
+	)
+	after := compactMap(t,
+		"name: terrain\n--\n0 1 0 0\n 1 1 0\n",
+		"name: owner\n--\n1 1\n",
+		"name: roads\n--\n1\n",
+	)
+
+	d, err := DiffMaps(before, after)
+	require.NoError(t, err)
+	assert.False(t, d.Empty())
+	assert.Equal(t, []string{"roads"}, d.Added)
+	assert.Equal(t, []string{"rivers"}, d.Removed)
+	require.Len(t, d.Layers, 1)
+	assert.Equal(t, "terrain", d.Layers[0].Name)
+	assert.Equal(t, []CellChange{
+		{Position: NewPosition(2, 0), Old: intPtr(1), New: intPtr(0)},
+		{Position: NewPosition(3, 1), Old: intPtr(0)},
+	}, d.Layers[0].Changes)
+	assert.True(t, d.Layers[0].Changes[1].Removed())
+	assert.Equal(t, []Position{NewPosition(2, 0), NewPosition(3, 1)}, d.Layers[0].Positions())
+
+	_, ok := d.Layer("owner")
+	assert.False(t, ok)
+	_, ok = d.Layer("terrain")
+	assert.True(t, ok)
+
+	d, err = DiffMaps(after, before)
+	require.NoError(t, err)
+	assert.True(t, d.Layers[0].Changes[1].Added())
+
+	_, err = DiffMaps(before, nil)
+	assert.Error(t, err)
+}
+
+func TestDiffMaps_Identical(t *testing.T) {
+	m := compactMap(t, "name: terrain\n--\n0 1\n 1 0\n")
+	d, err := DiffMaps(m, compactMap(t, "name: terrain\n--\n0 1\n 1 0\n"))
+	require.NoError(t, err)
+	assert.True(t, d.Empty())
+}
+
+func TestDiffMaps_SizeAndTopology(t *testing.T) {
+	before := compactMap(t, "name: terrain\n--\n0 1\n 1 0\n")
+	after := NewMap(5, 3)
+	after.SetTopology(Torus)
+	for _, g := range before.GetGrids() {
+		require.NoError(t, after.AddGrid(g))
+	}
+
+	d, err := DiffMaps(before, after)
+	require.NoError(t, err)
+	assert.False(t, d.Empty())
+	assert.True(t, d.Resized())
+	assert.True(t, d.TopologyChanged())
+	assert.Equal(t, []int{4, 3, 5, 3}, []int{d.OldWidth, d.OldHeight, d.NewWidth, d.NewHeight})
+	assert.Equal(t, Flat, d.OldTopology)
+	assert.Equal(t, Torus, d.NewTopology)
+	assert.Empty(t, d.Layers)
+}
+
+func TestDiffGrids_ComparesMapPositions(t *testing.T) {
+	// The same cells stored in a grid moved by one column are unchanged.
+	a := NewGrid(NewPosition(0, 0), "terrain", 2, 1, [][]Cell{{NewCellWithValue(0, 0, 1), NewCellWithValue(1, 0, 2)}})
+	b := NewGrid(NewPosition(1, 0), "terrain", 2, 1, [][]Cell{{NewCellWithValue(0, 0, 2), NewCellWithValue(1, 0, 3)}})
+	assert.Equal(t, []CellChange{
+		{Position: NewPosition(0, 0), Old: intPtr(1)},
+		{Position: NewPosition(2, 0), New: intPtr(3)},
+	}, DiffGrids(a, b))
+}
@@ -27,6 +27,21 @@ type Grid interface {
 	// CopyCellsTo copies the grid's cells into the destination slice.
 	// It returns an error if the destination slice has incorrect dimensions.
 	CopyCellsTo(destination [][]Cell) error
+	// GetTopology returns how the grid's edges connect.
+	GetTopology() Topology
+	// SetTopology changes how the grid's edges connect.
+	SetTopology(t Topology)
+	// Normalize wraps a position into the grid along its wrapping axes.
+	// It reports false if the position is outside a non-wrapping edge.
+	Normalize(pos Position) (Position, bool)
+	// Distance returns the fewest steps between two positions, crossing seams if shorter.
+	Distance(a, b Position) int
+	// Neighbors returns the normalized in-bounds positions adjacent to pos.
+	// Positions may refer to nil cells.
+	Neighbors(pos Position) []Position
+	// Range returns the normalized in-bounds positions within n steps of pos.
+	// Positions may refer to nil cells.
+	Range(pos Position, n int) []Position
 }
 
 // concreteGrid implements the Grid interface.
@@ -36,6 +51,7 @@ type concreteGrid struct {
 	width    int
 	height   int
 	cells    [][]Cell
+	topology Topology
 }
 
 func (g *concreteGrid) GetPosition() Position {
@@ -45,13 +61,14 @@ func (g *concreteGrid) GetName() string {
 	return g.name
 }
 func (g *concreteGrid) GetCellAt(q, r int) (Cell, error) {
-	if q < 0 || r < 0 || q >= g.width || r >= g.height {
+	pos, ok := g.wrapper().normalize(Position{Q: q, R: r})
+	if !ok {
 		return nil, fmt.Errorf("cell at (%d, %d) is out of bounds", q, r)
 	}
-	return g.cells[r][q], nil
+	return g.cells[pos.R][pos.Q], nil
 }
 func (g *concreteGrid) GetCellAtPosition(pos Position) (Cell, error) {
-	if pos.Q < 0 || pos.R < 0 || pos.Q >= g.width || pos.R >= g.height {
+	if _, ok := g.wrapper().normalize(pos); !ok {
 		return nil, fmt.Errorf("cell at position %s is out of bounds", pos)
 	}
 	return g.GetCellAt(pos.Q, pos.R)
@@ -99,6 +116,29 @@ func (g *concreteGrid) CopyCellsTo(destination [][]Cell) error {
 	return nil
 }
 
+func (g *concreteGrid) GetTopology() Topology {
+	return g.topology
+}
+func (g *concreteGrid) SetTopology(t Topology) {
+	g.topology = t
+}
+func (g *concreteGrid) Normalize(pos Position) (Position, bool) {
+	return g.wrapper().normalize(pos)
+}
+func (g *concreteGrid) Distance(a, b Position) int {
+	return g.wrapper().distance(a, b)
+}
+func (g *concreteGrid) Neighbors(pos Position) []Position {
+	return g.wrapper().neighbors(pos)
+}
+func (g *concreteGrid) Range(pos Position, n int) []Position {
+	return g.wrapper().rangeOf(pos, n)
+}
+
+func (g *concreteGrid) wrapper() wrapper {
+	return wrapper{topology: g.topology, width: g.width, height: g.height}
+}
+
 // NewGrid creates a new Grid with the specified position, name, width, height, and cells.
 func NewGrid(position Position, name string, width, height int, cells [][]Cell) Grid {
 	return &concreteGrid{
@@ -9,8 +9,8 @@ import (
 // Position represents a coordinate on the hex grid.
 type Position struct {
 	// Q and R are the axial coordinates of the hexagonal grid.
-	Q int
-	R int
+	Q int `json:"q"`
+	R int `json:"r"`
 }
 
 // --- Implementations ---
@@ -0,0 +1,183 @@
+package hex
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FormatVersion is the version written into saved maps, both JSON and binary.
+// Decoding accepts every version up to and including this one.
+const FormatVersion = 1
+
+// mapDocument is the saved form of a Map, shared by the JSON and binary formats.
+type mapDocument struct {
+	Version  int            `json:"version"`
+	Width    int            `json:"width"`
+	Height   int            `json:"height"`
+	Topology string         `json:"topology"`
+	Layers   []gridDocument `json:"layers"`
+}
+
+// gridDocument is the saved form of a Grid. Cells are stored row by row as their
+// values, with null for nil cells; cell positions are rebuilt from indices.
+type gridDocument struct {
+	Name     string   `json:"name"`
+	Position Position `json:"position"`
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
+	Topology string   `json:"topology"`
+	Cells    [][]*int `json:"cells"`
+}
+
+// formatMigrations upgrade a decoded document from the version at their index
+// to the next one. Index 0 upgrades documents written before versioning.
+var formatMigrations = []func(*mapDocument) error{
+	func(doc *mapDocument) error {
+		if doc.Topology == "" {
+			doc.Topology = Flat.String()
+		}
+		return nil
+	},
+}
+
+// MarshalMap encodes a Map and all of its layers as JSON.
+func MarshalMap(m Map) ([]byte, error) {
+	if m == nil {
+		return nil, fmt.Errorf("cannot marshal nil map")
+	}
+	return json.Marshal(toMapDocument(m))
+}
+
+// UnmarshalMap decodes a Map written by MarshalMap, upgrading older versions.
+func UnmarshalMap(data []byte) (Map, error) {
+	var doc mapDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode map: %w", err)
+	}
+	return fromMapDocument(&doc)
+}
+
+// MarshalGrid encodes a single Grid as JSON.
+func MarshalGrid(g Grid) ([]byte, error) {
+	if g == nil {
+		return nil, fmt.Errorf("cannot marshal nil grid")
+	}
+	return json.Marshal(toGridDocument(g))
+}
+
+// UnmarshalGrid decodes a Grid written by MarshalGrid.
+func UnmarshalGrid(data []byte) (Grid, error) {
+	var doc gridDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode grid: %w", err)
+	}
+	return fromGridDocument(&doc)
+}
+
+// MarshalJSON implements json.Marshaler for concreteMap.
+func (m *concreteMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toMapDocument(m))
+}
+
+// MarshalJSON implements json.Marshaler for concreteGrid.
+func (g *concreteGrid) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toGridDocument(g))
+}
+
+func toMapDocument(m Map) *mapDocument {
+	width, height := m.GetDimensions()
+	doc := &mapDocument{
+		Version:  FormatVersion,
+		Width:    width,
+		Height:   height,
+		Topology: m.GetTopology().String(),
+		Layers:   make([]gridDocument, 0, len(m.GetGrids())),
+	}
+	for _, g := range m.GetGrids() {
+		doc.Layers = append(doc.Layers, *toGridDocument(g))
+	}
+	return doc
+}
+
+func toGridDocument(g Grid) *gridDocument {
+	doc := &gridDocument{
+		Name:     g.GetName(),
+		Position: g.GetPosition(),
+		Width:    g.GetWidth(),
+		Height:   g.GetHeight(),
+		Topology: g.GetTopology().String(),
+		Cells:    make([][]*int, g.GetHeight()),
+	}
+	for r := range doc.Cells {
+		doc.Cells[r] = make([]*int, g.GetWidth())
+		for q := range doc.Cells[r] {
+			if cell, err := g.GetCellAt(q, r); err == nil && cell != nil {
+				v := cell.GetValue()
+				doc.Cells[r][q] = &v
+			}
+		}
+	}
+	return doc
+}
+
+func fromMapDocument(doc *mapDocument) (Map, error) {
+	if doc.Version < 0 {
+		return nil, fmt.Errorf("invalid map format version %d", doc.Version)
+	}
+	if doc.Version > FormatVersion {
+		return nil, fmt.Errorf("map format version %d is newer than supported version %d", doc.Version, FormatVersion)
+	}
+	for v := doc.Version; v < FormatVersion; v++ {
+		if err := formatMigrations[v](doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate map from version %d: %w", v, err)
+		}
+	}
+	topology, err := ParseTopology(doc.Topology)
+	if err != nil {
+		return nil, err
+	}
+	m := NewMap(doc.Width, doc.Height)
+	m.SetTopology(topology) // layers adopt the map topology as they are added
+	for i := range doc.Layers {
+		g, err := fromGridDocument(&doc.Layers[i])
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		if err := m.AddGrid(g); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func fromGridDocument(doc *gridDocument) (Grid, error) {
+	if doc.Width < 0 || doc.Height < 0 {
+		return nil, fmt.Errorf("grid %s has negative dimensions %dx%d", doc.Name, doc.Width, doc.Height)
+	}
+	if len(doc.Cells) != doc.Height {
+		return nil, fmt.Errorf("grid %s has %d rows, expected %d", doc.Name, len(doc.Cells), doc.Height)
+	}
+	topology := Flat
+	if doc.Topology != "" {
+		t, err := ParseTopology(doc.Topology)
+		if err != nil {
+			return nil, err
+		}
+		topology = t
+	}
+	cells := make([][]Cell, doc.Height)
+	for r, row := range doc.Cells {
+		if len(row) != doc.Width {
+			return nil, fmt.Errorf("grid %s row %d has %d columns, expected %d", doc.Name, r, len(row), doc.Width)
+		}
+		cells[r] = make([]Cell, doc.Width)
+		for q, v := range row {
+			if v != nil {
+				cells[r][q] = NewCellWithValue(q, r, *v)
+			}
+		}
+	}
+	g := NewGrid(doc.Position, doc.Name, doc.Width, doc.Height, cells)
+	g.SetTopology(topology)
+	return g, nil
+}
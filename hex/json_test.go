@@ -0,0 +1,126 @@
+package hex
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSparseGrid returns a grid with values q*10+r and a nil cell at (1, 0).
+func newSparseGrid(name string, pos Position) Grid {
+	cells := [][]Cell{
+		{NewCellWithValue(0, 0, 0), nil, NewCellWithValue(2, 0, 20)},
+		{NewCellWithValue(0, 1, 1), NewCellWithValue(1, 1, 11), NewCellWithValue(2, 1, 21)},
+	}
+	return NewGrid(pos, name, 3, 2, cells)
+}
+
+func assertGridsEqual(t *testing.T, want, got Grid) {
+	assert := assert.New(t)
+	assert.Equal(want.GetName(), got.GetName())
+	assert.Equal(want.GetPosition(), got.GetPosition())
+	assert.Equal(want.GetWidth(), got.GetWidth())
+	assert.Equal(want.GetHeight(), got.GetHeight())
+	assert.Equal(want.GetTopology(), got.GetTopology())
+	for i := 0; i < want.GetCellCount(); i++ {
+		wc, _ := want.GetCellAtIndex(i)
+		gc, _ := got.GetCellAtIndex(i)
+		if wc == nil {
+			assert.Nil(gc, "cell %d should be nil", i)
+			continue
+		}
+		require.NotNil(t, gc, "cell %d should not be nil", i)
+		assert.Equal(wc.GetPosition(), gc.GetPosition())
+		assert.Equal(wc.GetValue(), gc.GetValue())
+	}
+}
+
+func TestPosition_JSON(t *testing.T) {
+	data, err := json.Marshal(Position{Q: 3, R: -2})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"q":3,"r":-2}`, string(data))
+}
+
+func TestMarshalGrid_RoundTrip(t *testing.T) {
+	grid := newSparseGrid("terrain", Position{Q: 4, R: 5})
+	grid.SetTopology(Cylinder)
+
+	data, err := MarshalGrid(grid)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"terrain","position":{"q":4,"r":5},"width":3,"height":2,"topology":"cylinder","cells":[[0,null,20],[1,11,21]]}`, string(data))
+
+	decoded, err := UnmarshalGrid(data)
+	require.NoError(t, err)
+	assertGridsEqual(t, grid, decoded)
+}
+
+func TestMarshalMap_RoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMap(3, 2)
+	require.NoError(t, m.AddGrid(newSparseGrid("terrain", Position{})))
+	require.NoError(t, m.AddGrid(newSparseGrid("resources", Position{Q: 1, R: 1})))
+	m.SetTopology(Torus)
+
+	data, err := MarshalMap(m)
+	require.NoError(t, err)
+	viaInterface, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(string(data), string(viaInterface), "json.Marshal should use the same format")
+
+	decoded, err := UnmarshalMap(data)
+	require.NoError(t, err)
+	w, h := decoded.GetDimensions()
+	assert.Equal(3, w)
+	assert.Equal(2, h)
+	assert.Equal(Torus, decoded.GetTopology())
+	require.Len(t, decoded.GetGrids(), 2)
+	for i, g := range m.GetGrids() {
+		assertGridsEqual(t, g, decoded.GetGrids()[i])
+	}
+}
+
+func TestUnmarshalMap_Versions(t *testing.T) {
+	assert := assert.New(t)
+
+	// Files written before the version and topology fields existed still load.
+	legacy := `{"width":1,"height":1,"layers":[{"name":"a","position":{"q":0,"r":0},"width":1,"height":1,"cells":[[5]]}]}`
+	m, err := UnmarshalMap([]byte(legacy))
+	require.NoError(t, err)
+	assert.Equal(Flat, m.GetTopology())
+	g, err := m.GetGridByName("a")
+	require.NoError(t, err)
+	cell, _ := g.GetCellAt(0, 0)
+	assert.Equal(5, cell.GetValue())
+
+	_, err = UnmarshalMap([]byte(`{"version":99,"width":1,"height":1,"layers":[]}`))
+	assert.ErrorContains(err, "newer than supported")
+	_, err = UnmarshalMap([]byte(`{"version":-1,"width":1,"height":1,"layers":[]}`))
+	assert.ErrorContains(err, "invalid map format version -1")
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"bad topology", `{"version":1,"width":1,"height":1,"topology":"sphere","layers":[]}`},
+		{"row count", `{"version":1,"width":1,"height":1,"layers":[{"name":"a","width":1,"height":2,"cells":[[1]]}]}`},
+		{"column count", `{"version":1,"width":1,"height":1,"layers":[{"name":"a","width":2,"height":1,"cells":[[1]]}]}`},
+		{"negative size", `{"version":1,"width":1,"height":1,"layers":[{"name":"a","width":-1,"height":0,"cells":[]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalMap([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+	_, err := UnmarshalGrid([]byte(`[]`))
+	assert.Error(t, err)
+	_, err = MarshalMap(nil)
+	assert.Error(t, err)
+	_, err = MarshalGrid(nil)
+	assert.Error(t, err)
+}
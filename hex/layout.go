@@ -0,0 +1,100 @@
+package hex
+
+import "math"
+
+// Point is a location in pixel space.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Layout converts between axial positions and pixel coordinates for drawing.
+type Layout struct {
+	Orientation Orientation
+	// Size is the distance from a hex's center to its corners.
+	Size float64
+	// Origin is the pixel center of Position{0, 0}.
+	Origin Point
+}
+
+// NewLayout creates a Layout with hexes of the given size centered on the origin.
+func NewLayout(orientation Orientation, size float64) Layout {
+	return Layout{Orientation: orientation, Size: size}
+}
+
+// HexToPixel returns the pixel center of a position.
+func (l Layout) HexToPixel(p Position) Point {
+	q, r := float64(p.Q), float64(p.R)
+	if l.Orientation == FlatTop {
+		return Point{
+			X: l.Origin.X + l.Size*1.5*q,
+			Y: l.Origin.Y + l.Size*math.Sqrt(3)*(r+q/2),
+		}
+	}
+	return Point{
+		X: l.Origin.X + l.Size*math.Sqrt(3)*(q+r/2),
+		Y: l.Origin.Y + l.Size*1.5*r,
+	}
+}
+
+// PixelToHex returns the position whose hex contains the pixel.
+func (l Layout) PixelToHex(pt Point) Position {
+	x, y := (pt.X-l.Origin.X)/l.Size, (pt.Y-l.Origin.Y)/l.Size
+	var q, r float64
+	if l.Orientation == FlatTop {
+		q = x * 2 / 3
+		r = -x/3 + y/math.Sqrt(3)
+	} else {
+		q = x/math.Sqrt(3) - y/3
+		r = y * 2 / 3
+	}
+	return roundAxial(q, r)
+}
+
+// Corners returns the six corners of a position's hex, clockwise from the
+// east (flat) or north-east (pointy) corner.
+func (l Layout) Corners(p Position) [6]Point {
+	center := l.HexToPixel(p)
+	var corners [6]Point
+	for i := range corners {
+		angle := math.Pi / 3 * float64(i)
+		if l.Orientation == PointyTop {
+			angle -= math.Pi / 6
+		}
+		corners[i] = Point{
+			X: center.X + l.Size*math.Cos(angle),
+			Y: center.Y + l.Size*math.Sin(angle),
+		}
+	}
+	return corners
+}
+
+// Bounds returns the top-left and bottom-right pixel corners of the box
+// enclosing the hexes of all positions.
+func (l Layout) Bounds(positions []Position) (Point, Point) {
+	if len(positions) == 0 {
+		return l.Origin, l.Origin
+	}
+	lo := Point{X: math.Inf(1), Y: math.Inf(1)}
+	hi := Point{X: math.Inf(-1), Y: math.Inf(-1)}
+	for _, p := range positions {
+		for _, c := range l.Corners(p) {
+			lo.X, lo.Y = math.Min(lo.X, c.X), math.Min(lo.Y, c.Y)
+			hi.X, hi.Y = math.Max(hi.X, c.X), math.Max(hi.Y, c.Y)
+		}
+	}
+	return lo, hi
+}
+
+// roundAxial rounds fractional axial coordinates to the nearest position.
+func roundAxial(q, r float64) Position {
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return Position{Q: int(rq), R: int(rr)}
+}
@@ -0,0 +1,74 @@
+package hex
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout_HexToPixel(t *testing.T) {
+	pointy := NewLayout(PointyTop, 10)
+	p := pointy.HexToPixel(NewPosition(1, 0))
+	assert.InDelta(t, 10*math.Sqrt(3), p.X, 1e-9)
+	assert.InDelta(t, 0, p.Y, 1e-9)
+	p = pointy.HexToPixel(NewPosition(0, 1))
+	assert.InDelta(t, 5*math.Sqrt(3), p.X, 1e-9)
+	assert.InDelta(t, 15, p.Y, 1e-9)
+
+	flat := NewLayout(FlatTop, 10)
+	p = flat.HexToPixel(NewPosition(1, 0))
+	assert.InDelta(t, 15, p.X, 1e-9)
+	assert.InDelta(t, 5*math.Sqrt(3), p.Y, 1e-9)
+
+	flat.Origin = Point{X: 100, Y: 50}
+	assert.Equal(t, Point{X: 100, Y: 50}, flat.HexToPixel(NewPosition(0, 0)))
+}
+
+func TestLayout_PixelToHex(t *testing.T) {
+	for _, o := range []Orientation{PointyTop, FlatTop} {
+		l := Layout{Orientation: o, Size: 12, Origin: Point{X: 7, Y: -3}}
+		for _, p := range NewPosition(0, 0).Range(4) {
+			center := l.HexToPixel(p)
+			assert.Equal(t, p, l.PixelToHex(center), "%s center of %s", o, p)
+			// Points well inside the hex map back to it too.
+			for _, c := range l.Corners(p) {
+				inside := Point{X: center.X + (c.X-center.X)*0.9, Y: center.Y + (c.Y-center.Y)*0.9}
+				assert.Equal(t, p, l.PixelToHex(inside), "%s corner of %s", o, p)
+			}
+		}
+	}
+}
+
+func TestLayout_Corners(t *testing.T) {
+	pointy := NewLayout(PointyTop, 10)
+	corners := pointy.Corners(NewPosition(0, 0))
+	// Pointy hexes have a corner straight above and below the center.
+	assert.InDelta(t, 0, corners[5].X, 1e-9)
+	assert.InDelta(t, -10, corners[5].Y, 1e-9)
+	assert.InDelta(t, 10, corners[2].Y, 1e-9)
+
+	flat := NewLayout(FlatTop, 10)
+	corners = flat.Corners(NewPosition(0, 0))
+	// Flat hexes have a corner straight left and right of the center.
+	assert.InDelta(t, 10, corners[0].X, 1e-9)
+	assert.InDelta(t, -10, corners[3].X, 1e-9)
+
+	// Neighboring hexes share an edge.
+	a := pointy.Corners(NewPosition(0, 0))
+	b := pointy.Corners(NewPosition(1, 0))
+	assert.InDelta(t, a[0].X, b[4].X, 1e-9)
+	assert.InDelta(t, a[0].Y, b[4].Y, 1e-9)
+}
+
+func TestLayout_Bounds(t *testing.T) {
+	l := NewLayout(FlatTop, 10)
+	lo, hi := l.Bounds([]Position{NewPosition(0, 0)})
+	assert.InDelta(t, -10, lo.X, 1e-9)
+	assert.InDelta(t, 10, hi.X, 1e-9)
+	assert.InDelta(t, -5*math.Sqrt(3), lo.Y, 1e-9)
+	assert.InDelta(t, 5*math.Sqrt(3), hi.Y, 1e-9)
+
+	lo, hi = l.Bounds(nil)
+	assert.Equal(t, lo, hi)
+}
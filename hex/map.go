@@ -16,13 +16,28 @@ type Map interface {
 	RemoveGridByIndex(index int) error
 	// AddLayer adds a new layer to the map.
 	AddLayer(f GenerateGridFunc) error
+	// GetTopology returns how the map's edges connect.
+	GetTopology() Topology
+	// SetTopology changes how the map's edges connect. The topology is applied
+	// to every layer already on the map and to layers added later.
+	SetTopology(t Topology)
+	// Normalize wraps a position into the map along its wrapping axes.
+	// It reports false if the position is outside a non-wrapping edge.
+	Normalize(pos Position) (Position, bool)
+	// Distance returns the fewest steps between two positions, crossing seams if shorter.
+	Distance(a, b Position) int
+	// Neighbors returns the normalized in-bounds positions adjacent to pos.
+	Neighbors(pos Position) []Position
+	// Range returns the normalized in-bounds positions within n steps of pos.
+	Range(pos Position, n int) []Position
 }
 
 // concreteMap implements the Map interface.
 type concreteMap struct {
-	width  int
-	height int
-	grids  []Grid
+	width    int
+	height   int
+	grids    []Grid
+	topology Topology
 }
 
 func (m *concreteMap) GetDimensions() (int, int) {
@@ -53,6 +68,7 @@ func (m *concreteMap) AddGrid(grid Grid) error {
 	if grid == nil {
 		return fmt.Errorf("cannot add nil grid")
 	}
+	grid.SetTopology(m.topology)
 	m.grids = append(m.grids, grid)
 	return nil
 }
@@ -75,6 +91,37 @@ func (m *concreteMap) RemoveGridByIndex(index int) error {
 	return nil
 }
 
+func (m *concreteMap) GetTopology() Topology {
+	return m.topology
+}
+
+func (m *concreteMap) SetTopology(t Topology) {
+	m.topology = t
+	for _, grid := range m.grids {
+		grid.SetTopology(t)
+	}
+}
+
+func (m *concreteMap) Normalize(pos Position) (Position, bool) {
+	return m.wrapper().normalize(pos)
+}
+
+func (m *concreteMap) Distance(a, b Position) int {
+	return m.wrapper().distance(a, b)
+}
+
+func (m *concreteMap) Neighbors(pos Position) []Position {
+	return m.wrapper().neighbors(pos)
+}
+
+func (m *concreteMap) Range(pos Position, n int) []Position {
+	return m.wrapper().rangeOf(pos, n)
+}
+
+func (m *concreteMap) wrapper() wrapper {
+	return wrapper{topology: m.topology, width: m.width, height: m.height}
+}
+
 // NewMap creates a new Map with the specified dimensions.
 func NewMap(width, height int) Map {
 	return &concreteMap{
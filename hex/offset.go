@@ -0,0 +1,90 @@
+package hex
+
+import "fmt"
+
+// Orientation is the way hexagons are drawn.
+type Orientation int
+
+const (
+	// PointyTop hexagons have a vertex at the top; rows are horizontal.
+	PointyTop Orientation = iota
+	// FlatTop hexagons have an edge at the top; columns are vertical.
+	FlatTop
+)
+
+// String implements the Stringer interface for Orientation.
+func (o Orientation) String() string {
+	switch o {
+	case PointyTop:
+		return "pointy"
+	case FlatTop:
+		return "flat"
+	}
+	return fmt.Sprintf("Orientation(%d)", int(o))
+}
+
+// Parity selects which rows (pointy) or columns (flat) of an offset layout
+// are shoved by half a hex.
+type Parity int
+
+const (
+	// Odd shoves odd rows right (pointy) or odd columns down (flat).
+	Odd Parity = iota
+	// Even shoves even rows right (pointy) or even columns down (flat).
+	Even
+)
+
+// String implements the Stringer interface for Parity.
+func (p Parity) String() string {
+	switch p {
+	case Odd:
+		return "odd"
+	case Even:
+		return "even"
+	}
+	return fmt.Sprintf("Parity(%d)", int(p))
+}
+
+// OffsetCoord is a column/row coordinate in an offset layout.
+type OffsetCoord struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+// OffsetLayout converts between axial positions and one of the four offset
+// layouts: odd-r, even-r (pointy) and odd-q, even-q (flat).
+type OffsetLayout struct {
+	Orientation Orientation
+	Parity      Parity
+}
+
+// ToOffset converts an axial position to offset coordinates.
+func (l OffsetLayout) ToOffset(p Position) OffsetCoord {
+	if l.Orientation == FlatTop {
+		return OffsetCoord{Col: p.Q, Row: p.R + l.shift(p.Q)}
+	}
+	return OffsetCoord{Col: p.Q + l.shift(p.R), Row: p.R}
+}
+
+// FromOffset converts offset coordinates to an axial position.
+func (l OffsetLayout) FromOffset(o OffsetCoord) Position {
+	if l.Orientation == FlatTop {
+		return Position{Q: o.Col, R: o.Row - l.shift(o.Col)}
+	}
+	return Position{Q: o.Col - l.shift(o.Row), R: o.Row}
+}
+
+// Shoved reports whether the given row (pointy) or column (flat) is offset by
+// half a hex.
+func (l OffsetLayout) Shoved(line int) bool {
+	odd := line&1 == 1
+	return odd == (l.Parity == Odd)
+}
+
+// shift is the offset between axial and offset coordinates along a line.
+func (l OffsetLayout) shift(line int) int {
+	if l.Parity == Even {
+		return (line + line&1) / 2
+	}
+	return (line - line&1) / 2
+}
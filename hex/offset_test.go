@@ -0,0 +1,60 @@
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetLayout_KnownValues(t *testing.T) {
+	// Values from the offset coordinate tables at redblobgames.com/grids/hexagons.
+	tests := []struct {
+		name   string
+		layout OffsetLayout
+		pos    Position
+		want   OffsetCoord
+	}{
+		{"odd-r", OffsetLayout{PointyTop, Odd}, Position{Q: -1, R: 3}, OffsetCoord{Col: 0, Row: 3}},
+		{"even-r", OffsetLayout{PointyTop, Even}, Position{Q: -2, R: 3}, OffsetCoord{Col: 0, Row: 3}},
+		{"odd-q", OffsetLayout{FlatTop, Odd}, Position{Q: 3, R: -1}, OffsetCoord{Col: 3, Row: 0}},
+		{"even-q", OffsetLayout{FlatTop, Even}, Position{Q: 3, R: -2}, OffsetCoord{Col: 3, Row: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.layout.ToOffset(tt.pos))
+			assert.Equal(t, tt.pos, tt.layout.FromOffset(tt.want))
+		})
+	}
+}
+
+func TestOffsetLayout_RoundTrip(t *testing.T) {
+	for _, layout := range []OffsetLayout{{PointyTop, Odd}, {PointyTop, Even}, {FlatTop, Odd}, {FlatTop, Even}} {
+		for q := -5; q <= 5; q++ {
+			for r := -5; r <= 5; r++ {
+				p := Position{Q: q, R: r}
+				assert.Equal(t, p, layout.FromOffset(layout.ToOffset(p)), "%v/%v %s", layout.Orientation, layout.Parity, p)
+			}
+		}
+	}
+}
+
+func TestOffsetLayout_Shoved(t *testing.T) {
+	assert := assert.New(t)
+	odd := OffsetLayout{PointyTop, Odd}
+	even := OffsetLayout{PointyTop, Even}
+	assert.True(odd.Shoved(1))
+	assert.True(odd.Shoved(-1))
+	assert.False(odd.Shoved(2))
+	assert.True(even.Shoved(0))
+	assert.False(even.Shoved(3))
+}
+
+func TestOrientationAndParity_String(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("pointy", PointyTop.String())
+	assert.Equal("flat", FlatTop.String())
+	assert.Equal("Orientation(5)", Orientation(5).String())
+	assert.Equal("odd", Odd.String())
+	assert.Equal("even", Even.String())
+	assert.Equal("Parity(5)", Parity(5).String())
+}
@@ -0,0 +1,107 @@
+package hex
+
+import "fmt"
+
+// Topology describes how the edges of a grid or map connect.
+type Topology int
+
+const (
+	// Flat grids end at their edges.
+	Flat Topology = iota
+	// Cylinder grids wrap east-west: column q = width is column 0 again.
+	Cylinder
+	// Torus grids wrap both east-west and north-south.
+	Torus
+)
+
+// String implements the Stringer interface for Topology.
+func (t Topology) String() string {
+	switch t {
+	case Flat:
+		return "flat"
+	case Cylinder:
+		return "cylinder"
+	case Torus:
+		return "torus"
+	}
+	return fmt.Sprintf("Topology(%d)", int(t))
+}
+
+// ParseTopology converts a topology name as returned by String back to a Topology.
+func ParseTopology(name string) (Topology, error) {
+	for _, t := range []Topology{Flat, Cylinder, Torus} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return Flat, fmt.Errorf("unknown topology %q", name)
+}
+
+// wrapper applies a topology to a width x height area anchored at (0, 0).
+// Grids and maps are parallelograms in axial coordinates, so shifting by the
+// width along q or by the height along r lands on the same cell again.
+type wrapper struct {
+	topology Topology
+	width    int
+	height   int
+}
+
+// normalize folds p into the area along the wrapping axes. It reports false
+// if p lies outside the area along an axis that does not wrap.
+func (w wrapper) normalize(p Position) (Position, bool) {
+	if w.width <= 0 || w.height <= 0 {
+		return p, false
+	}
+	if w.topology == Cylinder || w.topology == Torus {
+		p.Q = ((p.Q % w.width) + w.width) % w.width
+	}
+	if w.topology == Torus {
+		p.R = ((p.R % w.height) + w.height) % w.height
+	}
+	return p, p.Q >= 0 && p.R >= 0 && p.Q < w.width && p.R < w.height
+}
+
+// distance returns the fewest steps between a and b, crossing seams if shorter.
+// Both are folded into the area first, so one shift covers any seam.
+func (w wrapper) distance(a, b Position) int {
+	a, _ = w.normalize(a)
+	b, _ = w.normalize(b)
+	best := a.Distance(b)
+	qShifts, rShifts := []int{0}, []int{0}
+	if w.topology == Cylinder || w.topology == Torus {
+		qShifts = []int{-w.width, 0, w.width}
+	}
+	if w.topology == Torus {
+		rShifts = []int{-w.height, 0, w.height}
+	}
+	for _, dq := range qShifts {
+		for _, dr := range rShifts {
+			best = min(best, a.Distance(Position{Q: b.Q + dq, R: b.R + dr}))
+		}
+	}
+	return best
+}
+
+// neighbors returns the normalized positions adjacent to p that lie in the area.
+func (w wrapper) neighbors(p Position) []Position {
+	return w.collect(p.Neighbors())
+}
+
+// rangeOf returns the normalized positions within n steps of p that lie in the area.
+func (w wrapper) rangeOf(p Position, n int) []Position {
+	return w.collect(p.Range(n))
+}
+
+// collect normalizes positions, dropping those outside the area and any
+// duplicates produced when a range is wider than the wrapped area.
+func (w wrapper) collect(positions []Position) []Position {
+	out := make([]Position, 0, len(positions))
+	seen := make(map[Position]bool, len(positions))
+	for _, p := range positions {
+		if n, ok := w.normalize(p); ok && !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
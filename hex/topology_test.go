@@ -0,0 +1,148 @@
+package hex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFilledGrid returns a width x height grid with a cell at every position.
+func newFilledGrid(width, height int) Grid {
+	cells := make([][]Cell, height)
+	for r := range cells {
+		cells[r] = make([]Cell, width)
+		for q := range cells[r] {
+			cells[r][q] = NewCell(q, r)
+		}
+	}
+	return NewGrid(Position{}, "filled", width, height, cells)
+}
+
+func TestTopology_StringAndParse(t *testing.T) {
+	assert := assert.New(t)
+	for _, topo := range []Topology{Flat, Cylinder, Torus} {
+		parsed, err := ParseTopology(topo.String())
+		assert.NoError(err)
+		assert.Equal(topo, parsed)
+	}
+	assert.Equal("Topology(9)", Topology(9).String())
+	_, err := ParseTopology("sphere")
+	assert.Error(err)
+}
+
+func TestGrid_Normalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology Topology
+		pos      Position
+		want     Position
+		wantOK   bool
+	}{
+		{"flat inside", Flat, Position{Q: 2, R: 3}, Position{Q: 2, R: 3}, true},
+		{"flat outside q", Flat, Position{Q: 10, R: 0}, Position{Q: 10, R: 0}, false},
+		{"cylinder wraps east", Cylinder, Position{Q: 10, R: 2}, Position{Q: 0, R: 2}, true},
+		{"cylinder wraps west", Cylinder, Position{Q: -1, R: 2}, Position{Q: 9, R: 2}, true},
+		{"cylinder does not wrap r", Cylinder, Position{Q: 0, R: 6}, Position{Q: 0, R: 6}, false},
+		{"torus wraps both", Torus, Position{Q: -11, R: 13}, Position{Q: 9, R: 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			grid := newFilledGrid(10, 6)
+			grid.SetTopology(tt.topology)
+			got, ok := grid.Normalize(tt.pos)
+			assert.Equal(tt.wantOK, ok)
+			assert.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestGrid_GetCellAtWraps(t *testing.T) {
+	assert := assert.New(t)
+	grid := newFilledGrid(10, 6)
+
+	_, err := grid.GetCellAt(-1, 0)
+	assert.Error(err, "flat grid should bounds-check strictly")
+
+	grid.SetTopology(Cylinder)
+	cell, err := grid.GetCellAt(-1, 0)
+	require.NoError(t, err)
+	assert.Equal(Position{Q: 9, R: 0}, cell.GetPosition())
+
+	cell, err = grid.GetCellAtPosition(Position{Q: 12, R: 5})
+	require.NoError(t, err)
+	assert.Equal(Position{Q: 2, R: 5}, cell.GetPosition())
+
+	_, err = grid.GetCellAt(0, -1)
+	assert.Error(err, "cylinder should not wrap north-south")
+}
+
+func TestGrid_DistanceWraps(t *testing.T) {
+	assert := assert.New(t)
+	grid := newFilledGrid(10, 6)
+	a, b := Position{Q: 0, R: 2}, Position{Q: 9, R: 2}
+
+	assert.Equal(9, grid.Distance(a, b))
+	grid.SetTopology(Cylinder)
+	assert.Equal(1, grid.Distance(a, b), "the seam should be one step")
+	assert.Equal(grid.Distance(a, b), grid.Distance(b, a))
+
+	c, d := Position{Q: 3, R: 0}, Position{Q: 3, R: 5}
+	assert.Equal(5, grid.Distance(c, d))
+	grid.SetTopology(Torus)
+	assert.Equal(1, grid.Distance(c, d))
+}
+
+func TestGrid_DistanceOutsideArea(t *testing.T) {
+	assert := assert.New(t)
+	grid := newFilledGrid(10, 6)
+	grid.SetTopology(Cylinder)
+	assert.Equal(5, grid.Distance(Position{Q: 25, R: 0}, Position{Q: 0, R: 0}), "positions more than a period apart")
+	assert.Equal(1, grid.Distance(Position{Q: -11, R: 2}, Position{Q: 0, R: 2}))
+
+	grid.SetTopology(Torus)
+	assert.Equal(1, grid.Distance(Position{Q: 3, R: 17}, Position{Q: 3, R: 0}))
+}
+
+func TestGrid_NeighborsAndRange(t *testing.T) {
+	assert := assert.New(t)
+	grid := newFilledGrid(10, 6)
+	corner := Position{Q: 0, R: 0}
+
+	assert.Len(grid.Neighbors(corner), 2, "flat corner has two in-bounds neighbors")
+	grid.SetTopology(Cylinder)
+	assert.Len(grid.Neighbors(corner), 4, "cylinder corner gains its western neighbors")
+	assert.Contains(grid.Neighbors(corner), Position{Q: 9, R: 0})
+	assert.Contains(grid.Neighbors(corner), Position{Q: 9, R: 1})
+	grid.SetTopology(Torus)
+	assert.Len(grid.Neighbors(corner), 6)
+
+	for _, p := range grid.Range(corner, 2) {
+		assert.LessOrEqual(grid.Distance(corner, p), 2)
+	}
+	assert.Len(grid.Range(corner, 2), 19)
+	assert.Len(grid.Range(corner, 20), 60, "a range wider than the torus covers it once")
+}
+
+func TestMap_Topology(t *testing.T) {
+	assert := assert.New(t)
+	m := NewMap(10, 6)
+	before := newFilledGrid(10, 6)
+	require.NoError(t, m.AddGrid(before))
+	assert.Equal(Flat, m.GetTopology())
+
+	m.SetTopology(Cylinder)
+	assert.Equal(Cylinder, before.GetTopology(), "existing layers should adopt the map topology")
+
+	after := newFilledGrid(10, 6)
+	require.NoError(t, m.AddGrid(after))
+	assert.Equal(Cylinder, after.GetTopology(), "new layers should adopt the map topology")
+
+	assert.Equal(1, m.Distance(Position{Q: 0, R: 0}, Position{Q: 9, R: 0}))
+	assert.Contains(m.Neighbors(Position{Q: 0, R: 0}), Position{Q: 9, R: 0})
+	assert.Len(m.Range(Position{Q: 0, R: 3}, 1), 7)
+	p, ok := m.Normalize(Position{Q: 11, R: 1})
+	assert.True(ok)
+	assert.Equal(Position{Q: 1, R: 1}, p)
+}
@@ -1,12 +1,11 @@
 package main
 
 import (
-	"github.com/jessevdk/go-flags"
-	"github.com/klumhru/4hex/viz"
+	"os"
+
+	"github.com/klumhru/4hex/cli"
 )
 
 func main() {
-	opts := &viz.Options{}
-	flags.Parse(opts)
-	viz.Run(opts)
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,387 @@
+package tiled
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/klumhru/4hex/game"
+	"github.com/klumhru/4hex/hex"
+)
+
+// Options configures Export.
+type Options struct {
+	// Layout selects the stagger axis (orientation) and stagger index (parity).
+	Layout hex.OffsetLayout
+	// TileWidth, TileHeight and HexSideLength set the tile geometry in pixels.
+	// They default to a regular 32 pixel hexagon for the layout's orientation.
+	TileWidth     int
+	TileHeight    int
+	HexSideLength int
+	// Tileset is the path of the tileset referenced by the map.
+	Tileset string
+	// FirstGID is the global id of the tileset's first tile. Defaults to 1.
+	// A cell with value v becomes tile FirstGID+v; nil cells become empty tiles.
+	FirstGID int
+}
+
+// Map and layer properties that let Import rebuild grids exactly.
+const (
+	propTopology = "hex.topology"
+	propWidth    = "hex.width"
+	propHeight   = "hex.height"
+	propCol      = "hex.col"
+	propRow      = "hex.row"
+	propQ        = "hex.q"
+	propR        = "hex.r"
+)
+
+// maxLayerCells bounds the size of an imported layer, guarding against corrupt
+// hex.width and hex.height properties.
+const maxLayerCells = 1 << 26
+
+func (o *Options) defaults() {
+	if o.TileWidth == 0 && o.TileHeight == 0 && o.HexSideLength == 0 {
+		if o.Layout.Orientation == hex.FlatTop {
+			o.TileWidth, o.TileHeight, o.HexSideLength = 32, 28, 16
+		} else {
+			o.TileWidth, o.TileHeight, o.HexSideLength = 28, 32, 16
+		}
+	}
+	if o.FirstGID == 0 {
+		o.FirstGID = 1
+	}
+}
+
+// Export converts every layer of m to a Tiled tile layer and the units of each
+// player to an object layer named after the player. All layers share one
+// Tiled map area covering every layer's cells in the offset layout.
+func Export(m hex.Map, players []game.Player, opts Options) (*Map, error) {
+	if m == nil {
+		return nil, fmt.Errorf("map cannot be nil")
+	}
+	opts.defaults()
+
+	// The Tiled area's origin is aligned to an even line so that Tiled's
+	// stagger index applies to the same lines as the absolute offset layout.
+	minCol, minRow, maxCol, maxRow := math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	for _, g := range m.GetGrids() {
+		for i := 0; i < g.GetCellCount(); i++ {
+			if cell, _ := g.GetCellAtIndex(i); cell != nil {
+				o := opts.Layout.ToOffset(cell.GetPosition().Add(g.GetPosition()))
+				minCol, minRow = min(minCol, o.Col), min(minRow, o.Row)
+				maxCol, maxRow = max(maxCol, o.Col), max(maxRow, o.Row)
+			}
+		}
+	}
+	if minCol > maxCol {
+		minCol, minRow, maxCol, maxRow = 0, 0, -1, -1
+	}
+	minCol, minRow = floorEven(minCol), floorEven(minRow)
+	width, height := maxCol-minCol+1, maxRow-minRow+1
+
+	mapWidth, mapHeight := m.GetDimensions()
+	tm := &Map{
+		Version:       "1.10",
+		Orientation:   "hexagonal",
+		RenderOrder:   "right-down",
+		Width:         width,
+		Height:        height,
+		TileWidth:     opts.TileWidth,
+		TileHeight:    opts.TileHeight,
+		HexSideLength: opts.HexSideLength,
+		StaggerAxis:   "y",
+		StaggerIndex:  opts.Layout.Parity.String(),
+		Properties: []Property{
+			stringProp(propTopology, m.GetTopology().String()),
+			intProp(propWidth, mapWidth),
+			intProp(propHeight, mapHeight),
+			intProp(propCol, minCol),
+			intProp(propRow, minRow),
+		},
+		Tilesets: []Tileset{{FirstGID: opts.FirstGID, Source: opts.Tileset}},
+	}
+	if opts.Layout.Orientation == hex.FlatTop {
+		tm.StaggerAxis = "x"
+	}
+	nextID := 1
+
+	for _, g := range m.GetGrids() {
+		layer := TileLayer{
+			ID:     nextID,
+			Name:   g.GetName(),
+			Width:  width,
+			Height: height,
+			Properties: []Property{
+				intProp(propQ, g.GetPosition().Q),
+				intProp(propR, g.GetPosition().R),
+				intProp(propWidth, g.GetWidth()),
+				intProp(propHeight, g.GetHeight()),
+			},
+			Data: make([]int, width*height),
+		}
+		nextID++
+		for i := 0; i < g.GetCellCount(); i++ {
+			cell, _ := g.GetCellAtIndex(i)
+			if cell == nil {
+				continue
+			}
+			if cell.GetValue() < 0 {
+				return nil, fmt.Errorf("layer %s: cell %s has negative value %d", g.GetName(), cell.GetPosition(), cell.GetValue())
+			}
+			o := opts.Layout.ToOffset(cell.GetPosition().Add(g.GetPosition()))
+			layer.Data[(o.Row-minRow)*width+(o.Col-minCol)] = opts.FirstGID + cell.GetValue()
+		}
+		tm.Layers = append(tm.Layers, layer)
+	}
+
+	nextObject := 1
+	for _, p := range players {
+		group := ObjectGroup{ID: nextID, Name: p.GetName()}
+		nextID++
+		for i := 0; i < p.GetUnitCount(); i++ {
+			u, _ := p.GetUnitAt(i)
+			if u == nil {
+				continue
+			}
+			o := opts.Layout.ToOffset(u.Position())
+			x, y := tm.tileCenter(o.Col-minCol, o.Row-minRow)
+			group.Objects = append(group.Objects, Object{
+				ID:   nextObject,
+				Name: u.GetName(),
+				Type: "unit",
+				X:    x,
+				Y:    y,
+				Properties: []Property{
+					intProp(propQ, u.Position().Q),
+					intProp(propR, u.Position().R),
+				},
+			})
+			nextObject++
+		}
+		tm.ObjectGroups = append(tm.ObjectGroups, group)
+	}
+	tm.NextLayerID, tm.NextObjectID = nextID, nextObject
+	return tm, nil
+}
+
+// Import converts a Tiled hexagonal map back into a hex.Map and players. Maps
+// written by Export are restored exactly; for other maps each tile layer
+// becomes a grid covering the layer's tiles, and units are placed on the tile
+// under each object.
+func Import(tm *Map) (hex.Map, []game.Player, error) {
+	if tm == nil {
+		return nil, nil, fmt.Errorf("map cannot be nil")
+	}
+	if tm.Orientation != "hexagonal" {
+		return nil, nil, fmt.Errorf("unsupported orientation %q, expected hexagonal", tm.Orientation)
+	}
+	layout, err := tm.layout()
+	if err != nil {
+		return nil, nil, err
+	}
+	firstGID := 1
+	if len(tm.Tilesets) > 0 {
+		firstGID = tm.Tilesets[0].FirstGID
+	}
+	originCol, _, err := intProperty(tm.Properties, propCol)
+	if err != nil {
+		return nil, nil, err
+	}
+	originRow, _, err := intProperty(tm.Properties, propRow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapWidth, hasWidth, err := intProperty(tm.Properties, propWidth)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapHeight, hasHeight, err := intProperty(tm.Properties, propHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+	grids := make([]hex.Grid, 0, len(tm.Layers))
+	for _, l := range tm.Layers {
+		g, err := importLayer(l, layout, originCol, originRow, firstGID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("layer %s: %w", l.Name, err)
+		}
+		grids = append(grids, g)
+		if !hasWidth {
+			mapWidth = max(mapWidth, g.GetPosition().Q+g.GetWidth())
+		}
+		if !hasHeight {
+			mapHeight = max(mapHeight, g.GetPosition().R+g.GetHeight())
+		}
+	}
+	m := hex.NewMap(mapWidth, mapHeight)
+	if name, ok := property(tm.Properties, propTopology); ok {
+		topology, err := hex.ParseTopology(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		m.SetTopology(topology)
+	}
+	for _, g := range grids {
+		if err := m.AddGrid(g); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var players []game.Player
+	for _, group := range tm.ObjectGroups {
+		p := game.NewPlayer(group.Name)
+		for _, o := range group.Objects {
+			u := hex.NewUnit(o.Name)
+			pos, err := tm.objectPosition(o, layout, originCol, originRow)
+			if err != nil {
+				return nil, nil, fmt.Errorf("object %d: %w", o.ID, err)
+			}
+			u.Move(pos)
+			p.AddUnit(u)
+		}
+		players = append(players, p)
+	}
+	return m, players, nil
+}
+
+func importLayer(l TileLayer, layout hex.OffsetLayout, originCol, originRow, firstGID int) (hex.Grid, error) {
+	if l.Width < 0 || l.Height < 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", l.Width, l.Height)
+	}
+	if len(l.Data) != l.Width*l.Height {
+		return nil, fmt.Errorf("has %d tiles, expected %d", len(l.Data), l.Width*l.Height)
+	}
+	values := make(map[hex.Position]int)
+	minQ, minR, maxQ, maxR := math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	for i, gid := range l.Data {
+		if gid == 0 {
+			continue
+		}
+		if gid < firstGID {
+			return nil, fmt.Errorf("tile id %d is below the first gid %d", gid, firstGID)
+		}
+		p := layout.FromOffset(hex.OffsetCoord{Col: originCol + i%l.Width, Row: originRow + i/l.Width})
+		values[p] = gid - firstGID
+		minQ, minR = min(minQ, p.Q), min(minR, p.R)
+		maxQ, maxR = max(maxQ, p.Q), max(maxR, p.R)
+	}
+	if len(values) == 0 {
+		minQ, minR, maxQ, maxR = 0, 0, -1, -1
+	}
+	root := hex.NewPosition(minQ, minR)
+	width, height := maxQ-minQ+1, maxR-minR+1
+
+	q, hasQ, err := intProperty(l.Properties, propQ)
+	if err != nil {
+		return nil, err
+	}
+	r, hasR, err := intProperty(l.Properties, propR)
+	if err != nil {
+		return nil, err
+	}
+	w, hasW, err := intProperty(l.Properties, propWidth)
+	if err != nil {
+		return nil, err
+	}
+	h, hasH, err := intProperty(l.Properties, propHeight)
+	if err != nil {
+		return nil, err
+	}
+	if hasQ && hasR && hasW && hasH {
+		// Check each dimension before multiplying so the product cannot overflow.
+		if w < 0 || h < 0 || w > maxLayerCells || h > maxLayerCells/max(w, 1) {
+			return nil, fmt.Errorf("invalid %s and %s properties %dx%d", propWidth, propHeight, w, h)
+		}
+		root, width, height = hex.NewPosition(q, r), w, h
+	}
+	for p := range values {
+		local := p.Sub(root)
+		if local.Q < 0 || local.R < 0 || local.Q >= width || local.R >= height {
+			return nil, fmt.Errorf("tile at %s lies outside the layer bounds", p)
+		}
+	}
+
+	cells := make([][]hex.Cell, height)
+	for row := range cells {
+		cells[row] = make([]hex.Cell, width)
+	}
+	for p, v := range values {
+		local := p.Sub(root)
+		cells[local.R][local.Q] = hex.NewCellWithValue(local.Q, local.R, v)
+	}
+	return hex.NewGrid(root, l.Name, width, height, cells), nil
+}
+
+// layout returns the offset layout described by the map's stagger settings.
+func (tm *Map) layout() (hex.OffsetLayout, error) {
+	var layout hex.OffsetLayout
+	switch tm.StaggerAxis {
+	case "y":
+		layout.Orientation = hex.PointyTop
+	case "x":
+		layout.Orientation = hex.FlatTop
+	default:
+		return layout, fmt.Errorf("unsupported stagger axis %q", tm.StaggerAxis)
+	}
+	switch tm.StaggerIndex {
+	case "odd":
+		layout.Parity = hex.Odd
+	case "even":
+		layout.Parity = hex.Even
+	default:
+		return layout, fmt.Errorf("unsupported stagger index %q", tm.StaggerIndex)
+	}
+	return layout, nil
+}
+
+// tileCenter returns the pixel center of the tile at a Tiled column and row.
+func (tm *Map) tileCenter(col, row int) (float64, float64) {
+	tw, th, side := float64(tm.TileWidth), float64(tm.TileHeight), float64(tm.HexSideLength)
+	odd := tm.StaggerIndex == "odd"
+	if tm.StaggerAxis == "x" {
+		x := float64(col) * (tw + side) / 2
+		y := float64(row) * th
+		if (col&1 == 1) == odd {
+			y += th / 2
+		}
+		return x + tw/2, y + th/2
+	}
+	x := float64(col) * tw
+	y := float64(row) * (th + side) / 2
+	if (row&1 == 1) == odd {
+		x += tw / 2
+	}
+	return x + tw/2, y + th/2
+}
+
+// objectPosition returns a unit's axial position, from its properties when
+// present and otherwise from the tile whose center is closest to the object.
+func (tm *Map) objectPosition(o Object, layout hex.OffsetLayout, originCol, originRow int) (hex.Position, error) {
+	q, hasQ, err := intProperty(o.Properties, propQ)
+	if err != nil {
+		return hex.Position{}, err
+	}
+	r, hasR, err := intProperty(o.Properties, propR)
+	if err != nil {
+		return hex.Position{}, err
+	}
+	if hasQ && hasR {
+		return hex.NewPosition(q, r), nil
+	}
+	bestCol, bestRow, bestDist := 0, 0, math.Inf(1)
+	for row := -1; row <= tm.Height; row++ {
+		for col := -1; col <= tm.Width; col++ {
+			x, y := tm.tileCenter(col, row)
+			if d := math.Hypot(x-o.X, y-o.Y); d < bestDist {
+				bestCol, bestRow, bestDist = col, row, d
+			}
+		}
+	}
+	return layout.FromOffset(hex.OffsetCoord{Col: originCol + bestCol, Row: originRow + bestRow}), nil
+}
+
+// floorEven rounds v down to an even number.
+func floorEven(v int) int {
+	return v - v&1
+}
@@ -0,0 +1,325 @@
+// Package tiled converts hex maps to and from the hexagonal map format of the
+// Tiled map editor (https://www.mapeditor.org), in both its TMX and JSON forms.
+//
+// Tiled stores hexagonal maps in a staggered offset layout. Pointy maps stagger
+// rows (stagger axis y) and flat maps stagger columns (stagger axis x); the
+// stagger index selects whether odd or even lines are shifted. Every hex.Map
+// layer becomes a tile layer and every player's units become an object layer.
+package tiled
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Map is a Tiled map document.
+type Map struct {
+	XMLName       xml.Name      `xml:"map"`
+	Version       string        `xml:"version,attr"`
+	Orientation   string        `xml:"orientation,attr"`
+	RenderOrder   string        `xml:"renderorder,attr"`
+	Width         int           `xml:"width,attr"`
+	Height        int           `xml:"height,attr"`
+	TileWidth     int           `xml:"tilewidth,attr"`
+	TileHeight    int           `xml:"tileheight,attr"`
+	HexSideLength int           `xml:"hexsidelength,attr"`
+	StaggerAxis   string        `xml:"staggeraxis,attr"`
+	StaggerIndex  string        `xml:"staggerindex,attr"`
+	Infinite      int           `xml:"infinite,attr"`
+	NextLayerID   int           `xml:"nextlayerid,attr"`
+	NextObjectID  int           `xml:"nextobjectid,attr"`
+	Properties    []Property    `xml:"properties>property"`
+	Tilesets      []Tileset     `xml:"tileset"`
+	Layers        []TileLayer   `xml:"layer"`
+	ObjectGroups  []ObjectGroup `xml:"objectgroup"`
+}
+
+// Property is a custom Tiled property.
+type Property struct {
+	Name  string `xml:"name,attr" json:"name"`
+	Type  string `xml:"type,attr,omitempty" json:"type"`
+	Value string `xml:"value,attr" json:"-"`
+}
+
+// Tileset references a tileset used by the map.
+type Tileset struct {
+	FirstGID int    `xml:"firstgid,attr" json:"firstgid"`
+	Source   string `xml:"source,attr" json:"source"`
+}
+
+// TileLayer is a layer of tiles. Data holds Width*Height global tile ids in
+// row-major order; 0 means no tile.
+type TileLayer struct {
+	ID         int        `xml:"id,attr"`
+	Name       string     `xml:"name,attr"`
+	Width      int        `xml:"width,attr"`
+	Height     int        `xml:"height,attr"`
+	Properties []Property `xml:"properties>property"`
+	Data       []int      `xml:"-"`
+}
+
+// ObjectGroup is a layer of free-standing objects.
+type ObjectGroup struct {
+	ID         int        `xml:"id,attr"`
+	Name       string     `xml:"name,attr"`
+	Properties []Property `xml:"properties>property"`
+	Objects    []Object   `xml:"object"`
+}
+
+// Object is a point object, used for units.
+type Object struct {
+	ID         int        `xml:"id,attr"`
+	Name       string     `xml:"name,attr"`
+	Type       string     `xml:"type,attr,omitempty"`
+	X          float64    `xml:"x,attr"`
+	Y          float64    `xml:"y,attr"`
+	Properties []Property `xml:"properties>property"`
+}
+
+// property returns the value of the named property.
+func property(props []Property, name string) (string, bool) {
+	for _, p := range props {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// intProperty returns the named property as an int.
+func intProperty(props []Property, name string) (int, bool, error) {
+	v, ok := property(props, name)
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, true, fmt.Errorf("property %s: %w", name, err)
+	}
+	return n, true, nil
+}
+
+func intProp(name string, v int) Property {
+	return Property{Name: name, Type: "int", Value: strconv.Itoa(v)}
+}
+
+func stringProp(name, v string) Property {
+	return Property{Name: name, Type: "string", Value: v}
+}
+
+// tileData is the CSV payload of a TMX tile layer.
+type tileData struct {
+	Encoding string `xml:"encoding,attr"`
+	CSV      string `xml:",chardata"`
+}
+
+// tmxTileLayer adds the data element that TileLayer keeps as a slice.
+type tmxTileLayer struct {
+	TileLayer
+	Data tileData `xml:"data"`
+}
+
+// tmxMap mirrors Map with TMX-shaped tile layers. ObjectGroups is repeated so
+// that object layers are written after the tile layers.
+type tmxMap struct {
+	Map
+	Layers       []tmxTileLayer `xml:"layer"`
+	ObjectGroups []ObjectGroup  `xml:"objectgroup"`
+}
+
+// WriteTMX writes the map as a TMX document.
+func (m *Map) WriteTMX(w io.Writer) error {
+	doc := tmxMap{Map: *m, ObjectGroups: m.ObjectGroups}
+	doc.Map.Layers, doc.Map.ObjectGroups = nil, nil
+	for _, l := range m.Layers {
+		ids := make([]string, len(l.Data))
+		for i, id := range l.Data {
+			ids[i] = strconv.Itoa(id)
+		}
+		doc.Layers = append(doc.Layers, tmxTileLayer{TileLayer: l, Data: tileData{Encoding: "csv", CSV: strings.Join(ids, ",")}})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write TMX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadTMX reads a TMX document with CSV-encoded tile layers.
+func ReadTMX(r io.Reader) (*Map, error) {
+	var doc tmxMap
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read TMX: %w", err)
+	}
+	m := doc.Map
+	m.Layers, m.ObjectGroups = nil, doc.ObjectGroups
+	for _, l := range doc.Layers {
+		if l.Data.Encoding != "csv" {
+			return nil, fmt.Errorf("layer %s uses unsupported encoding %q", l.Name, l.Data.Encoding)
+		}
+		layer := l.TileLayer
+		for _, field := range strings.Split(l.Data.CSV, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			id, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("layer %s: %w", l.Name, err)
+			}
+			layer.Data = append(layer.Data, id)
+		}
+		m.Layers = append(m.Layers, layer)
+	}
+	return &m, nil
+}
+
+// jsonProperty is a Property as Tiled writes it in JSON, with typed values.
+type jsonProperty struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// jsonLayer is any Tiled JSON layer; Type selects the fields in use.
+type jsonLayer struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Width      int            `json:"width,omitempty"`
+	Height     int            `json:"height,omitempty"`
+	Data       []int          `json:"data,omitempty"`
+	Objects    []jsonObject   `json:"objects,omitempty"`
+	Properties []jsonProperty `json:"properties,omitempty"`
+	Opacity    float64        `json:"opacity"`
+	Visible    bool           `json:"visible"`
+	X          int            `json:"x"`
+	Y          int            `json:"y"`
+}
+
+type jsonObject struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	X          float64        `json:"x"`
+	Y          float64        `json:"y"`
+	Point      bool           `json:"point"`
+	Properties []jsonProperty `json:"properties,omitempty"`
+}
+
+// jsonMap is a Map as Tiled writes it in JSON.
+type jsonMap struct {
+	Type          string         `json:"type"`
+	Version       string         `json:"version"`
+	Orientation   string         `json:"orientation"`
+	RenderOrder   string         `json:"renderorder"`
+	Width         int            `json:"width"`
+	Height        int            `json:"height"`
+	TileWidth     int            `json:"tilewidth"`
+	TileHeight    int            `json:"tileheight"`
+	HexSideLength int            `json:"hexsidelength"`
+	StaggerAxis   string         `json:"staggeraxis"`
+	StaggerIndex  string         `json:"staggerindex"`
+	Infinite      bool           `json:"infinite"`
+	NextLayerID   int            `json:"nextlayerid"`
+	NextObjectID  int            `json:"nextobjectid"`
+	Properties    []jsonProperty `json:"properties,omitempty"`
+	Tilesets      []Tileset      `json:"tilesets"`
+	Layers        []jsonLayer    `json:"layers"`
+}
+
+func toJSONProperties(props []Property) []jsonProperty {
+	out := make([]jsonProperty, 0, len(props))
+	for _, p := range props {
+		jp := jsonProperty{Name: p.Name, Type: p.Type, Value: p.Value}
+		if p.Type == "int" {
+			if n, err := strconv.Atoi(p.Value); err == nil {
+				jp.Value = n
+			}
+		}
+		out = append(out, jp)
+	}
+	return out
+}
+
+func fromJSONProperties(props []jsonProperty) []Property {
+	out := make([]Property, 0, len(props))
+	for _, p := range props {
+		value := fmt.Sprint(p.Value)
+		if f, ok := p.Value.(float64); ok {
+			value = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		out = append(out, Property{Name: p.Name, Type: p.Type, Value: value})
+	}
+	return out
+}
+
+// WriteJSON writes the map in Tiled's JSON format.
+func (m *Map) WriteJSON(w io.Writer) error {
+	doc := jsonMap{
+		Type: "map", Version: m.Version, Orientation: m.Orientation, RenderOrder: m.RenderOrder,
+		Width: m.Width, Height: m.Height, TileWidth: m.TileWidth, TileHeight: m.TileHeight,
+		HexSideLength: m.HexSideLength, StaggerAxis: m.StaggerAxis, StaggerIndex: m.StaggerIndex,
+		Infinite: m.Infinite != 0, NextLayerID: m.NextLayerID, NextObjectID: m.NextObjectID,
+		Properties: toJSONProperties(m.Properties), Tilesets: m.Tilesets, Layers: []jsonLayer{},
+	}
+	for _, l := range m.Layers {
+		doc.Layers = append(doc.Layers, jsonLayer{
+			ID: l.ID, Name: l.Name, Type: "tilelayer", Width: l.Width, Height: l.Height,
+			Data: l.Data, Properties: toJSONProperties(l.Properties), Opacity: 1, Visible: true,
+		})
+	}
+	for _, g := range m.ObjectGroups {
+		layer := jsonLayer{ID: g.ID, Name: g.Name, Type: "objectgroup", Properties: toJSONProperties(g.Properties), Opacity: 1, Visible: true, Objects: []jsonObject{}}
+		for _, o := range g.Objects {
+			layer.Objects = append(layer.Objects, jsonObject{ID: o.ID, Name: o.Name, Type: o.Type, X: o.X, Y: o.Y, Point: true, Properties: toJSONProperties(o.Properties)})
+		}
+		doc.Layers = append(doc.Layers, layer)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write Tiled JSON: %w", err)
+	}
+	return nil
+}
+
+// ReadJSON reads a map in Tiled's JSON format.
+func ReadJSON(r io.Reader) (*Map, error) {
+	var doc jsonMap
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read Tiled JSON: %w", err)
+	}
+	m := &Map{
+		Version: doc.Version, Orientation: doc.Orientation, RenderOrder: doc.RenderOrder,
+		Width: doc.Width, Height: doc.Height, TileWidth: doc.TileWidth, TileHeight: doc.TileHeight,
+		HexSideLength: doc.HexSideLength, StaggerAxis: doc.StaggerAxis, StaggerIndex: doc.StaggerIndex,
+		NextLayerID: doc.NextLayerID, NextObjectID: doc.NextObjectID,
+		Properties: fromJSONProperties(doc.Properties), Tilesets: doc.Tilesets,
+	}
+	if doc.Infinite {
+		m.Infinite = 1
+	}
+	for _, l := range doc.Layers {
+		switch l.Type {
+		case "tilelayer":
+			m.Layers = append(m.Layers, TileLayer{ID: l.ID, Name: l.Name, Width: l.Width, Height: l.Height, Data: l.Data, Properties: fromJSONProperties(l.Properties)})
+		case "objectgroup":
+			g := ObjectGroup{ID: l.ID, Name: l.Name, Properties: fromJSONProperties(l.Properties)}
+			for _, o := range l.Objects {
+				g.Objects = append(g.Objects, Object{ID: o.ID, Name: o.Name, Type: o.Type, X: o.X, Y: o.Y, Properties: fromJSONProperties(o.Properties)})
+			}
+			m.ObjectGroups = append(m.ObjectGroups, g)
+		}
+	}
+	return m, nil
+}
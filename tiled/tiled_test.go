@@ -0,0 +1,271 @@
+package tiled
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/klumhru/4hex/game"
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var layouts = []hex.OffsetLayout{
+	{Orientation: hex.PointyTop, Parity: hex.Odd},
+	{Orientation: hex.PointyTop, Parity: hex.Even},
+	{Orientation: hex.FlatTop, Parity: hex.Odd},
+	{Orientation: hex.FlatTop, Parity: hex.Even},
+}
+
+// newTestMap returns a map with a full terrain layer and a sparse, offset
+// resource layer.
+func newTestMap(t *testing.T) hex.Map {
+	t.Helper()
+	terrain := make([][]hex.Cell, 4)
+	for r := range terrain {
+		terrain[r] = make([]hex.Cell, 5)
+		for q := range terrain[r] {
+			terrain[r][q] = hex.NewCellWithValue(q, r, (q+r)%3)
+		}
+	}
+	resources := [][]hex.Cell{
+		{hex.NewCellWithValue(0, 0, 7), nil},
+		{nil, hex.NewCellWithValue(1, 1, 0)},
+	}
+	m := hex.NewMap(5, 4)
+	m.SetTopology(hex.Cylinder)
+	require.NoError(t, m.AddGrid(hex.NewGrid(hex.NewPosition(0, 0), "terrain", 5, 4, terrain)))
+	require.NoError(t, m.AddGrid(hex.NewGrid(hex.NewPosition(2, 1), "resources", 2, 2, resources)))
+	return m
+}
+
+func newTestPlayers() []game.Player {
+	red := game.NewPlayer("red")
+	scout := hex.NewUnit("scout")
+	scout.Move(hex.NewPosition(1, 2))
+	red.AddUnit(scout)
+	blue := game.NewPlayer("blue")
+	settler := hex.NewUnit("settler")
+	settler.Move(hex.NewPosition(4, 3))
+	blue.AddUnit(settler)
+	return []game.Player{red, blue}
+}
+
+func assertMapsEqual(t *testing.T, want, got hex.Map) {
+	t.Helper()
+	assert.Equal(t, want.GetTopology(), got.GetTopology())
+	ww, wh := want.GetDimensions()
+	gw, gh := got.GetDimensions()
+	assert.Equal(t, []int{ww, wh}, []int{gw, gh})
+	require.Len(t, got.GetGrids(), len(want.GetGrids()))
+	for i, wg := range want.GetGrids() {
+		gg := got.GetGrids()[i]
+		assert.Equal(t, wg.GetName(), gg.GetName())
+		assert.Equal(t, wg.GetPosition(), gg.GetPosition())
+		assert.Equal(t, wg.GetWidth(), gg.GetWidth())
+		assert.Equal(t, wg.GetHeight(), gg.GetHeight())
+		for j := 0; j < wg.GetCellCount(); j++ {
+			wc, _ := wg.GetCellAtIndex(j)
+			gc, _ := gg.GetCellAtIndex(j)
+			if wc == nil {
+				assert.Nil(t, gc, "layer %s index %d", wg.GetName(), j)
+				continue
+			}
+			require.NotNil(t, gc, "layer %s index %d", wg.GetName(), j)
+			assert.Equal(t, wc.GetValue(), gc.GetValue())
+		}
+	}
+}
+
+func assertPlayersEqual(t *testing.T, want, got []game.Player) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for i, wp := range want {
+		assert.Equal(t, wp.GetName(), got[i].GetName())
+		require.Equal(t, wp.GetUnitCount(), got[i].GetUnitCount())
+		for j := 0; j < wp.GetUnitCount(); j++ {
+			wu, _ := wp.GetUnitAt(j)
+			gu, _ := got[i].GetUnitAt(j)
+			assert.Equal(t, wu.GetName(), gu.GetName())
+			assert.Equal(t, wu.Position(), gu.Position())
+		}
+	}
+}
+
+func TestExport_Layout(t *testing.T) {
+	m := newTestMap(t)
+	tm, err := Export(m, nil, Options{Layout: layouts[2], Tileset: "terrain.tsx"})
+	require.NoError(t, err)
+	assert.Equal(t, "hexagonal", tm.Orientation)
+	assert.Equal(t, "x", tm.StaggerAxis)
+	assert.Equal(t, "odd", tm.StaggerIndex)
+	assert.Equal(t, []Tileset{{FirstGID: 1, Source: "terrain.tsx"}}, tm.Tilesets)
+	require.Len(t, tm.Layers, 2)
+	for _, l := range tm.Layers {
+		assert.Len(t, l.Data, tm.Width*tm.Height)
+	}
+
+	tm, err = Export(m, nil, Options{Layout: layouts[1]})
+	require.NoError(t, err)
+	assert.Equal(t, "y", tm.StaggerAxis)
+	assert.Equal(t, "even", tm.StaggerIndex)
+}
+
+func TestExport_TileIDs(t *testing.T) {
+	cells := [][]hex.Cell{{hex.NewCellWithValue(0, 0, 3), nil}}
+	m := hex.NewMap(2, 1)
+	require.NoError(t, m.AddGrid(hex.NewGrid(hex.NewPosition(0, 0), "terrain", 2, 1, cells)))
+
+	tm, err := Export(m, nil, Options{Layout: layouts[0], FirstGID: 10})
+	require.NoError(t, err)
+	require.Len(t, tm.Layers, 1)
+	assert.Equal(t, []int{13}, tm.Layers[0].Data)
+}
+
+func TestExport_NegativeValue(t *testing.T) {
+	cells := [][]hex.Cell{{hex.NewCellWithValue(0, 0, -1)}}
+	m := hex.NewMap(1, 1)
+	require.NoError(t, m.AddGrid(hex.NewGrid(hex.NewPosition(0, 0), "terrain", 1, 1, cells)))
+
+	_, err := Export(m, nil, Options{})
+	assert.ErrorContains(t, err, "negative value")
+}
+
+func TestRoundTrip_TMX(t *testing.T) {
+	for _, layout := range layouts {
+		t.Run(layout.Orientation.String()+"-"+layout.Parity.String(), func(t *testing.T) {
+			m, players := newTestMap(t), newTestPlayers()
+			tm, err := Export(m, players, Options{Layout: layout})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, tm.WriteTMX(&buf))
+			read, err := ReadTMX(&buf)
+			require.NoError(t, err)
+
+			got, gotPlayers, err := Import(read)
+			require.NoError(t, err)
+			assertMapsEqual(t, m, got)
+			assertPlayersEqual(t, players, gotPlayers)
+		})
+	}
+}
+
+func TestRoundTrip_JSON(t *testing.T) {
+	for _, layout := range layouts {
+		t.Run(layout.Orientation.String()+"-"+layout.Parity.String(), func(t *testing.T) {
+			m, players := newTestMap(t), newTestPlayers()
+			tm, err := Export(m, players, Options{Layout: layout})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, tm.WriteJSON(&buf))
+			read, err := ReadJSON(&buf)
+			require.NoError(t, err)
+
+			got, gotPlayers, err := Import(read)
+			require.NoError(t, err)
+			assertMapsEqual(t, m, got)
+			assertPlayersEqual(t, players, gotPlayers)
+		})
+	}
+}
+
+func TestImport_WithoutProperties(t *testing.T) {
+	for _, layout := range layouts {
+		t.Run(layout.Orientation.String()+"-"+layout.Parity.String(), func(t *testing.T) {
+			m, players := newTestMap(t), newTestPlayers()
+			tm, err := Export(m, players, Options{Layout: layout})
+			require.NoError(t, err)
+
+			// Maps authored in Tiled carry no hex properties: units are
+			// located from their pixel position and layers from their tiles.
+			for i := range tm.Layers {
+				tm.Layers[i].Properties = nil
+			}
+			for i := range tm.ObjectGroups {
+				for j := range tm.ObjectGroups[i].Objects {
+					tm.ObjectGroups[i].Objects[j].Properties = nil
+				}
+			}
+
+			got, gotPlayers, err := Import(tm)
+			require.NoError(t, err)
+			assertPlayersEqual(t, players, gotPlayers)
+			terrain, err := got.GetGridByName("terrain")
+			require.NoError(t, err)
+			for r := 0; r < 4; r++ {
+				for q := 0; q < 5; q++ {
+					cell, err := terrain.GetCellAtPosition(hex.NewPosition(q, r).Sub(terrain.GetPosition()))
+					require.NoError(t, err)
+					require.NotNil(t, cell)
+					assert.Equal(t, (q+r)%3, cell.GetValue())
+				}
+			}
+		})
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	_, _, err := Import(nil)
+	assert.Error(t, err)
+
+	_, _, err = Import(&Map{Orientation: "orthogonal"})
+	assert.ErrorContains(t, err, "orientation")
+
+	_, _, err = Import(&Map{Orientation: "hexagonal", StaggerAxis: "z", StaggerIndex: "odd"})
+	assert.ErrorContains(t, err, "stagger axis")
+
+	_, _, err = Import(&Map{
+		Orientation:  "hexagonal",
+		StaggerAxis:  "y",
+		StaggerIndex: "odd",
+		Layers:       []TileLayer{{Name: "terrain", Width: 2, Height: 2, Data: []int{1}}},
+	})
+	assert.ErrorContains(t, err, "terrain")
+}
+
+// layerTMX is a one-layer TMX map whose layer declares its size as %d x %d.
+const layerTMX = `<map orientation="hexagonal" width="2" height="1" staggeraxis="y" staggerindex="odd">
+ <layer name="terrain" width="2" height="1">
+  <properties>
+   <property name="hex.q" type="int" value="0"/>
+   <property name="hex.r" type="int" value="0"/>
+   <property name="hex.width" type="int" value="%d"/>
+   <property name="hex.height" type="int" value="%d"/>
+  </properties>
+  <data encoding="csv">2,3</data>
+ </layer>
+</map>`
+
+func TestImport_InvalidLayerSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		want          string
+	}{
+		{"negative height", 2, -1, "invalid hex.width and hex.height"},
+		{"negative width", -2, 1, "invalid hex.width and hex.height"},
+		{"too large", 1 << 20, 1 << 20, "invalid hex.width and hex.height"},
+		{"tiles outside", 1, 1, "outside the layer bounds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm, err := ReadTMX(bytes.NewBufferString(fmt.Sprintf(layerTMX, tt.width, tt.height)))
+			require.NoError(t, err)
+			_, _, err = Import(tm)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	tm, err := ReadTMX(bytes.NewBufferString(fmt.Sprintf(layerTMX, 2, 1)))
+	require.NoError(t, err)
+	_, _, err = Import(tm)
+	assert.NoError(t, err)
+}
+
+func TestReadTMX_Invalid(t *testing.T) {
+	_, err := ReadTMX(bytes.NewBufferString("<map><layer><data encoding=\"base64\">AAAA</data></layer></map>"))
+	assert.Error(t, err)
+}
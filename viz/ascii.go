@@ -0,0 +1,226 @@
+package viz
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/klumhru/4hex/hex"
+)
+
+// asciiHex is the outline of one hex with its label slot, together with the
+// step between neighboring hexes on the canvas. Outlines of neighbors overlap
+// on shared edges, which is what makes the hexes interlock.
+type asciiHex struct {
+	lines []string
+	// interior marks with # the part of lines filled when coloring.
+	interior []string
+	// labelX and labelY locate the label slot inside lines.
+	labelX, labelY int
+	// colStep and rowStep are the canvas distances between offset columns and rows.
+	colStep, rowStep int
+	// shove is the canvas shift of shoved rows (pointy) or columns (flat).
+	shove int
+}
+
+var pointyHex = asciiHex{
+	lines: []string{
+		" / \\ ",
+		"|   |",
+		" \\ / ",
+	},
+	interior: []string{
+		"     ",
+		" ### ",
+		"     ",
+	},
+	labelX: 1, labelY: 1,
+	colStep: 4, rowStep: 2, shove: 2,
+}
+
+var flatHex = asciiHex{
+	lines: []string{
+		"  ___  ",
+		" /   \\ ",
+		"/     \\",
+		"\\     /",
+		" \\___/ ",
+	},
+	interior: []string{
+		"       ",
+		"  ###  ",
+		" ##### ",
+		" ##### ",
+		"  ###  ",
+	},
+	labelX: 2, labelY: 2,
+	colStep: 5, rowStep: 4, shove: 2,
+}
+
+// labelWidth is the number of characters available for a label.
+const labelWidth = 3
+
+// ASCIIOptions configures ASCII rendering.
+type ASCIIOptions struct {
+	// Layout selects pointy or flat hexes and which rows or columns are shoved.
+	Layout hex.OffsetLayout
+	// Label returns the text drawn inside a hex, clipped to three characters.
+	// Defaults to the cell's value.
+	Label func(pos hex.Position, cell hex.Cell) string
+}
+
+// RenderASCII draws the cells of g as interlocking ASCII hexes. Missing cells
+// are left blank.
+func RenderASCII(g hex.Grid, opts ASCIIOptions) string {
+	var cells []hex.Cell
+	for i := 0; i < g.GetCellCount(); i++ {
+		if cell, _ := g.GetCellAtIndex(i); cell != nil {
+			cells = append(cells, cell)
+		}
+	}
+	return renderASCII(g.GetPosition(), cells, opts)
+}
+
+// RenderMapASCII draws the named layer of m, or the first layer when layer is
+// empty, as interlocking ASCII hexes.
+func RenderMapASCII(m hex.Map, layer string, opts ASCIIOptions) (string, error) {
+	grids := m.GetGrids()
+	if len(grids) == 0 {
+		return "", nil
+	}
+	g := grids[0]
+	if layer != "" {
+		var err error
+		if g, err = m.GetGridByName(layer); err != nil {
+			return "", err
+		}
+	}
+	return RenderASCII(g, opts), nil
+}
+
+func renderASCII(root hex.Position, cells []hex.Cell, opts ASCIIOptions) string {
+	return drawASCII(root, cells, opts).String(nil)
+}
+
+// asciiCanvas holds drawn hexes. Each rune inside a hex remembers the cell
+// covering it so that colored output can fill hex interiors.
+type asciiCanvas struct {
+	runes [][]rune
+	fills [][]hex.Cell
+}
+
+// drawASCII draws cells onto a canvas sized to fit them.
+func drawASCII(root hex.Position, cells []hex.Cell, opts ASCIIOptions) *asciiCanvas {
+	if len(cells) == 0 {
+		return &asciiCanvas{}
+	}
+	label := opts.Label
+	if label == nil {
+		label = func(_ hex.Position, cell hex.Cell) string {
+			return fmt.Sprint(cell.GetValue())
+		}
+	}
+	shape := pointyHex
+	if opts.Layout.Orientation == hex.FlatTop {
+		shape = flatHex
+	}
+
+	// Align the canvas origin to an even line so shoving follows the
+	// absolute parity of each row or column.
+	minCol, minRow, maxCol, maxRow := math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	for _, c := range cells {
+		o := opts.Layout.ToOffset(c.GetPosition().Add(root))
+		minCol, minRow = min(minCol, o.Col), min(minRow, o.Row)
+		maxCol, maxRow = max(maxCol, o.Col), max(maxRow, o.Row)
+	}
+	minCol, minRow = minCol-minCol&1, minRow-minRow&1
+	width := (maxCol-minCol)*shape.colStep + shape.shove + len(shape.lines[0])
+	height := (maxRow-minRow)*shape.rowStep + shape.shove + len(shape.lines)
+	canvas := &asciiCanvas{
+		runes: make([][]rune, height),
+		fills: make([][]hex.Cell, height),
+	}
+	for y := range canvas.runes {
+		canvas.runes[y] = []rune(strings.Repeat(" ", width))
+		canvas.fills[y] = make([]hex.Cell, width)
+	}
+
+	for _, c := range cells {
+		pos := c.GetPosition().Add(root)
+		o := opts.Layout.ToOffset(pos)
+		x, y := (o.Col-minCol)*shape.colStep, (o.Row-minRow)*shape.rowStep
+		if opts.Layout.Orientation == hex.FlatTop {
+			if opts.Layout.Shoved(o.Col) {
+				y += shape.shove
+			}
+		} else if opts.Layout.Shoved(o.Row) {
+			x += shape.shove
+		}
+		for dy, line := range shape.lines {
+			for dx, r := range line {
+				if r != ' ' {
+					canvas.runes[y+dy][x+dx] = r
+				}
+				if shape.interior[dy][dx] == '#' {
+					canvas.fills[y+dy][x+dx] = c
+				}
+			}
+		}
+		text := []rune(centerLabel(label(pos, c)))
+		copy(canvas.runes[y+shape.labelY][x+shape.labelX:], text)
+	}
+	return canvas
+}
+
+// centerLabel fits a label into labelWidth characters.
+func centerLabel(s string) string {
+	r := []rune(s)
+	if len(r) > labelWidth {
+		return strings.Repeat("#", labelWidth)
+	}
+	pad := labelWidth - len(r)
+	return strings.Repeat(" ", pad-pad/2) + s + strings.Repeat(" ", pad/2)
+}
+
+// String joins the canvas lines, dropping blank lines at the top and bottom,
+// trailing spaces and indentation shared by every line. When style is not nil
+// it styles each run of runes inside a cell.
+func (c *asciiCanvas) String(style func(cell hex.Cell) lipgloss.Style) string {
+	ends := make([]int, len(c.runes))
+	indent := math.MaxInt
+	first, last := len(c.runes), -1
+	for y, row := range c.runes {
+		// Measure in runes: labels may hold multi-byte characters.
+		end := len(row)
+		for end > 0 && row[end-1] == ' ' {
+			end--
+		}
+		ends[y] = end
+		if end > 0 {
+			start := 0
+			for row[start] == ' ' {
+				start++
+			}
+			indent = min(indent, start)
+			first, last = min(first, y), max(last, y)
+		}
+	}
+	var b strings.Builder
+	for y := first; y <= last; y++ {
+		for x := indent; x < ends[y]; {
+			run := x + 1
+			for run < ends[y] && c.fills[y][run] == c.fills[y][x] {
+				run++
+			}
+			text := string(c.runes[y][x:run])
+			if style != nil && c.fills[y][x] != nil {
+				text = style(c.fills[y][x]).Render(text)
+			}
+			b.WriteString(text)
+			x = run
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package viz
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got with testdata/<name>.golden, rewriting the file
+// instead when the -update flag is set.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
+
+var asciiLayouts = []hex.OffsetLayout{
+	{Orientation: hex.PointyTop, Parity: hex.Odd},
+	{Orientation: hex.PointyTop, Parity: hex.Even},
+	{Orientation: hex.FlatTop, Parity: hex.Odd},
+	{Orientation: hex.FlatTop, Parity: hex.Even},
+}
+
+func layoutName(l hex.OffsetLayout) string {
+	return l.Orientation.String() + "-" + l.Parity.String()
+}
+
+// newOffsetGrid returns a grid holding the cells of a w×h rectangle in the
+// offset layout, each valued col*10+row.
+func newOffsetGrid(layout hex.OffsetLayout, w, h int) hex.Grid {
+	var positions []hex.Position
+	lo := hex.NewPosition(0, 0)
+	hi := lo
+	for row := 0; row < h; row++ {
+		for col := 0; col < w; col++ {
+			p := layout.FromOffset(hex.OffsetCoord{Col: col, Row: row})
+			positions = append(positions, p)
+			lo = hex.NewPosition(min(lo.Q, p.Q), min(lo.R, p.R))
+			hi = hex.NewPosition(max(hi.Q, p.Q), max(hi.R, p.R))
+		}
+	}
+	cells := make([][]hex.Cell, hi.R-lo.R+1)
+	for r := range cells {
+		cells[r] = make([]hex.Cell, hi.Q-lo.Q+1)
+	}
+	for _, p := range positions {
+		local := p.Sub(lo)
+		o := layout.ToOffset(p)
+		cells[local.R][local.Q] = hex.NewCellWithValue(local.Q, local.R, o.Col*10+o.Row)
+	}
+	return hex.NewGrid(lo, "offset", hi.Q-lo.Q+1, hi.R-lo.R+1, cells)
+}
+
+func TestRenderASCII_Axial(t *testing.T) {
+	// Hexes are placed by their coordinates, so both parities of an
+	// orientation draw the same picture of an axial grid.
+	for _, layout := range asciiLayouts {
+		got := RenderASCII(newTestGrid("terrain", 4, 3), ASCIIOptions{Layout: layout})
+		assertGolden(t, "axial-"+layout.Orientation.String(), got)
+	}
+}
+
+func TestRenderASCII_Offset(t *testing.T) {
+	label := func(pos hex.Position, cell hex.Cell) string {
+		return fmt.Sprintf("%d,%d", cell.GetValue()/10, cell.GetValue()%10)
+	}
+	for _, layout := range asciiLayouts {
+		t.Run(layoutName(layout), func(t *testing.T) {
+			got := RenderASCII(newOffsetGrid(layout, 4, 3), ASCIIOptions{Layout: layout, Label: label})
+			assertGolden(t, "offset-"+layoutName(layout), got)
+		})
+	}
+}
+
+func TestRenderASCII_MultiByteLabels(t *testing.T) {
+	label := func(pos hex.Position, cell hex.Cell) string {
+		if cell.GetValue()%2 == 0 {
+			return "é"
+		}
+		return "★ü"
+	}
+	got := RenderASCII(newTestGrid("terrain", 4, 3), ASCIIOptions{Label: label})
+	assertGolden(t, "multibyte-labels", got)
+	for _, line := range strings.Split(got, "\n") {
+		assert.Equal(t, strings.TrimRight(line, " "), line, "line has trailing spaces")
+	}
+}
+
+func TestRenderMapASCII(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	resources := hex.NewGrid(hex.NewPosition(1, 1), "resources", 2, 1, [][]hex.Cell{{hex.NewCellWithValue(0, 0, 1234), hex.NewCellWithValue(1, 0, 7)}})
+	require.NoError(t, m.AddGrid(resources))
+
+	got, err := RenderMapASCII(m, "resources", ASCIIOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, " / \\ / \\\n|###| 7 |\n \\ / \\ /\n", got)
+
+	got, err = RenderMapASCII(m, "", ASCIIOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, RenderASCII(newTestGrid("terrain", 4, 3), ASCIIOptions{}), got)
+
+	_, err = RenderMapASCII(m, "missing", ASCIIOptions{})
+	assert.Error(t, err)
+
+	got, err = RenderMapASCII(hex.NewMap(0, 0), "", ASCIIOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -34,7 +34,7 @@ func RenderCell(cell hex.Cell) string {
 		Border(lipgloss.NormalBorder()).
 		Padding(1, 2).
 		Align(lipgloss.Center).
-		Background(lipgloss.Color("240")) // Example background color
+		Background(lipgloss.Color(hexColor(DefaultPalette.Color(cell.GetValue()))))
 
 	cellContent := cellStyle.Render()
 
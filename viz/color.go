@@ -0,0 +1,195 @@
+package viz
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/klumhru/4hex/hex"
+	"github.com/muesli/termenv"
+)
+
+// ColorProfile is the range of colors written to a terminal.
+type ColorProfile int
+
+const (
+	// AutoColor detects the profile of standard output, honoring NO_COLOR.
+	AutoColor ColorProfile = iota
+	// TrueColor writes 24-bit colors.
+	TrueColor
+	// ANSI256 writes colors from the 256 color palette.
+	ANSI256
+	// ANSI writes the 16 basic colors.
+	ANSI
+	// NoColor writes plain text, for logs and dumb terminals.
+	NoColor
+)
+
+var colorProfileNames = map[ColorProfile]string{
+	AutoColor: "auto",
+	TrueColor: "truecolor",
+	ANSI256:   "256",
+	ANSI:      "ansi",
+	NoColor:   "none",
+}
+
+// String implements the Stringer interface for ColorProfile.
+func (p ColorProfile) String() string {
+	if name, ok := colorProfileNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("ColorProfile(%d)", int(p))
+}
+
+// ParseColorProfile returns the profile with the given name: auto, truecolor,
+// 256, ansi or none.
+func ParseColorProfile(name string) (ColorProfile, error) {
+	for p, n := range colorProfileNames {
+		if n == strings.ToLower(name) {
+			return p, nil
+		}
+	}
+	return AutoColor, fmt.Errorf("unknown color profile %q", name)
+}
+
+// DetectColorProfile returns the profile supported by the terminal behind w.
+// Writers that are not terminals get NoColor.
+func DetectColorProfile(w io.Writer) ColorProfile {
+	switch lipgloss.NewRenderer(w).ColorProfile() {
+	case termenv.TrueColor:
+		return TrueColor
+	case termenv.ANSI256:
+		return ANSI256
+	case termenv.ANSI:
+		return ANSI
+	}
+	return NoColor
+}
+
+// renderer returns a lipgloss renderer that writes colors for the profile.
+func (p ColorProfile) renderer() *lipgloss.Renderer {
+	if p == AutoColor {
+		p = DetectColorProfile(os.Stdout)
+	}
+	r := lipgloss.NewRenderer(io.Discard)
+	switch p {
+	case TrueColor:
+		r.SetColorProfile(termenv.TrueColor)
+	case ANSI256:
+		r.SetColorProfile(termenv.ANSI256)
+	case ANSI:
+		r.SetColorProfile(termenv.ANSI)
+	default:
+		r.SetColorProfile(termenv.Ascii)
+	}
+	return r
+}
+
+// ColorOptions configures colored rendering.
+type ColorOptions struct {
+	ASCIIOptions
+	// Layer names the layer to draw. Defaults to the first layer.
+	Layer string
+	// Scheme colors the layer's values. Defaults to the entry of Schemes for
+	// the layer's name, or TerrainScheme.
+	Scheme *Scheme
+	// Profile selects the colors written. Defaults to AutoColor.
+	Profile ColorProfile
+	// Legend appends a legend of the values drawn.
+	Legend bool
+	// Overlay is drawn on top of the layer.
+	Overlay *Overlay
+}
+
+// RenderColored draws a layer of m as interlocking ASCII hexes filled with
+// the colors of its values.
+func RenderColored(m hex.Map, opts ColorOptions) (string, error) {
+	grids := m.GetGrids()
+	if len(grids) == 0 {
+		return "", nil
+	}
+	g := grids[0]
+	if opts.Layer != "" {
+		var err error
+		if g, err = m.GetGridByName(opts.Layer); err != nil {
+			return "", err
+		}
+	}
+	scheme := TerrainScheme
+	if opts.Scheme != nil {
+		scheme = *opts.Scheme
+	} else if s, ok := Schemes[g.GetName()]; ok {
+		scheme = s
+	}
+
+	var cells []hex.Cell
+	values := make(map[int]bool)
+	for i := 0; i < g.GetCellCount(); i++ {
+		if cell, _ := g.GetCellAtIndex(i); cell != nil {
+			cells = append(cells, cell)
+			values[cell.GetValue()] = true
+		}
+	}
+
+	r := opts.Profile.renderer()
+	overlay := opts.Overlay.index()
+	styles := make(map[color.RGBA]lipgloss.Style)
+	style := func(cell hex.Cell) lipgloss.Style {
+		c := overlay.fill(cell.GetPosition().Add(g.GetPosition()), scheme.Palette.Color(cell.GetValue()))
+		if _, ok := styles[c]; !ok {
+			styles[c] = fillStyle(r, c)
+		}
+		return styles[c]
+	}
+	asciiOpts := opts.ASCIIOptions
+	label := asciiOpts.Label
+	asciiOpts.Label = func(pos hex.Position, cell hex.Cell) string {
+		if text, ok := overlay.label(pos); ok {
+			return text
+		}
+		if label != nil {
+			return label(pos, cell)
+		}
+		return fmt.Sprint(cell.GetValue())
+	}
+	out := drawASCII(g.GetPosition(), cells, asciiOpts).String(style)
+	if opts.Legend {
+		out += renderLegend(r, scheme, values)
+	}
+	return out, nil
+}
+
+// renderLegend lists the values drawn with a color swatch and label each.
+func renderLegend(r *lipgloss.Renderer, scheme Scheme, values map[int]bool) string {
+	sorted := make([]int, 0, len(values))
+	for v := range values {
+		sorted = append(sorted, v)
+	}
+	slices.Sort(sorted)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:\n", scheme.Name)
+	for _, v := range sorted {
+		label := scheme.Label(v)
+		if label != fmt.Sprint(v) {
+			label = fmt.Sprintf("%s (%d)", label, v)
+		}
+		fmt.Fprintf(&b, "  %s %s\n", fillStyle(r, scheme.Palette.Color(v)).Render(centerLabel(fmt.Sprint(v))), label)
+	}
+	return b.String()
+}
+
+// fillStyle fills with c, writing text in black or white, whichever is
+// easier to read on it.
+func fillStyle(r *lipgloss.Renderer, c color.RGBA) lipgloss.Style {
+	text := "#000000"
+	if 299*int(c.R)+587*int(c.G)+114*int(c.B) < 128*1000 {
+		text = "#ffffff"
+	}
+	return r.NewStyle().
+		Background(lipgloss.Color(hexColor(c))).
+		Foreground(lipgloss.Color(text))
+}
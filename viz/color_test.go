@@ -0,0 +1,83 @@
+package viz
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newColorMap(t *testing.T) hex.Map {
+	t.Helper()
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	owner := hex.NewGrid(hex.NewPosition(0, 0), "owner", 2, 1, [][]hex.Cell{{hex.NewCellWithValue(0, 0, -1), hex.NewCellWithValue(1, 0, 1)}})
+	require.NoError(t, m.AddGrid(owner))
+	return m
+}
+
+func TestRenderColored_Profiles(t *testing.T) {
+	m := newColorMap(t)
+
+	plain, err := RenderColored(m, ColorOptions{Profile: NoColor})
+	require.NoError(t, err)
+	assert.Equal(t, RenderASCII(newTestGrid("terrain", 4, 3), ASCIIOptions{}), plain)
+	assert.NotContains(t, plain, "\x1b[")
+
+	// Water is #3a6ea5.
+	truecolor, err := RenderColored(m, ColorOptions{Profile: TrueColor})
+	require.NoError(t, err)
+	assert.Contains(t, truecolor, "48;2;58;110;165")
+
+	ansi256, err := RenderColored(m, ColorOptions{Profile: ANSI256})
+	require.NoError(t, err)
+	assert.Contains(t, ansi256, "48;5;")
+	assert.NotContains(t, ansi256, "48;2;")
+
+	ansi, err := RenderColored(m, ColorOptions{Profile: ANSI})
+	require.NoError(t, err)
+	assert.Contains(t, ansi, "\x1b[")
+	assert.NotContains(t, ansi, "48;5;")
+	assert.NotContains(t, ansi, "48;2;")
+}
+
+func TestRenderColored_Legend(t *testing.T) {
+	m := newColorMap(t)
+
+	out, err := RenderColored(m, ColorOptions{Profile: NoColor, Legend: true})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(out, "terrain:\n   0  water (0)\n   1  land (1)\n"), out)
+
+	// The owner layer picks the owner scheme from its name.
+	out, err = RenderColored(m, ColorOptions{Profile: NoColor, Layer: "owner", Legend: true})
+	require.NoError(t, err)
+	assert.Contains(t, out, "owner:\n   -1 none (-1)\n   1  1\n")
+
+	scheme := Scheme{Name: "custom", Labels: map[int]string{1: "one"}}
+	out, err = RenderColored(m, ColorOptions{Profile: NoColor, Layer: "owner", Scheme: &scheme, Legend: true})
+	require.NoError(t, err)
+	assert.Contains(t, out, "custom:\n   -1 -1\n   1  one (1)\n")
+
+	_, err = RenderColored(m, ColorOptions{Layer: "missing"})
+	assert.Error(t, err)
+}
+
+func TestParseColorProfile(t *testing.T) {
+	for _, p := range []ColorProfile{AutoColor, TrueColor, ANSI256, ANSI, NoColor} {
+		parsed, err := ParseColorProfile(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, parsed)
+	}
+	p, err := ParseColorProfile("TrueColor")
+	require.NoError(t, err)
+	assert.Equal(t, TrueColor, p)
+	_, err = ParseColorProfile("sepia")
+	assert.Error(t, err)
+}
+
+func TestDetectColorProfile(t *testing.T) {
+	// Buffers are not terminals.
+	assert.Equal(t, NoColor, DetectColorProfile(&strings.Builder{}))
+}
@@ -0,0 +1,32 @@
+package viz
+
+import "github.com/klumhru/4hex/hex"
+
+// Glyphs marking changed hexes in a DiffOverlay.
+const (
+	AddedGlyph   = '+'
+	ChangedGlyph = '*'
+)
+
+// DiffOverlay highlights the changes of one layer for drawing over the after
+// map: changed hexes are highlighted and, when marks is set, labelled with
+// AddedGlyph or ChangedGlyph instead of their value, which keeps them visible
+// without color. Removed cells have no hex to draw on and are left out.
+func DiffOverlay(d hex.LayerDiff, marks bool) *Overlay {
+	o := &Overlay{}
+	for _, c := range d.Changes {
+		if c.Removed() {
+			continue
+		}
+		o.Highlight = append(o.Highlight, c.Position)
+		if !marks {
+			continue
+		}
+		glyph := ChangedGlyph
+		if c.Added() {
+			glyph = AddedGlyph
+		}
+		o.Marks = append(o.Marks, Mark{Position: c.Position, Glyph: glyph})
+	}
+	return o
+}
@@ -0,0 +1,41 @@
+package viz
+
+import (
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffOverlay(t *testing.T) {
+	before := hex.NewMap(4, 3)
+	require.NoError(t, before.AddGrid(newTestGrid("terrain", 4, 3)))
+	after := hex.NewMap(4, 3)
+	cells := make([][]hex.Cell, 3)
+	for r := range cells {
+		cells[r] = make([]hex.Cell, 4)
+	}
+	require.NoError(t, newTestGrid("terrain", 4, 3).CopyCellsTo(cells))
+	// Change (1,1), add the missing (0,0) and remove (3,2).
+	cells[1][1] = hex.NewCellWithValue(1, 1, 5)
+	cells[0][0] = hex.NewCellWithValue(0, 0, 2)
+	cells[2][3] = nil
+	require.NoError(t, after.AddGrid(hex.NewGrid(hex.NewPosition(0, 0), "terrain", 4, 3, cells)))
+
+	d, err := hex.DiffMaps(before, after)
+	require.NoError(t, err)
+	changes, ok := d.Layer("terrain")
+	require.True(t, ok)
+	require.Len(t, changes.Changes, 3)
+
+	o := DiffOverlay(changes, true)
+	assert.Equal(t, []hex.Position{{Q: 0, R: 0}, {Q: 1, R: 1}}, o.Highlight)
+	assert.Empty(t, o.Reachable)
+	assert.Equal(t, []Mark{{Position: hex.Position{Q: 0, R: 0}, Glyph: AddedGlyph}, {Position: hex.Position{Q: 1, R: 1}, Glyph: ChangedGlyph}}, o.Marks)
+	assert.Empty(t, DiffOverlay(changes, false).Marks)
+
+	plain, err := RenderColored(after, ColorOptions{Profile: NoColor, Overlay: o})
+	require.NoError(t, err)
+	assert.Equal(t, " / \\ / \\ / \\ / \\\n| + | 1 | 0 | 1 |\n \\ / \\ / \\ / \\ / \\\n  | 1 | * | 1 | 0 |\n   \\ / \\ / \\ / \\ /\n    | 0 | 1 | 0 |\n     \\ / \\ / \\ /\n", plain)
+}
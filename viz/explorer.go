@@ -0,0 +1,263 @@
+package viz
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/klumhru/4hex/hex"
+)
+
+// KeyType identifies a key press.
+type KeyType int
+
+const (
+	// KeyRune is a printable key; KeyMsg.Rune holds the character.
+	KeyRune KeyType = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyEscape
+	KeyCtrlC
+)
+
+// KeyMsg reports a key press to the explorer.
+type KeyMsg struct {
+	Type KeyType
+	Rune rune
+}
+
+// ResizeMsg reports the terminal size to the explorer.
+type ResizeMsg struct {
+	Width  int
+	Height int
+}
+
+// cursorKeys maps keys to Directions. They mirror the directions' places on a
+// QWERTY keyboard around the s key: w e / a d / z x.
+var cursorKeys = map[rune]int{'d': 0, 'e': 1, 'w': 2, 'a': 3, 'z': 4, 'x': 5}
+
+const (
+	// cellWidth is the number of columns a hex takes on screen.
+	cellWidth = 4
+	// panelWidth is the width of the side panel, including its border.
+	panelWidth = 30
+)
+
+// Explorer is an interactive view of a map. It follows the model/update/view
+// pattern: Update applies a message to the state and View renders it, so the
+// explorer can be driven by RunExplorer or by tests.
+type Explorer struct {
+	gameMap hex.Map
+	layout  hex.OffsetLayout
+	hidden  map[int]bool // layer indices toggled off; layers added later start visible
+	cursor  hex.Position
+	// origin is the offset coordinate at the top-left of the viewport.
+	origin hex.OffsetCoord
+	// lo and hi bound the map in offset coordinates.
+	lo, hi        hex.OffsetCoord
+	width, height int
+	quit          bool
+}
+
+// NewExplorer creates an explorer for m with all layers visible and the
+// cursor on the first cell.
+func NewExplorer(m hex.Map) *Explorer {
+	e := &Explorer{
+		gameMap: m,
+		layout:  hex.OffsetLayout{Orientation: hex.PointyTop, Parity: hex.Odd},
+		hidden:  make(map[int]bool),
+		width:   80,
+		height:  24,
+	}
+	fills := collectFills(m.GetGrids(), nil)
+	e.lo = hex.OffsetCoord{Col: math.MaxInt, Row: math.MaxInt}
+	e.hi = hex.OffsetCoord{Col: math.MinInt, Row: math.MinInt}
+	for _, f := range fills {
+		o := e.layout.ToOffset(f.Position)
+		e.lo.Col, e.lo.Row = min(e.lo.Col, o.Col), min(e.lo.Row, o.Row)
+		e.hi.Col, e.hi.Row = max(e.hi.Col, o.Col), max(e.hi.Row, o.Row)
+	}
+	if len(fills) == 0 {
+		e.lo, e.hi = hex.OffsetCoord{}, hex.OffsetCoord{}
+	} else {
+		e.cursor = fills[0].Position
+	}
+	e.origin = e.lo
+	return e
+}
+
+// Cursor returns the selected position.
+func (e *Explorer) Cursor() hex.Position {
+	return e.cursor
+}
+
+// LayerVisible reports whether the layer at index i is drawn.
+func (e *Explorer) LayerVisible(i int) bool {
+	return i >= 0 && i < len(e.gameMap.GetGrids()) && !e.hidden[i]
+}
+
+// Quit reports whether the user asked to leave.
+func (e *Explorer) Quit() bool {
+	return e.quit
+}
+
+// Update applies a KeyMsg or ResizeMsg. Arrow keys pan the view, w e a d z x
+// move the cursor to a neighbor, digits toggle layers and q or Esc quits.
+func (e *Explorer) Update(msg any) {
+	switch msg := msg.(type) {
+	case ResizeMsg:
+		e.width, e.height = msg.Width, msg.Height
+		e.follow()
+	case KeyMsg:
+		switch msg.Type {
+		case KeyUp:
+			e.pan(0, -1)
+		case KeyDown:
+			e.pan(0, 1)
+		case KeyLeft:
+			e.pan(-1, 0)
+		case KeyRight:
+			e.pan(1, 0)
+		case KeyEscape, KeyCtrlC:
+			e.quit = true
+		case KeyRune:
+			if dir, ok := cursorKeys[msg.Rune]; ok {
+				e.move(dir)
+			} else if msg.Rune >= '1' && msg.Rune <= '9' {
+				if i := int(msg.Rune - '1'); i < len(e.gameMap.GetGrids()) {
+					e.hidden[i] = !e.hidden[i]
+				}
+			} else if msg.Rune == 'q' {
+				e.quit = true
+			}
+		}
+	}
+}
+
+// viewport returns the number of hex columns and rows that fit on screen.
+func (e *Explorer) viewport() (int, int) {
+	cols := (e.width - panelWidth - cellWidth/2) / cellWidth
+	rows := e.height - 2
+	return max(cols, 1), max(rows, 1)
+}
+
+// pan moves the viewport, keeping it over the map.
+func (e *Explorer) pan(dc, dr int) {
+	cols, rows := e.viewport()
+	e.origin.Col = clamp(e.origin.Col+dc, e.lo.Col, max(e.lo.Col, e.hi.Col-cols+1))
+	e.origin.Row = clamp(e.origin.Row+dr, e.lo.Row, max(e.lo.Row, e.hi.Row-rows+1))
+}
+
+// move steps the cursor to a neighbor inside the map's bounds.
+func (e *Explorer) move(dir int) {
+	next := e.cursor.Neighbor(dir)
+	o := e.layout.ToOffset(next)
+	if o.Col < e.lo.Col || o.Col > e.hi.Col || o.Row < e.lo.Row || o.Row > e.hi.Row {
+		return
+	}
+	e.cursor = next
+	e.follow()
+}
+
+// follow pans the viewport so the cursor is visible.
+func (e *Explorer) follow() {
+	cols, rows := e.viewport()
+	o := e.layout.ToOffset(e.cursor)
+	if o.Col < e.origin.Col {
+		e.origin.Col = o.Col
+	} else if o.Col >= e.origin.Col+cols {
+		e.origin.Col = o.Col - cols + 1
+	}
+	if o.Row < e.origin.Row {
+		e.origin.Row = o.Row
+	} else if o.Row >= e.origin.Row+rows {
+		e.origin.Row = o.Row - rows + 1
+	}
+	e.pan(0, 0)
+}
+
+// View renders the map viewport beside the side panel, with a title and help line.
+func (e *Explorer) View() string {
+	cols, rows := e.viewport()
+	lines := make([]string, rows)
+	for i := range lines {
+		row := e.origin.Row + i
+		var b strings.Builder
+		if e.layout.Shoved(row) {
+			b.WriteString(strings.Repeat(" ", cellWidth/2))
+		}
+		for col := e.origin.Col; col < e.origin.Col+cols; col++ {
+			pos := e.layout.FromOffset(hex.OffsetCoord{Col: col, Row: row})
+			glyph := e.glyph(pos)
+			if pos == e.cursor {
+				b.WriteString("[" + glyph + "]")
+			} else {
+				b.WriteString(" " + glyph + " ")
+			}
+		}
+		lines[i] = b.String()
+	}
+	mapView := lipgloss.NewStyle().
+		Width(e.width - panelWidth).
+		MaxWidth(e.width - panelWidth).
+		Render(strings.Join(lines, "\n"))
+
+	title := fmt.Sprintf("4hex explorer  cursor %d,%d", e.cursor.Q, e.cursor.R)
+	help := "arrows pan  w e a d z x move  1-9 layers  q quit"
+	return title + "\n" + lipgloss.JoinHorizontal(lipgloss.Top, mapView, e.panel()) + "\n" + help
+}
+
+// glyph draws the value of the top-most visible layer at pos in two columns.
+func (e *Explorer) glyph(pos hex.Position) string {
+	grids := e.gameMap.GetGrids()
+	for i := len(grids) - 1; i >= 0; i-- {
+		if e.hidden[i] {
+			continue
+		}
+		if cell := layerCell(grids[i], pos); cell != nil {
+			style := lipgloss.NewStyle().Foreground(lipgloss.Color(hexColor(DefaultPalette.Color(cell.GetValue()))))
+			return style.Render(valueGlyph(cell.GetValue()))
+		}
+	}
+	return " ."
+}
+
+// panel lists every layer with its visibility and its value under the cursor.
+func (e *Explorer) panel() string {
+	o := e.layout.ToOffset(e.cursor)
+	lines := []string{
+		fmt.Sprintf("Cell  %d,%d", e.cursor.Q, e.cursor.R),
+		fmt.Sprintf("Offset %d,%d", o.Col, o.Row),
+		"",
+	}
+	for i, g := range e.gameMap.GetGrids() {
+		mark := " "
+		if !e.hidden[i] {
+			mark = "x"
+		}
+		value := "-"
+		if cell := layerCell(g, e.cursor); cell != nil {
+			value = fmt.Sprint(cell.GetValue())
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %d %-12.12s %s", mark, i+1, g.GetName(), value))
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Width(panelWidth - 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+// valueGlyph formats a value in two columns.
+func valueGlyph(v int) string {
+	if v < -9 || v > 99 {
+		return "##"
+	}
+	return fmt.Sprintf("%2d", v)
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
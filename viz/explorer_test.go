@@ -0,0 +1,125 @@
+package viz
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExplorer(t *testing.T, w, h int) *Explorer {
+	t.Helper()
+	m := hex.NewMap(w, h)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", w, h)))
+	resources := hex.NewGrid(hex.NewPosition(1, 1), "resources", 1, 1, [][]hex.Cell{{hex.NewCellWithValue(0, 0, 42)}})
+	require.NoError(t, m.AddGrid(resources))
+	return NewExplorer(m)
+}
+
+func TestExplorer_MoveCursor(t *testing.T) {
+	e := newTestExplorer(t, 4, 3)
+	// The top-left cell is missing, so the first cell is (1, 0).
+	assert.Equal(t, hex.NewPosition(1, 0), e.Cursor())
+
+	e.Update(KeyMsg{Type: KeyRune, Rune: 'x'})
+	assert.Equal(t, hex.NewPosition(1, 1), e.Cursor())
+	e.Update(KeyMsg{Type: KeyRune, Rune: 'd'})
+	assert.Equal(t, hex.NewPosition(2, 1), e.Cursor())
+	e.Update(KeyMsg{Type: KeyRune, Rune: 'w'})
+	assert.Equal(t, hex.NewPosition(2, 0), e.Cursor())
+
+	// Moving off the top edge is ignored.
+	e.Update(KeyMsg{Type: KeyRune, Rune: 'e'})
+	assert.Equal(t, hex.NewPosition(2, 0), e.Cursor())
+}
+
+func TestExplorer_ToggleLayers(t *testing.T) {
+	e := newTestExplorer(t, 4, 3)
+	e.Update(KeyMsg{Type: KeyRune, Rune: 'x'})
+	view := e.View()
+	assert.Contains(t, view, "[42]")
+	assert.Contains(t, view, "[x] 2 resources")
+
+	e.Update(KeyMsg{Type: KeyRune, Rune: '2'})
+	assert.False(t, e.LayerVisible(1))
+	view = e.View()
+	assert.NotContains(t, view, "42]")
+	assert.Contains(t, view, "[ 0]")
+	assert.Contains(t, view, "[ ] 2 resources")
+	// The panel still shows hidden layer data.
+	assert.Contains(t, view, "resources    42")
+
+	e.Update(KeyMsg{Type: KeyRune, Rune: '9'})
+	assert.True(t, e.LayerVisible(0))
+}
+
+func TestExplorer_LayerAddedLater(t *testing.T) {
+	e := newTestExplorer(t, 4, 3)
+	units := hex.NewGrid(hex.NewPosition(1, 0), "units", 1, 1, [][]hex.Cell{{hex.NewCellWithValue(0, 0, 7)}})
+	require.NoError(t, e.gameMap.AddGrid(units))
+	assert.True(t, e.LayerVisible(2))
+	assert.Contains(t, e.View(), "[ 7]")
+	assert.Contains(t, e.View(), "[x] 3 units")
+
+	e.Update(KeyMsg{Type: KeyRune, Rune: '3'})
+	assert.False(t, e.LayerVisible(2))
+	assert.Contains(t, e.View(), "[ ] 3 units")
+}
+
+func TestExplorer_Pan(t *testing.T) {
+	e := newTestExplorer(t, 40, 40)
+	e.Update(ResizeMsg{Width: 60, Height: 12})
+	cols, rows := e.viewport()
+	require.Less(t, cols, 40)
+	require.Less(t, rows, 40)
+
+	e.Update(KeyMsg{Type: KeyUp})
+	e.Update(KeyMsg{Type: KeyLeft})
+	origin := e.origin
+	e.Update(KeyMsg{Type: KeyRight})
+	e.Update(KeyMsg{Type: KeyDown})
+	assert.Equal(t, origin.Col+1, e.origin.Col)
+	assert.Equal(t, origin.Row+1, e.origin.Row)
+
+	// Panning stops at the map's edge.
+	for range 100 {
+		e.Update(KeyMsg{Type: KeyDown})
+	}
+	assert.Equal(t, e.hi.Row-rows+1, e.origin.Row)
+
+	// Moving the cursor scrolls it back into view.
+	for range 5 {
+		e.Update(KeyMsg{Type: KeyRune, Rune: 'x'})
+	}
+	o := e.layout.ToOffset(e.Cursor())
+	assert.GreaterOrEqual(t, o.Row, e.origin.Row)
+	assert.Less(t, o.Row, e.origin.Row+rows)
+
+	lines := strings.Split(e.View(), "\n")
+	assert.Len(t, lines, rows+2)
+}
+
+func TestExplorer_Quit(t *testing.T) {
+	for _, key := range []KeyMsg{{Type: KeyRune, Rune: 'q'}, {Type: KeyEscape}, {Type: KeyCtrlC}} {
+		e := newTestExplorer(t, 4, 3)
+		assert.False(t, e.Quit())
+		e.Update(key)
+		assert.True(t, e.Quit())
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("\x1b[A\x1b[Bx\x1bOC\x1b[D\x03é\x1b"))
+	assert.Equal(t, []KeyMsg{
+		{Type: KeyUp},
+		{Type: KeyDown},
+		{Type: KeyRune, Rune: 'x'},
+		{Type: KeyRight},
+		{Type: KeyLeft},
+		{Type: KeyCtrlC},
+		{Type: KeyRune, Rune: 'é'},
+		{Type: KeyEscape},
+	}, keys)
+}
@@ -0,0 +1,158 @@
+package viz
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// Overlay is drawn on top of a map to show computed data such as AI paths,
+// movement ranges and influence. Positions are map positions; positions the
+// drawn layer has no cell for are skipped.
+type Overlay struct {
+	// Path is highlighted with each hex labelled by its step number.
+	Path []hex.Position
+	// Reachable hexes are tinted.
+	Reachable []hex.Position
+	// Highlight hexes are tinted in a stronger color than Reachable, for
+	// drawing attention to hexes such as the changes of a map diff.
+	Highlight []hex.Position
+	// Marks replace the label of their hex with a glyph.
+	Marks []Mark
+	// Heatmap fills hexes with a color from Gradient, scaled between the
+	// lowest and highest value.
+	Heatmap map[hex.Position]float64
+	// Gradient colors the heatmap. Defaults to DefaultGradient.
+	Gradient Gradient
+}
+
+// Mark is a glyph drawn on a position.
+type Mark struct {
+	Position hex.Position
+	Glyph    rune
+}
+
+// Gradient is a sequence of evenly spaced color stops.
+type Gradient []color.RGBA
+
+// DefaultGradient runs from cold blue through yellow to hot red.
+var DefaultGradient = Gradient{
+	{R: 0x31, G: 0x36, B: 0x95, A: 0xff},
+	{R: 0xff, G: 0xff, B: 0xbf, A: 0xff},
+	{R: 0xa5, G: 0x00, B: 0x26, A: 0xff},
+}
+
+// Colors used to highlight overlays.
+var (
+	pathColor      = color.RGBA{R: 0xd6, G: 0x27, B: 0x28, A: 0xff}
+	reachableColor = color.RGBA{R: 0xff, G: 0xe0, B: 0x66, A: 0xff}
+	highlightColor = color.RGBA{R: 0xe0, G: 0x3c, B: 0xe0, A: 0xff}
+)
+
+// At returns the color at t in [0,1], interpolating between stops.
+func (g Gradient) At(t float64) color.RGBA {
+	if len(g) == 0 {
+		return color.RGBA{}
+	}
+	t = math.Max(0, math.Min(1, t))
+	scaled := t * float64(len(g)-1)
+	i := int(scaled)
+	if i >= len(g)-1 {
+		return g[len(g)-1]
+	}
+	return blend(g[i], g[i+1], scaled-float64(i))
+}
+
+// blend mixes a and b, with t = 0 giving a and t = 1 giving b.
+func blend(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+}
+
+// overlayIndex looks up an overlay by position.
+type overlayIndex struct {
+	path      map[hex.Position]int
+	reachable map[hex.Position]bool
+	highlight map[hex.Position]bool
+	marks     map[hex.Position]rune
+	heat      map[hex.Position]color.RGBA
+}
+
+// index prepares the overlay for drawing. A nil overlay draws nothing.
+func (o *Overlay) index() *overlayIndex {
+	idx := &overlayIndex{
+		path:      make(map[hex.Position]int),
+		reachable: make(map[hex.Position]bool),
+		highlight: make(map[hex.Position]bool),
+		marks:     make(map[hex.Position]rune),
+		heat:      make(map[hex.Position]color.RGBA),
+	}
+	if o == nil {
+		return idx
+	}
+	for i, p := range o.Path {
+		idx.path[p] = i
+	}
+	for _, p := range o.Reachable {
+		idx.reachable[p] = true
+	}
+	for _, p := range o.Highlight {
+		idx.highlight[p] = true
+	}
+	for _, m := range o.Marks {
+		idx.marks[m.Position] = m.Glyph
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range o.Heatmap {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	gradient := o.Gradient
+	if len(gradient) == 0 {
+		gradient = DefaultGradient
+	}
+	for p, v := range o.Heatmap {
+		t := 0.5
+		if hi > lo {
+			t = (v - lo) / (hi - lo)
+		}
+		idx.heat[p] = gradient.At(t)
+	}
+	return idx
+}
+
+// fill returns the color of a hex at p whose layer color is base.
+func (idx *overlayIndex) fill(p hex.Position, base color.RGBA) color.RGBA {
+	if _, ok := idx.path[p]; ok {
+		return pathColor
+	}
+	return idx.tint(p, base)
+}
+
+// tint is fill for renderers that draw the path as a line.
+func (idx *overlayIndex) tint(p hex.Position, base color.RGBA) color.RGBA {
+	if c, ok := idx.heat[p]; ok {
+		base = c
+	}
+	if idx.highlight[p] {
+		return blend(base, highlightColor, 0.7)
+	}
+	if idx.reachable[p] {
+		return blend(base, reachableColor, 0.6)
+	}
+	return base
+}
+
+// label returns the text overlaid on p, if any.
+func (idx *overlayIndex) label(p hex.Position) (string, bool) {
+	if g, ok := idx.marks[p]; ok {
+		return string(g), true
+	}
+	if step, ok := idx.path[p]; ok {
+		return strconv.Itoa(step), true
+	}
+	return "", false
+}
@@ -0,0 +1,116 @@
+package viz
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGradient_At(t *testing.T) {
+	g := Gradient{{R: 0, A: 0xff}, {R: 100, A: 0xff}, {R: 200, B: 50, A: 0xff}}
+	assert.Equal(t, g[0], g.At(0))
+	assert.Equal(t, g[1], g.At(0.5))
+	assert.Equal(t, g[2], g.At(1))
+	assert.Equal(t, color.RGBA{R: 50, A: 0xff}, g.At(0.25))
+	assert.Equal(t, color.RGBA{R: 150, B: 25, A: 0xff}, g.At(0.75))
+	// Values outside [0,1] are clamped.
+	assert.Equal(t, g[0], g.At(-3))
+	assert.Equal(t, g[2], g.At(7))
+	assert.Equal(t, color.RGBA{}, Gradient{}.At(0.5))
+}
+
+func TestOverlay_Index(t *testing.T) {
+	o := &Overlay{
+		Path:      []hex.Position{{Q: 0, R: 0}, {Q: 1, R: 0}},
+		Reachable: []hex.Position{{Q: 2, R: 0}, {Q: 3, R: 0}, {Q: 2, R: 1}},
+		Highlight: []hex.Position{{Q: 2, R: 1}},
+		Marks:     []Mark{{Position: hex.Position{Q: 1, R: 0}, Glyph: '@'}},
+		Heatmap:   map[hex.Position]float64{{Q: 3, R: 0}: 10, {Q: 4, R: 0}: 20, {Q: 5, R: 0}: 0},
+	}
+	idx := o.index()
+	base := color.RGBA{R: 1, G: 2, B: 3, A: 0xff}
+
+	assert.Equal(t, pathColor, idx.fill(hex.Position{Q: 0, R: 0}, base))
+	assert.Equal(t, base, idx.tint(hex.Position{Q: 0, R: 0}, base))
+	assert.Equal(t, blend(base, reachableColor, 0.6), idx.fill(hex.Position{Q: 2, R: 0}, base))
+	assert.Equal(t, DefaultGradient.At(1), idx.fill(hex.Position{Q: 4, R: 0}, base))
+	assert.Equal(t, DefaultGradient.At(0), idx.fill(hex.Position{Q: 5, R: 0}, base))
+	// Reachable tints the heatmap color.
+	assert.Equal(t, blend(DefaultGradient.At(0.5), reachableColor, 0.6), idx.fill(hex.Position{Q: 3, R: 0}, base))
+	assert.Equal(t, base, idx.fill(hex.Position{Q: 9, R: 9}, base))
+	// Highlight wins over reachable.
+	assert.Equal(t, blend(base, highlightColor, 0.7), idx.fill(hex.Position{Q: 2, R: 1}, base))
+
+	text, ok := idx.label(hex.Position{Q: 0, R: 0})
+	assert.True(t, ok)
+	assert.Equal(t, "0", text)
+	// Marks win over path steps.
+	text, _ = idx.label(hex.Position{Q: 1, R: 0})
+	assert.Equal(t, "@", text)
+	_, ok = idx.label(hex.Position{Q: 2, R: 0})
+	assert.False(t, ok)
+
+	var none *Overlay
+	assert.Equal(t, base, none.index().fill(hex.Position{}, base))
+}
+
+func TestRenderColored_Overlay(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	overlay := &Overlay{
+		Path:    []hex.Position{{Q: 1, R: 0}, {Q: 1, R: 1}, {Q: 1, R: 2}},
+		Marks:   []Mark{{Position: hex.Position{Q: 3, R: 2}, Glyph: 'X'}},
+		Heatmap: map[hex.Position]float64{{Q: 0, R: 1}: -5, {Q: 3, R: 0}: 5},
+	}
+
+	plain, err := RenderColored(m, ColorOptions{Profile: NoColor, Overlay: overlay})
+	require.NoError(t, err)
+	assert.Equal(t, "   / \\ / \\ / \\\n  | 0 | 0 | 1 |\n / \\ / \\ / \\ / \\\n| 1 | 1 | 1 | 0 |\n \\ / \\ / \\ / \\ / \\\n  | 0 | 2 | 0 | X |\n   \\ / \\ / \\ / \\ /\n", plain)
+
+	colored, err := RenderColored(m, ColorOptions{Profile: TrueColor, Overlay: overlay})
+	require.NoError(t, err)
+	for _, c := range []color.RGBA{pathColor, DefaultGradient.At(1)} {
+		assert.Contains(t, colored, fmt.Sprintf("48;2;%d;%d;%d", c.R, c.G, c.B))
+	}
+}
+
+func TestRenderColored_NonASCIIMark(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	overlay := &Overlay{Marks: []Mark{{Position: hex.Position{Q: 0, R: 1}, Glyph: '★'}, {Position: hex.Position{Q: 3, R: 2}, Glyph: 'É'}}}
+
+	plain, err := RenderColored(m, ColorOptions{Profile: NoColor, Overlay: overlay})
+	require.NoError(t, err)
+	assert.Equal(t, "   / \\ / \\ / \\\n  | 1 | 0 | 1 |\n / \\ / \\ / \\ / \\\n| ★ | 0 | 1 | 0 |\n \\ / \\ / \\ / \\ / \\\n  | 0 | 1 | 0 | É |\n   \\ / \\ / \\ / \\ /\n", plain)
+
+	colored, err := RenderColored(m, ColorOptions{Profile: TrueColor, Overlay: overlay})
+	require.NoError(t, err)
+	assert.Contains(t, colored, "★")
+	assert.Contains(t, colored, "É")
+}
+
+func TestRenderSVG_Overlay(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	overlay := &Overlay{
+		Path:      []hex.Position{{Q: 1, R: 0}, {Q: 1, R: 1}},
+		Reachable: []hex.Position{{Q: 2, R: 2}},
+		Marks:     []Mark{{Position: hex.Position{Q: 3, R: 2}, Glyph: '&'}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderSVG(&buf, m, SVGOptions{Overlay: overlay}))
+	out := buf.String()
+	counts := svgElements(t, buf.Bytes())
+	assert.Equal(t, 1, counts["polyline"])
+	assert.Equal(t, 3, counts["text"])
+	assert.Contains(t, out, ">&amp;</text>")
+	assert.Contains(t, out, hexColor(blend(DefaultPalette.Color(0), reachableColor, 0.6)))
+	assert.Equal(t, 1, strings.Count(out, `stroke="`+hexColor(pathColor)))
+}
@@ -0,0 +1,124 @@
+package viz
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/klumhru/4hex/generator"
+)
+
+// Palette maps cell values, such as terrain ids or shape colors, to colors.
+// Values without an entry get a stable generated color.
+type Palette map[int]color.RGBA
+
+// DefaultPalette colors the terrain produced by the generator package.
+var DefaultPalette = Palette{
+	generator.Water: {R: 0x3a, G: 0x6e, B: 0xa5, A: 0xff},
+	generator.Land:  {R: 0x7f, G: 0xb0, B: 0x69, A: 0xff},
+}
+
+// emptyColor fills hexes that have no value in the colored layer.
+var emptyColor = color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff}
+
+// Color returns the color for a value.
+func (p Palette) Color(value int) color.RGBA {
+	if c, ok := p[value]; ok {
+		return c
+	}
+	// Step the hue by the golden angle so neighboring values differ clearly.
+	hue := math.Mod(float64(value)*137.508, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	return hsv(hue, 0.55, 0.85)
+}
+
+// hsv converts a hue in degrees and saturation and value in [0,1] to a color.
+func hsv(h, s, v float64) color.RGBA {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
+
+// hexColor formats a color as #rrggbb.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Scheme is a palette for one kind of layer, with the labels its legend uses.
+type Scheme struct {
+	Name    string
+	Palette Palette
+	Labels  map[int]string
+}
+
+// Label returns the legend label for a value.
+func (s Scheme) Label(value int) string {
+	if label, ok := s.Labels[value]; ok {
+		return label
+	}
+	return fmt.Sprint(value)
+}
+
+// Schemes for the layers the generator and game packages produce.
+var (
+	TerrainScheme = Scheme{
+		Name:    "terrain",
+		Palette: DefaultPalette,
+		Labels:  map[int]string{generator.Water: "water", generator.Land: "land"},
+	}
+	OwnerScheme = Scheme{
+		Name: "owner",
+		Palette: Palette{
+			-1: {R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+			0:  {R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+			1:  {R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+			2:  {R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+			3:  {R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+			4:  {R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+			5:  {R: 0x17, G: 0xbe, B: 0xcf, A: 0xff},
+		},
+		Labels: map[int]string{-1: "none"},
+	}
+	ResourceScheme = Scheme{
+		Name: "resource",
+		Palette: Palette{
+			0: {R: 0x40, G: 0x40, B: 0x40, A: 0xff},
+			1: {R: 0x8c, G: 0x6d, B: 0x31, A: 0xff},
+			2: {R: 0xbd, G: 0x9e, B: 0x39, A: 0xff},
+			3: {R: 0xe7, G: 0xcb, B: 0x94, A: 0xff},
+		},
+		Labels: map[int]string{0: "none"},
+	}
+)
+
+// Schemes maps layer names to the scheme used to color them by default.
+var Schemes = map[string]Scheme{
+	"terrain":   TerrainScheme,
+	"owner":     OwnerScheme,
+	"owners":    OwnerScheme,
+	"resource":  ResourceScheme,
+	"resources": ResourceScheme,
+}
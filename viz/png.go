@@ -0,0 +1,123 @@
+package viz
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+)
+
+// samples is the number of subpixel samples per axis used for anti-aliasing.
+const samples = 4
+
+// ImageOptions configures raster rendering.
+type ImageOptions struct {
+	// Orientation selects pointy or flat topped hexes.
+	Orientation hex.Orientation
+	// Scale is the hex radius in pixels. Defaults to 8.
+	Scale float64
+	// Margin is the space around the map in pixels.
+	Margin int
+	// Layer names the map layer whose values color the hexes. Defaults to the
+	// first layer.
+	Layer string
+	// Palette maps values to fill colors. Defaults to DefaultPalette.
+	Palette Palette
+	// Background fills pixels outside the map. Defaults to transparent.
+	Background color.RGBA
+}
+
+// ShapePalette builds a Palette for grids whose values are shape colors, as
+// produced by generator.GridFromShape.
+func ShapePalette(colors map[shapes.Color]color.RGBA) Palette {
+	p := make(Palette, len(colors))
+	for k, c := range colors {
+		p[int(k)] = c
+	}
+	return p
+}
+
+// RenderImage draws m into a new image, sized to fit the map.
+func RenderImage(m hex.Map, opts ImageOptions) (*image.RGBA, error) {
+	fills, err := mapFills(m, opts.Layer)
+	if err != nil {
+		return nil, err
+	}
+	return renderImage(fills, opts), nil
+}
+
+// RenderGridImage draws a single grid, colored by its values.
+func RenderGridImage(g hex.Grid, opts ImageOptions) *image.RGBA {
+	return renderImage(collectFills([]hex.Grid{g}, g), opts)
+}
+
+// EncodePNG draws m and writes it as a PNG.
+func EncodePNG(w io.Writer, m hex.Map, opts ImageOptions) error {
+	img, err := RenderImage(m, opts)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return nil
+}
+
+func renderImage(fills []hexFill, opts ImageOptions) *image.RGBA {
+	if opts.Scale <= 0 {
+		opts.Scale = 8
+	}
+	if opts.Palette == nil {
+		opts.Palette = DefaultPalette
+	}
+	margin := float64(max(opts.Margin, 0))
+	layout := hex.NewLayout(opts.Orientation, opts.Scale)
+	lo, hi := layout.Bounds(fillPositions(fills))
+	layout.Origin = hex.Point{X: margin - lo.X, Y: margin - lo.Y}
+	width := int(math.Ceil(hi.X - lo.X + 2*margin))
+	height := int(math.Ceil(hi.Y - lo.Y + 2*margin))
+
+	colors := make(map[hex.Position]color.RGBA, len(fills))
+	for _, f := range fills {
+		if f.Set {
+			colors[f.Position] = opts.Palette.Color(f.Value)
+		} else {
+			colors[f.Position] = emptyColor
+		}
+	}
+
+	// Each pixel averages the colors of the hexes under its subpixel samples,
+	// which anti-aliases both the map border and edges between colors.
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b, a int
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					pt := hex.Point{
+						X: float64(x) + (float64(sx)+0.5)/samples,
+						Y: float64(y) + (float64(sy)+0.5)/samples,
+					}
+					c, ok := colors[layout.PixelToHex(pt)]
+					if !ok {
+						c = opts.Background
+					}
+					r, g, b, a = r+int(c.R), g+int(c.G), b+int(c.B), a+int(c.A)
+				}
+			}
+			n := samples * samples
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + n/2) / n),
+				G: uint8((g + n/2) / n),
+				B: uint8((b + n/2) / n),
+				A: uint8((a + n/2) / n),
+			})
+		}
+	}
+	return img
+}
@@ -0,0 +1,97 @@
+package viz
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+	"github.com/klumhru/4hex/shapes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderGridImage(t *testing.T) {
+	for _, o := range []hex.Orientation{hex.PointyTop, hex.FlatTop} {
+		g := newTestGrid("terrain", 4, 3)
+		opts := ImageOptions{Orientation: o, Scale: 10}
+		img := RenderGridImage(g, opts)
+
+		layout := hex.NewLayout(o, 10)
+		lo, _ := layout.Bounds([]hex.Position{{Q: 1, R: 0}, {Q: 0, R: 1}, {Q: 3, R: 2}})
+		layout.Origin = hex.Point{X: -lo.X, Y: -lo.Y}
+
+		// Hex centers have their exact palette color.
+		for _, p := range []hex.Position{{Q: 1, R: 0}, {Q: 2, R: 0}, {Q: 3, R: 2}} {
+			c := layout.HexToPixel(p)
+			want := DefaultPalette.Color((p.Q + p.R) % 2)
+			assert.Equal(t, want, img.RGBAAt(int(c.X), int(c.Y)), "%s %s", o, p)
+		}
+		// The missing cell is transparent.
+		c := layout.HexToPixel(hex.NewPosition(0, 0))
+		assert.Equal(t, color.RGBA{}, img.RGBAAt(int(c.X), int(c.Y)))
+	}
+}
+
+func TestRenderImage_AntiAliased(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	img, err := RenderImage(m, ImageOptions{Scale: 12})
+	require.NoError(t, err)
+
+	// Edge pixels blend colors, so the image holds more than the two terrain
+	// colors and transparency.
+	seen := make(map[color.RGBA]bool)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			seen[img.RGBAAt(x, y)] = true
+		}
+	}
+	assert.True(t, seen[DefaultPalette[generator.Water]])
+	assert.True(t, seen[DefaultPalette[generator.Land]])
+	assert.Greater(t, len(seen), 3)
+}
+
+func TestRenderImage_Scale(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	small, err := RenderImage(m, ImageOptions{Scale: 5})
+	require.NoError(t, err)
+	large, err := RenderImage(m, ImageOptions{Scale: 10, Margin: 3})
+	require.NoError(t, err)
+	assert.InDelta(t, 2*small.Bounds().Dx()+6, large.Bounds().Dx(), 2)
+	assert.InDelta(t, 2*small.Bounds().Dy()+6, large.Bounds().Dy(), 2)
+
+	_, err = RenderImage(m, ImageOptions{Layer: "missing"})
+	assert.Error(t, err)
+}
+
+func TestEncodePNG(t *testing.T) {
+	square := shapes.NewSquare(0, 0, 4, "square")
+	g, err := generator.GridFromShape(square)
+	require.NoError(t, err)
+	m := hex.NewMap(4, 4)
+	require.NoError(t, m.AddGrid(g))
+	red := color.RGBA{R: 0xff, A: 0xff}
+	value, err := square.GetColorAt(0, 0)
+	require.NoError(t, err)
+	palette := ShapePalette(map[shapes.Color]color.RGBA{value: red})
+	bg := color.RGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xff}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodePNG(&buf, m, ImageOptions{Palette: palette, Background: bg}))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBAModel.Convert(bg), color.RGBAModel.Convert(img.At(0, 0)))
+	found := false
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y && !found; y++ {
+		for x := b.Min.X; x < b.Max.X && !found; x++ {
+			found = color.RGBAModel.Convert(img.At(x, y)) == red
+		}
+	}
+	assert.True(t, found, "no pixel has the shape's palette color")
+}
@@ -0,0 +1,88 @@
+package viz
+
+import (
+	"sort"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// hexFill is a map position to draw and the value that colors it. Set is
+// false for positions that only exist in layers other than the colored one.
+type hexFill struct {
+	Position hex.Position
+	Value    int
+	Set      bool
+}
+
+// mapFills returns every position covered by a layer of m, valued from the
+// named layer, or from the first layer when layer is empty.
+func mapFills(m hex.Map, layer string) ([]hexFill, error) {
+	grids := m.GetGrids()
+	if len(grids) == 0 {
+		return nil, nil
+	}
+	colored := grids[0]
+	if layer != "" {
+		g, err := m.GetGridByName(layer)
+		if err != nil {
+			return nil, err
+		}
+		colored = g
+	}
+	return collectFills(grids, colored), nil
+}
+
+// collectFills merges the cells of grids into fills sorted by row, then column.
+func collectFills(grids []hex.Grid, colored hex.Grid) []hexFill {
+	index := make(map[hex.Position]int)
+	var fills []hexFill
+	for _, g := range grids {
+		for i := 0; i < g.GetCellCount(); i++ {
+			cell, _ := g.GetCellAtIndex(i)
+			if cell == nil {
+				continue
+			}
+			pos := cell.GetPosition().Add(g.GetPosition())
+			j, seen := index[pos]
+			if !seen {
+				j = len(fills)
+				index[pos] = j
+				fills = append(fills, hexFill{Position: pos})
+			}
+			if g == colored {
+				fills[j].Value, fills[j].Set = cell.GetValue(), true
+			}
+		}
+	}
+	sort.Slice(fills, func(i, j int) bool {
+		a, b := fills[i].Position, fills[j].Position
+		if a.R != b.R {
+			return a.R < b.R
+		}
+		return a.Q < b.Q
+	})
+	return fills
+}
+
+// fillPositions returns the positions of fills.
+func fillPositions(fills []hexFill) []hex.Position {
+	positions := make([]hex.Position, len(fills))
+	for i, f := range fills {
+		positions[i] = f.Position
+	}
+	return positions
+}
+
+// layerCell returns the cell of g at a map position, or nil if g has none
+// there. Unlike Grid.GetCellAtPosition it never wraps around the grid.
+func layerCell(g hex.Grid, pos hex.Position) hex.Cell {
+	local := pos.Sub(g.GetPosition())
+	if local.Q < 0 || local.R < 0 || local.Q >= g.GetWidth() || local.R >= g.GetHeight() {
+		return nil
+	}
+	cell, err := g.GetCellAt(local.Q, local.R)
+	if err != nil {
+		return nil
+	}
+	return cell
+}
@@ -0,0 +1,175 @@
+package viz
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klumhru/4hex/hex"
+)
+
+// SVGOptions configures SVG rendering.
+type SVGOptions struct {
+	// Orientation selects pointy or flat topped hexes.
+	Orientation hex.Orientation
+	// Size is the hex radius in pixels. Defaults to 24.
+	Size float64
+	// Margin is the space around the map in pixels. Defaults to Size/2.
+	Margin float64
+	// Layer names the map layer whose values color the hexes. Defaults to the
+	// first layer.
+	Layer string
+	// Palette maps values to fill colors. Defaults to DefaultPalette.
+	Palette Palette
+	// Labels draws the axial coordinates in each hex.
+	Labels bool
+	// Units are drawn as labeled markers on their positions.
+	Units []hex.Unit
+	// Paths are drawn as lines through the centers of their positions.
+	Paths [][]hex.Position
+	// Overlay is drawn on top of the map, its path as a line like Paths.
+	Overlay *Overlay
+}
+
+func (o *SVGOptions) defaults() {
+	if o.Size <= 0 {
+		o.Size = 24
+	}
+	if o.Margin <= 0 {
+		o.Margin = o.Size / 2
+	}
+	if o.Palette == nil {
+		o.Palette = DefaultPalette
+	}
+}
+
+// RenderSVG writes an SVG image of m. Every position covered by a layer is
+// drawn; positions without a value in the colored layer are drawn grey.
+func RenderSVG(w io.Writer, m hex.Map, opts SVGOptions) error {
+	fills, err := mapFills(m, opts.Layer)
+	if err != nil {
+		return err
+	}
+	return renderSVG(w, fills, opts)
+}
+
+// RenderGridSVG writes an SVG image of a single grid, colored by its values.
+func RenderGridSVG(w io.Writer, g hex.Grid, opts SVGOptions) error {
+	return renderSVG(w, collectFills([]hex.Grid{g}, g), opts)
+}
+
+func renderSVG(w io.Writer, fills []hexFill, opts SVGOptions) error {
+	opts.defaults()
+	layout := hex.NewLayout(opts.Orientation, opts.Size)
+	lo, hi := layout.Bounds(fillPositions(fills))
+	layout.Origin = hex.Point{X: opts.Margin - lo.X, Y: opts.Margin - lo.Y}
+	width, height := hi.X-lo.X+2*opts.Margin, hi.Y-lo.Y+2*opts.Margin
+
+	overlay := opts.Overlay.index()
+	paths := opts.Paths
+	if opts.Overlay != nil && len(opts.Overlay.Path) > 0 {
+		paths = append(paths[:len(paths):len(paths)], opts.Overlay.Path)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		num(width), num(height), num(width), num(height))
+
+	fmt.Fprintln(bw, `<g class="hexes" stroke="#333333" stroke-width="1">`)
+	for _, f := range fills {
+		fill := emptyColor
+		title := f.Position.String()
+		if f.Set {
+			fill = opts.Palette.Color(f.Value)
+			title += fmt.Sprintf(" = %d", f.Value)
+		}
+		fill = overlay.tint(f.Position, fill)
+		corners := layout.Corners(f.Position)
+		fmt.Fprintf(bw, "<polygon points=\"%s\" fill=\"%s\"><title>%s</title></polygon>\n",
+			points(corners[:]), hexColor(fill), escape(title))
+	}
+	fmt.Fprintln(bw, "</g>")
+
+	if opts.Labels {
+		fmt.Fprintf(bw, "<g class=\"labels\" font-family=\"monospace\" font-size=\"%s\" text-anchor=\"middle\" dominant-baseline=\"central\" fill=\"#222222\">\n", num(opts.Size/3))
+		for _, f := range fills {
+			c := layout.HexToPixel(f.Position)
+			fmt.Fprintf(bw, "<text x=\"%s\" y=\"%s\">%d,%d</text>\n", num(c.X), num(c.Y), f.Position.Q, f.Position.R)
+		}
+		fmt.Fprintln(bw, "</g>")
+	}
+
+	if len(paths) > 0 {
+		fmt.Fprintf(bw, "<g class=\"paths\" fill=\"none\" stroke=\"%s\" stroke-width=\"%s\" stroke-linecap=\"round\" stroke-linejoin=\"round\">\n", hexColor(pathColor), num(opts.Size/6))
+		for _, path := range paths {
+			centers := make([]hex.Point, len(path))
+			for i, p := range path {
+				centers[i] = layout.HexToPixel(p)
+			}
+			fmt.Fprintf(bw, "<polyline points=\"%s\"/>\n", points(centers))
+		}
+		fmt.Fprintln(bw, "</g>")
+	}
+
+	if len(overlay.marks) > 0 || len(overlay.path) > 0 {
+		fmt.Fprintf(bw, "<g class=\"marks\" font-family=\"sans-serif\" font-weight=\"bold\" font-size=\"%s\" text-anchor=\"middle\" dominant-baseline=\"central\" fill=\"#000000\">\n", num(opts.Size/2))
+		for _, f := range fills {
+			if text, ok := overlay.label(f.Position); ok {
+				c := layout.HexToPixel(f.Position)
+				fmt.Fprintf(bw, "<text x=\"%s\" y=\"%s\">%s</text>\n", num(c.X), num(c.Y), escape(text))
+			}
+		}
+		fmt.Fprintln(bw, "</g>")
+	}
+
+	if len(opts.Units) > 0 {
+		fmt.Fprintf(bw, "<g class=\"units\" font-family=\"sans-serif\" font-size=\"%s\" text-anchor=\"middle\" dominant-baseline=\"central\">\n", num(opts.Size/2))
+		for _, u := range opts.Units {
+			if u == nil {
+				continue
+			}
+			c := layout.HexToPixel(u.Position())
+			fmt.Fprintf(bw, "<g class=\"unit\"><title>%s</title><circle cx=\"%s\" cy=\"%s\" r=\"%s\" fill=\"#ffffff\" stroke=\"#000000\"/><text x=\"%s\" y=\"%s\">%s</text></g>\n",
+				escape(u.GetName()), num(c.X), num(c.Y), num(opts.Size/2), num(c.X), num(c.Y), escape(initial(u.GetName())))
+		}
+		fmt.Fprintln(bw, "</g>")
+	}
+
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// points formats points for a polygon or polyline.
+func points(ps []hex.Point) string {
+	parts := make([]string, len(ps))
+	for i, p := range ps {
+		parts[i] = num(p.X) + "," + num(p.Y)
+	}
+	return strings.Join(parts, " ")
+}
+
+// num formats a coordinate with two decimals, trimming trailing zeros.
+func num(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", v), "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// initial returns the upper-cased first letter of a name.
+func initial(name string) string {
+	for _, r := range name {
+		return strings.ToUpper(string(r))
+	}
+	return "?"
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package viz
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klumhru/4hex/generator"
+	"github.com/klumhru/4hex/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGrid returns a w×h grid of alternating water and land with its
+// top-left cell missing.
+func newTestGrid(name string, w, h int) hex.Grid {
+	cells := make([][]hex.Cell, h)
+	for r := range cells {
+		cells[r] = make([]hex.Cell, w)
+		for q := range cells[r] {
+			if q == 0 && r == 0 {
+				continue
+			}
+			cells[r][q] = hex.NewCellWithValue(q, r, (q+r)%2)
+		}
+	}
+	return hex.NewGrid(hex.NewPosition(0, 0), name, w, h, cells)
+}
+
+// svgElements parses an SVG document and counts its elements by name.
+func svgElements(t *testing.T, doc []byte) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if start, ok := tok.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+	return counts
+}
+
+func TestRenderGridSVG(t *testing.T) {
+	for _, o := range []hex.Orientation{hex.PointyTop, hex.FlatTop} {
+		var buf bytes.Buffer
+		require.NoError(t, RenderGridSVG(&buf, newTestGrid("terrain", 4, 3), SVGOptions{Orientation: o}))
+
+		counts := svgElements(t, buf.Bytes())
+		assert.Equal(t, 1, counts["svg"])
+		assert.Equal(t, 11, counts["polygon"])
+		assert.Zero(t, counts["text"])
+		assert.Contains(t, buf.String(), hexColor(DefaultPalette[generator.Water]))
+		assert.Contains(t, buf.String(), hexColor(DefaultPalette[generator.Land]))
+	}
+}
+
+func TestRenderSVG_Overlays(t *testing.T) {
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	scout := hex.NewUnit("scout <1>")
+	scout.Move(hex.NewPosition(1, 1))
+
+	var buf bytes.Buffer
+	err := RenderSVG(&buf, m, SVGOptions{
+		Labels: true,
+		Units:  []hex.Unit{scout},
+		Paths:  [][]hex.Position{{hex.NewPosition(1, 1), hex.NewPosition(2, 1), hex.NewPosition(2, 2)}},
+	})
+	require.NoError(t, err)
+
+	counts := svgElements(t, buf.Bytes())
+	assert.Equal(t, 11, counts["polygon"])
+	assert.Equal(t, 11+1, counts["text"])
+	assert.Equal(t, 1, counts["circle"])
+	assert.Equal(t, 1, counts["polyline"])
+	assert.Contains(t, buf.String(), ">2,1<")
+	assert.Contains(t, buf.String(), "scout &lt;1&gt;")
+}
+
+func TestRenderSVG_Layer(t *testing.T) {
+	resources := hex.NewGrid(hex.NewPosition(1, 1), "resources", 1, 1, [][]hex.Cell{{hex.NewCellWithValue(0, 0, 5)}})
+	m := hex.NewMap(4, 3)
+	require.NoError(t, m.AddGrid(newTestGrid("terrain", 4, 3)))
+	require.NoError(t, m.AddGrid(resources))
+	palette := Palette{5: {R: 0xff, A: 0xff}}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderSVG(&buf, m, SVGOptions{Layer: "resources", Palette: palette}))
+	out := buf.String()
+	// All terrain positions are drawn; only the resource is colored.
+	assert.Equal(t, 11, strings.Count(out, "<polygon"))
+	assert.Equal(t, 1, strings.Count(out, `fill="#ff0000"`))
+	assert.Equal(t, 10, strings.Count(out, `fill="`+hexColor(emptyColor)+`"`))
+
+	assert.Error(t, RenderSVG(&buf, m, SVGOptions{Layer: "missing"}))
+}
+
+func TestRenderSVG_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderSVG(&buf, hex.NewMap(0, 0), SVGOptions{}))
+	counts := svgElements(t, buf.Bytes())
+	assert.Equal(t, 1, counts["svg"])
+	assert.Zero(t, counts["polygon"])
+}
+
+func TestPalette_Color(t *testing.T) {
+	p := Palette{3: {R: 1, G: 2, B: 3, A: 0xff}}
+	assert.Equal(t, "#010203", hexColor(p.Color(3)))
+	// Generated colors are stable and differ between values.
+	assert.Equal(t, p.Color(4), p.Color(4))
+	assert.NotEqual(t, p.Color(4), p.Color(5))
+	assert.Equal(t, uint8(0xff), p.Color(-7).A)
+}
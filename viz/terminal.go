@@ -0,0 +1,87 @@
+package viz
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Terminal control sequences used by RunExplorer.
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+// RunExplorer runs e on a terminal until the user quits. It puts it into raw
+// mode and draws on the alternate screen of out, restoring both on return.
+func RunExplorer(in, out *os.File, e *Explorer) error {
+	fd := in.Fd()
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("explorer needs an interactive terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, leaveScreen)
+
+	buf := make([]byte, 64)
+	for !e.Quit() {
+		if w, h, err := term.GetSize(out.Fd()); err == nil {
+			e.Update(ResizeMsg{Width: w, Height: h})
+		}
+		// Raw mode disables newline translation.
+		fmt.Fprint(out, clearScreen+strings.ReplaceAll(e.View(), "\n", "\r\n"))
+
+		n, err := in.Read(buf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			e.Update(key)
+		}
+	}
+	return nil
+}
+
+// parseKeys decodes the bytes of terminal input into key presses.
+func parseKeys(b []byte) []KeyMsg {
+	var keys []KeyMsg
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) >= 3 && (b[1] == '[' || b[1] == 'O'):
+			switch b[2] {
+			case 'A':
+				keys = append(keys, KeyMsg{Type: KeyUp})
+			case 'B':
+				keys = append(keys, KeyMsg{Type: KeyDown})
+			case 'C':
+				keys = append(keys, KeyMsg{Type: KeyRight})
+			case 'D':
+				keys = append(keys, KeyMsg{Type: KeyLeft})
+			}
+			b = b[3:]
+		case b[0] == 0x1b:
+			keys = append(keys, KeyMsg{Type: KeyEscape})
+			b = b[1:]
+		case b[0] == 0x03:
+			keys = append(keys, KeyMsg{Type: KeyCtrlC})
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, KeyMsg{Type: KeyRune, Rune: r})
+			b = b[size:]
+		}
+	}
+	return keys
+}